@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/handlers"
+	"utara_backend/migrations"
+	"utara_backend/models"
+	"utara_backend/scheduler"
+	"utara_backend/storage"
+)
+
+// command is a subcommand of the backend binary. Every command loads the
+// same .env and database settings as the server. run returns the exit code:
+// 0 on success, 1 on failure and 2 on bad arguments.
+type command struct {
+	name        string
+	args        string
+	description string
+	run         func(args []string) int
+}
+
+var commands = []command{
+	{"serve", "", "Run the HTTP server (default)", serve},
+	{"migrate", "[-status]", "Apply pending database migrations", migrate},
+	{"seed", "[-dining-halls a,b] [-name -email -phone [-password]]", "Seed room types, food pass categories and a first super admin", seed},
+	{"create-admin", "-name -email -phone [-password]", "Create a super admin", createAdmin},
+	{"import-rooms", "[-dry-run] [-upsert] <csv|xlsx>", "Import rooms from a file", importRooms},
+	{"export", "[-format csv|xlsx|ndjson] [-o file] <entity> [key=value ...]", "Export rooms, room-requests, users, payments or food-passes", export},
+	{"run-job", "<name>", "Run a background job now", runJob},
+	{"check-consistency", "[-fix]", "Cross-check rooms, stays, requests, payments and passes", checkConsistency},
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [arguments]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-18s %s\n", cmd.name, cmd.description)
+		if cmd.args != "" {
+			fmt.Fprintf(w, "  %-18s   %s %s\n", "", cmd.name, cmd.args)
+		}
+	}
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v any) int {
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	if err := out.Encode(v); err != nil {
+		log.Println("Error writing output:", err)
+		return 1
+	}
+	return 0
+}
+
+// migrate applies the pending database migrations, or lists them all with
+// -status
+func migrate(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	status := flags.Bool("status", false, "list migrations and when they were applied without applying any")
+	flags.Parse(args)
+
+	if *status {
+		statuses, err := migrations.Status(context.Background())
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+		return printJSON(statuses)
+	}
+
+	// Some steps move files between storage backends
+	if err := storage.Init(); err != nil {
+		log.Println("Failed to configure storage:", err)
+		return 1
+	}
+	ran, err := migrations.Up(context.Background())
+	for _, m := range ran {
+		log.Printf("Applied migration %d: %s (%dms)", m.Version, m.Name, m.DurationMs)
+	}
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	if len(ran) == 0 {
+		log.Println("No pending migrations")
+	}
+	return 0
+}
+
+// adminFlags are the details of a super admin given on the command line.
+// The password can come from ADMIN_PASSWORD instead, to keep it out of the
+// shell history.
+type adminFlags struct {
+	name, email, phone, password *string
+}
+
+func addAdminFlags(flags *flag.FlagSet) adminFlags {
+	return adminFlags{
+		name:     flags.String("name", "", "admin name"),
+		email:    flags.String("email", "", "admin email, used to log in"),
+		phone:    flags.String("phone", "", "admin phone number"),
+		password: flags.String("password", "", "admin password, defaults to $ADMIN_PASSWORD"),
+	}
+}
+
+func (f adminFlags) given() bool {
+	return *f.name != "" || *f.email != "" || *f.phone != ""
+}
+
+// create inserts the super admin, refusing an email already in use
+func (f adminFlags) create() (models.User, error) {
+	req := models.SignupRequest{
+		Name:        strings.TrimSpace(*f.name),
+		Email:       strings.TrimSpace(*f.email),
+		PhoneNumber: strings.TrimSpace(*f.phone),
+		Password:    *f.password,
+		Role:        models.RoleSuperAdmin,
+	}
+	if req.Password == "" {
+		req.Password = os.Getenv("ADMIN_PASSWORD")
+	}
+	if req.Name == "" || req.Email == "" || req.PhoneNumber == "" {
+		return models.User{}, errors.New("-name, -email and -phone are required")
+	}
+	if len(req.Password) < 6 {
+		return models.User{}, errors.New("password must be at least 6 characters, give -password or set ADMIN_PASSWORD")
+	}
+
+	err := config.DB.Collection("users").FindOne(context.Background(), bson.M{"email": req.Email}).Err()
+	if err == nil {
+		return models.User{}, errors.New("user already exists")
+	}
+	if err != mongo.ErrNoDocuments {
+		return models.User{}, err
+	}
+	return handlers.InsertUser(req)
+}
+
+func createAdmin(args []string) int {
+	flags := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	admin := addAdminFlags(flags)
+	flags.Parse(args)
+
+	user, err := admin.create()
+	if err != nil {
+		log.Println("Error creating admin:", err)
+		return 1
+	}
+	user.Password = ""
+	return printJSON(user)
+}
+
+// firstSuperAdmin returns the oldest super admin
+func firstSuperAdmin() (models.User, error) {
+	var user models.User
+	err := config.DB.Collection("users").FindOne(
+		context.Background(),
+		bson.M{"role": models.RoleSuperAdmin},
+		options.FindOne().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	).Decode(&user)
+	return user, err
+}
+
+// seed fills an empty database with the defaults the server needs. Room
+// types and dining halls are only seeded into empty collections, and the
+// admin only if there is no super admin yet.
+func seed(args []string) int {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	halls := flags.String("dining-halls", "", "comma separated dining halls, defaults to the buildings with rooms")
+	admin := addAdminFlags(flags)
+	flags.Parse(args)
+
+	var diningHalls []string
+	if *halls != "" {
+		diningHalls = strings.Split(*halls, ",")
+	}
+	roomTypes, categories, err := handlers.SeedDefaults(diningHalls)
+	if err != nil {
+		log.Println("Seeding failed:", err)
+		return 1
+	}
+	result := gin.H{"room_types_seeded": roomTypes, "food_pass_categories_seeded": categories}
+
+	_, err = firstSuperAdmin()
+	switch {
+	case err == nil:
+		result["admin"] = "a super admin already exists"
+	case err != mongo.ErrNoDocuments:
+		log.Println("Error checking for a super admin:", err)
+		return 1
+	case !admin.given():
+		log.Println("No super admin exists yet, give -name, -email and -phone to create one")
+		result["admin"] = "not created"
+	default:
+		user, err := admin.create()
+		if err != nil {
+			log.Println("Error creating admin:", err)
+			return 1
+		}
+		result["admin"] = user.Email
+	}
+	return printJSON(result)
+}
+
+func importRooms(args []string) int {
+	flags := flag.NewFlagSet("import-rooms", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "check the file without writing rooms")
+	upsert := flags.Bool("upsert", false, "update rooms that already exist instead of skipping them")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		log.Println("Usage: import-rooms [-dry-run] [-upsert] <csv|xlsx>")
+		return 2
+	}
+
+	report, err := handlers.ImportRoomsFile(flags.Arg(0), *dryRun, *upsert)
+	if err != nil {
+		log.Println("Import failed:", err)
+		return 1
+	}
+	if code := printJSON(report); code != 0 {
+		return code
+	}
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// export writes a dataset with the same filters as its endpoint, given as
+// key=value arguments. It covers every record rather than one user's.
+func export(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "csv", "csv, xlsx or ndjson")
+	output := flags.String("o", "", "file to write, defaults to stdout")
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		log.Println("Usage: export [-format csv|xlsx|ndjson] [-o file] <entity> [key=value ...]")
+		return 2
+	}
+	if !slices.Contains(handlers.ExportDatasets(), flags.Arg(0)) {
+		log.Printf("Unknown entity %q, use %s", flags.Arg(0), strings.Join(handlers.ExportDatasets(), ", "))
+		return 2
+	}
+	filters := url.Values{}
+	for _, arg := range flags.Args()[1:] {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			log.Printf("Invalid filter %q, use key=value", arg)
+			return 2
+		}
+		filters.Add(key, value)
+	}
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Println("Error creating output file:", err)
+			return 1
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if err := handlers.Export(out, flags.Arg(0), *format, filters); err != nil {
+		log.Println("Export failed:", err)
+		return 1
+	}
+	return 0
+}
+
+// runJob runs a registered job to completion, ignoring its schedule and
+// paused flag as a manual run from the admin API does
+func runJob(args []string) int {
+	flags := flag.NewFlagSet("run-job", flag.ExitOnError)
+	flags.Parse(args)
+
+	startServices()
+	handlers.RegisterJobs()
+	if flags.NArg() != 1 {
+		log.Println("Usage: run-job <name>")
+		for _, job := range scheduler.Jobs() {
+			log.Printf("  %-22s %s", job.Name, job.Description)
+		}
+		return 2
+	}
+
+	run, err := scheduler.RunNow(flags.Arg(0), "cli")
+	if err != nil {
+		log.Println("Error running job:", err)
+		return 1
+	}
+	if code := printJSON(run); code != 0 {
+		return code
+	}
+	if run.Status != models.JobRunSuccess {
+		return 1
+	}
+	return 0
+}
+
+// checkConsistency prints the consistency report. It exits 1 while
+// violations remain unfixed, so it can gate deploys or alert from cron.
+func checkConsistency(args []string) int {
+	flags := flag.NewFlagSet("check-consistency", flag.ExitOnError)
+	fix := flags.Bool("fix", false, "fix the violations that are safe to fix")
+	flags.Parse(args)
+
+	report, err := handlers.RunConsistencyCheck(*fix)
+	if err != nil {
+		log.Println("Consistency check failed:", err)
+		return 1
+	}
+	if code := printJSON(report); code != 0 {
+		return code
+	}
+	if len(report.Violations) > report.Fixed {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,144 @@
+package events
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"utara_backend/models"
+)
+
+type Type string
+
+const (
+	RequestCreated       Type = "REQUEST_CREATED"
+	RequestStatusChanged Type = "REQUEST_STATUS_CHANGED"
+	CheckedIn            Type = "CHECKED_IN"
+	CheckedOut           Type = "CHECKED_OUT"
+	RoomCleaned          Type = "ROOM_CLEANED"
+	FoodPassScanned      Type = "FOOD_PASS_SCANNED"
+)
+
+// Event is something that happened which dashboards may want to show.
+// Building is empty for events not tied to one, which every building sees.
+// UserID is the guest the event is about.
+type Event struct {
+	ID       primitive.ObjectID  `json:"id" bson:"_id"`
+	Type     Type                `json:"type" bson:"type"`
+	Building string              `json:"building,omitempty" bson:"building,omitempty"`
+	UserID   *primitive.ObjectID `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	Data     map[string]any      `json:"data,omitempty" bson:"data,omitempty"`
+	At       time.Time           `json:"at" bson:"at"`
+}
+
+// housekeepingEvents are the events housekeeping staff see
+var housekeepingEvents = []Type{CheckedIn, CheckedOut, RoomCleaned}
+
+// Subscriber is who is listening. Staff see every event, housekeeping sees
+// room events and guests see events about themselves. Buildings, if set,
+// limits the events to those buildings.
+type Subscriber struct {
+	UserID    primitive.ObjectID
+	Role      models.UserRole
+	Buildings []string
+}
+
+// Allows reports whether the subscriber may see the event
+func (s Subscriber) Allows(e Event) bool {
+	if len(s.Buildings) > 0 && e.Building != "" && !slices.Contains(s.Buildings, e.Building) {
+		return false
+	}
+	switch s.Role {
+	case models.RoleSuperAdmin, models.RoleStaff:
+		return true
+	case models.RoleHousekeeping:
+		return slices.Contains(housekeepingEvents, e.Type)
+	default:
+		return e.UserID != nil && *e.UserID == s.UserID
+	}
+}
+
+// subscriberBuffer is how many events a slow subscriber can fall behind
+// before further events to it are dropped
+const subscriberBuffer = 64
+
+type subscription struct {
+	subscriber Subscriber
+	events     chan Event
+}
+
+var (
+	mu            sync.RWMutex
+	subscriptions = map[*subscription]struct{}{}
+
+	// publish is how events leave this instance, set by Init
+	publish = dispatch
+)
+
+// Init picks how events are shared from EVENT_BUS: "memory" (default) only
+// reaches subscribers on this instance, "mongo" stores events and follows a
+// change stream so every replica sees them. Change streams need MongoDB to
+// run as a replica set.
+func Init() error {
+	switch backend := strings.ToLower(os.Getenv("EVENT_BUS")); backend {
+	case "", "memory":
+		publish = dispatch
+	case "mongo":
+		if err := startMongo(); err != nil {
+			return fmt.Errorf("events: %w", err)
+		}
+		publish = storeMongo
+	default:
+		return fmt.Errorf("unknown event bus %q", backend)
+	}
+	return nil
+}
+
+// Publish sends an event to its subscribers. It never blocks the caller on a
+// subscriber.
+func Publish(e Event) {
+	e.ID = primitive.NewObjectID()
+	e.At = time.Now()
+	publish(e)
+}
+
+// Subscribe starts delivering events the subscriber may see. The returned
+// function stops delivery and closes the channel.
+func Subscribe(s Subscriber) (<-chan Event, func()) {
+	sub := &subscription{subscriber: s, events: make(chan Event, subscriberBuffer)}
+	mu.Lock()
+	subscriptions[sub] = struct{}{}
+	mu.Unlock()
+
+	var once sync.Once
+	return sub.events, func() {
+		once.Do(func() {
+			mu.Lock()
+			delete(subscriptions, sub)
+			mu.Unlock()
+			close(sub.events)
+		})
+	}
+}
+
+// dispatch hands an event to the subscribers on this instance
+func dispatch(e Event) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for sub := range subscriptions {
+		if !sub.subscriber.Allows(e) {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			log.Printf("events: dropped %s for slow subscriber %s", e.Type, sub.subscriber.UserID.Hex())
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package events
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+)
+
+// eventRetention is how long published events stay in the events collection
+const eventRetention = 24 * time.Hour
+
+func eventsCollection() *mongo.Collection {
+	return config.DB.Collection("domain_events")
+}
+
+// startMongo opens the change stream up front, so a deployment without a
+// replica set fails at startup rather than silently dropping events
+func startMongo() error {
+	_, err := eventsCollection().Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(eventRetention.Seconds())),
+	})
+	if err != nil {
+		return err
+	}
+	stream, err := watch(nil)
+	if err != nil {
+		return err
+	}
+	go follow(stream)
+	return nil
+}
+
+func watch(resumeAfter bson.Raw) (*mongo.ChangeStream, error) {
+	opts := options.ChangeStream()
+	if resumeAfter != nil {
+		opts.SetResumeAfter(resumeAfter)
+	}
+	return eventsCollection().Watch(
+		context.Background(),
+		mongo.Pipeline{{{Key: "$match", Value: bson.M{"operationType": "insert"}}}},
+		opts,
+	)
+}
+
+// follow dispatches events inserted by any instance, reopening the stream
+// from where it left off if it breaks
+func follow(stream *mongo.ChangeStream) {
+	for {
+		for stream.Next(context.Background()) {
+			var change struct {
+				FullDocument Event `bson:"fullDocument"`
+			}
+			if err := stream.Decode(&change); err != nil {
+				log.Printf("events: failed to decode change: %v", err)
+				continue
+			}
+			dispatch(change.FullDocument)
+		}
+		log.Printf("events: change stream stopped: %v", stream.Err())
+		resumeAfter := stream.ResumeToken()
+		stream.Close(context.Background())
+
+		for {
+			time.Sleep(5 * time.Second)
+			var err error
+			if stream, err = watch(resumeAfter); err == nil {
+				break
+			}
+			log.Printf("events: failed to reopen change stream: %v", err)
+			// The resume point may have aged out of the oplog
+			resumeAfter = nil
+		}
+	}
+}
+
+// storeMongo publishes through the events collection. The change stream
+// brings the event back to this instance's subscribers too.
+func storeMongo(e Event) {
+	if _, err := eventsCollection().InsertOne(context.Background(), e); err != nil {
+		log.Printf("events: failed to store %s: %v", e.Type, err)
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"utara_backend/config"
+	"utara_backend/models"
+)
+
+func TestListFiltersScopeNonStaff(t *testing.T) {
+	query := url.Values{"user_id": {primitive.NewObjectID().Hex()}, "out_of_order": {"true"}}
+	for _, role := range []models.UserRole{models.RoleUser, models.RoleHousekeeping, models.RoleStaff, models.RoleSuperAdmin} {
+		user := models.User{ID: primitive.NewObjectID(), Role: role}
+		isStaff := role == models.RoleStaff || role == models.RoleSuperAdmin
+
+		requests := roomRequestListFilter(query, &user)
+		if own := requests["user_id"] == user.ID; own == isStaff {
+			t.Errorf("%s: room requests filtered to their own: %v", role, own)
+		}
+
+		rooms, err := roomListFilter(query, &user, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if visibleOnly := rooms["is_visible"] == true; visibleOnly == isStaff {
+			t.Errorf("%s: rooms limited to visible ones: %v", role, visibleOnly)
+		}
+	}
+}
+
+// serveAs runs a handler for a request made by user
+func serveAs(user models.User, handler gin.HandlerFunc, target string, params gin.Params) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	c.Params = params
+	c.Set("user_id", user.ID.Hex())
+	c.Set("user_role", string(user.Role))
+	handler(c)
+	return w
+}
+
+func TestHousekeepingSeesOnlyOwnData(t *testing.T) {
+	useTestDB(t)
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	housekeeper := models.User{ID: primitive.NewObjectID(), Name: "Housekeeper", Role: models.RoleHousekeeping}
+	guest := models.User{ID: primitive.NewObjectID(), Name: "Guest", Role: models.RoleUser}
+	for _, user := range []models.User{housekeeper, guest} {
+		if _, err := config.DB.Collection("users").InsertOne(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	request := models.RoomRequest{ID: primitive.NewObjectID(), UserID: guest.ID, Name: "Guest", Status: models.StatusApproved}
+	if _, err := config.DB.Collection("room_requests").InsertOne(ctx, request); err != nil {
+		t.Fatal(err)
+	}
+	pass := models.FoodPass{ID: primitive.NewObjectID(), UserID: guest.ID, MemberName: "Guest Member"}
+	if _, err := config.DB.Collection("food_passes").InsertOne(ctx, pass); err != nil {
+		t.Fatal(err)
+	}
+
+	w := serveAs(housekeeper, GetRoomRequestByID, "/room-requests/"+request.ID.Hex(), gin.Params{{Key: "id", Value: request.ID.Hex()}})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("housekeeper fetching a guest's request got %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	w = serveAs(housekeeper, GetUserFoodPasses, "/food-passes/user/"+guest.ID.Hex(), gin.Params{{Key: "user_id", Value: guest.ID.Hex()}})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("housekeeper listing a guest's passes got %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	w = serveAs(housekeeper, GetFoodPassWallet, "/food-passes/wallet?include_past=true&user_id="+guest.ID.Hex(), nil)
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), pass.MemberName) {
+		t.Errorf("housekeeper opening a guest's wallet got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Their own request is still theirs to see
+	own := models.RoomRequest{ID: primitive.NewObjectID(), UserID: housekeeper.ID, Status: models.StatusPending}
+	if _, err := config.DB.Collection("room_requests").InsertOne(ctx, own); err != nil {
+		t.Fatal(err)
+	}
+	w = serveAs(housekeeper, GetRoomRequestByID, "/room-requests/"+own.ID.Hex(), gin.Params{{Key: "id", Value: own.ID.Hex()}})
+	if w.Code != http.StatusOK {
+		t.Errorf("housekeeper fetching their own request got %d", w.Code)
+	}
+}
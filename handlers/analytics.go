@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+const (
+	analyticsDefaultDays = 30
+	analyticsMaxDays     = 366
+	analyticsTopValues   = 10
+)
+
+// percent is n out of total as a percentage to one decimal place
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(n)*1000/float64(total)) / 10
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// analyticsRange reads ?from= and ?to= (YYYY-MM-DD, IST, both inclusive),
+// defaulting to the last 30 days. It returns the start of the first day and
+// the end of the last.
+func analyticsRange(c *gin.Context) (time.Time, time.Time, bool) {
+	end := utils.StartOfDayIST(time.Now()).AddDate(0, 0, 1)
+	if to := c.Query("to"); to != "" {
+		t, err := time.ParseInLocation("2006-01-02", to, utils.IST)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, use YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+		end = t.AddDate(0, 0, 1)
+	}
+	start := end.AddDate(0, 0, -analyticsDefaultDays)
+	if from := c.Query("from"); from != "" {
+		t, err := time.ParseInLocation("2006-01-02", from, utils.IST)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, use YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+		start = t
+	}
+	if !start.Before(end) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return time.Time{}, time.Time{}, false
+	}
+	if end.Sub(start) > analyticsMaxDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Date range can be at most 366 days"})
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+// topValues counts values case-insensitively, most common first, showing
+// each under its first spelling
+func topValues(values []string, limit int) []models.CountedValue {
+	counts := map[string]*models.CountedValue{}
+	var order []*models.CountedValue
+	for _, value := range values {
+		value = strings.Join(strings.Fields(value), " ")
+		if value == "" {
+			continue
+		}
+		key := strings.ToLower(value)
+		if counts[key] == nil {
+			counts[key] = &models.CountedValue{Value: value}
+			order = append(order, counts[key])
+		}
+		counts[key].Count++
+	}
+	slices.SortStableFunc(order, func(a, b *models.CountedValue) int { return b.Count - a.Count })
+
+	top := []models.CountedValue{}
+	for _, value := range order {
+		if limit > 0 && len(top) == limit {
+			break
+		}
+		top = append(top, *value)
+	}
+	return top
+}
+
+// latencyStats summarises durations, using nearest rank percentiles
+func latencyStats(durations []time.Duration) models.LatencyStats {
+	stats := models.LatencyStats{Count: len(durations)}
+	if len(durations) == 0 {
+		return stats
+	}
+	slices.Sort(durations)
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	rank := func(p float64) time.Duration {
+		return durations[int(math.Ceil(p*float64(len(durations))))-1]
+	}
+	stats.Average = round1(total.Hours() / float64(len(durations)))
+	stats.Median = round1(rank(0.5).Hours())
+	stats.P90 = round1(rank(0.9).Hours())
+	stats.Max = round1(durations[len(durations)-1].Hours())
+	return stats
+}
+
+// stayNights is the number of nights a stay covers, at least one
+func stayNights(a models.RoomAssignment) int {
+	nights := int(math.Round(utils.StartOfDayIST(a.CheckOutDate).Sub(utils.StartOfDayIST(a.CheckInDate)).Hours() / 24))
+	return max(nights, 1)
+}
+
+// occupancyAnalytics fills in the occupancy, arrival and departure figures.
+// Past stays only count if the guest checked in, so no-shows don't inflate
+// occupancy; stays from today on count as booked.
+func occupancyAnalytics(report *models.Analytics, start, end time.Time, building string) error {
+	roomFilter := bson.M{}
+	if building != "" {
+		roomFilter["building"] = building
+	}
+	cursor, err := config.DB.Collection("rooms").Find(context.Background(), roomFilter)
+	if err != nil {
+		return err
+	}
+	var rooms []models.Room
+	if err := cursor.All(context.Background(), &rooms); err != nil {
+		return err
+	}
+	roomsByID := map[primitive.ObjectID]models.Room{}
+	roomIDs := make([]primitive.ObjectID, 0, len(rooms))
+	for _, room := range rooms {
+		roomsByID[room.ID] = room
+		roomIDs = append(roomIDs, room.ID)
+	}
+
+	cursor, err = config.DB.Collection("room_assignments").Find(context.Background(), bson.M{
+		"room_id":        bson.M{"$in": roomIDs},
+		"check_in_date":  bson.M{"$lt": end},
+		"check_out_date": bson.M{"$gt": start},
+	})
+	if err != nil {
+		return err
+	}
+	var assignments []models.RoomAssignment
+	if err := cursor.All(context.Background(), &assignments); err != nil {
+		return err
+	}
+	today := utils.StartOfDayIST(time.Now())
+	stays := assignments[:0]
+	for _, a := range assignments {
+		if a.CheckedIn || !a.CheckInDate.Before(today) {
+			stays = append(stays, a)
+		}
+	}
+
+	buildingNights := map[string]int{}
+	typeNights := map[string]int{}
+	occupiedNights, days := 0, 0
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		days++
+		daily := models.DailyOccupancy{Date: day.Format("2006-01-02"), Total: len(rooms)}
+		occupied := map[primitive.ObjectID]bool{}
+		for _, a := range stays {
+			checkIn, checkOut := utils.StartOfDayIST(a.CheckInDate), utils.StartOfDayIST(a.CheckOutDate)
+			if checkIn.Equal(day) {
+				daily.Arrivals++
+			}
+			if checkOut.Equal(day) {
+				daily.Departures++
+			}
+			if !checkIn.After(day) && checkOut.After(day) && !occupied[a.RoomID] {
+				occupied[a.RoomID] = true
+				room := roomsByID[a.RoomID]
+				buildingNights[room.Building]++
+				typeNights[string(room.Type)]++
+			}
+		}
+		daily.Occupied = len(occupied)
+		daily.Rate = percent(daily.Occupied, daily.Total)
+		occupiedNights += daily.Occupied
+		report.Daily = append(report.Daily, daily)
+	}
+	report.Occupancy = percent(occupiedNights, len(rooms)*days)
+
+	buildingRooms := map[string]int{}
+	typeRooms := map[string]int{}
+	for _, room := range rooms {
+		buildingRooms[room.Building]++
+		typeRooms[string(room.Type)]++
+	}
+	groups := func(roomCounts, nights map[string]int) []models.GroupOccupancy {
+		result := []models.GroupOccupancy{}
+		for name, count := range roomCounts {
+			result = append(result, models.GroupOccupancy{
+				Name:            name,
+				Rooms:           count,
+				OccupiedNights:  nights[name],
+				AvailableNights: count * days,
+				Rate:            percent(nights[name], count*days),
+			})
+		}
+		slices.SortFunc(result, func(a, b models.GroupOccupancy) int { return strings.Compare(a.Name, b.Name) })
+		return result
+	}
+	report.ByBuilding = groups(buildingRooms, buildingNights)
+	report.ByRoomType = groups(typeRooms, typeNights)
+
+	totalNights := 0
+	for _, a := range stays {
+		if !a.CheckInDate.Before(start) && a.CheckInDate.Before(end) {
+			report.Stays++
+			totalNights += stayNights(a)
+		}
+	}
+	if report.Stays > 0 {
+		report.AverageStayNights = round1(float64(totalNights) / float64(report.Stays))
+	}
+	return nil
+}
+
+// requestAnalytics fills in the figures about room requests created in the range
+func requestAnalytics(report *models.Analytics, start, end time.Time) error {
+	cursor, err := config.DB.Collection("room_requests").Find(
+		context.Background(),
+		bson.M{"created_at": bson.M{"$gte": start, "$lt": end}},
+		options.Find().SetProjection(bson.M{"chitthi": 0, "history": 0}),
+	)
+	if err != nil {
+		return err
+	}
+	var requests []models.RoomRequest
+	if err := cursor.All(context.Background(), &requests); err != nil {
+		return err
+	}
+
+	var statuses, places, purposes, reasons []string
+	var latencies []time.Duration
+	userIDs := map[primitive.ObjectID]bool{}
+	for _, r := range requests {
+		statuses = append(statuses, string(r.Status))
+		places = append(places, r.Place)
+		purposes = append(purposes, r.Purpose)
+		userIDs[r.UserID] = true
+		if r.ProcessedAt != nil && r.ProcessedAt.After(r.CreatedAt) {
+			latencies = append(latencies, r.ProcessedAt.Sub(r.CreatedAt))
+		}
+		if r.Status == models.StatusRejected {
+			reason := r.RejectionReason
+			if reason == "" {
+				reason = "Not given"
+			}
+			reasons = append(reasons, reason)
+		}
+	}
+
+	report.Requests = len(requests)
+	report.RequestsByStatus = topValues(statuses, 0)
+	report.ApprovalLatency = latencyStats(latencies)
+	report.RejectionReasons = topValues(reasons, 0)
+	report.TopPlaces = topValues(places, analyticsTopValues)
+	report.TopPurposes = topValues(purposes, analyticsTopValues)
+
+	report.RepeatGuests.RangeGuests = len(userIDs)
+	if len(userIDs) > 0 {
+		ids := make([]primitive.ObjectID, 0, len(userIDs))
+		for id := range userIDs {
+			ids = append(ids, id)
+		}
+		repeat, err := config.DB.Collection("users").CountDocuments(context.Background(), bson.M{
+			"_id":            bson.M{"$in": ids},
+			"total_bookings": bson.M{"$gte": 2},
+		})
+		if err != nil {
+			return err
+		}
+		report.RepeatGuests.RangeRepeat = int(repeat)
+	}
+	return nil
+}
+
+// repeatGuestAnalytics counts guests by their lifetime bookings
+func repeatGuestAnalytics(stats *models.RepeatGuestStats) error {
+	cursor, err := config.DB.Collection("users").Find(
+		context.Background(),
+		bson.M{"role": models.RoleUser, "total_bookings": bson.M{"$gte": 1}},
+		options.Find().SetProjection(bson.M{"total_bookings": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	var users []models.User
+	if err := cursor.All(context.Background(), &users); err != nil {
+		return err
+	}
+
+	buckets := []struct {
+		name     string
+		min, max int
+	}{{"1", 1, 1}, {"2", 2, 2}, {"3-5", 3, 5}, {"6-10", 6, 10}, {"11+", 11, math.MaxInt}}
+	counts := make([]int, len(buckets))
+	bookings := 0
+	for _, u := range users {
+		bookings += u.TotalBookings
+		if u.TotalBookings >= 2 {
+			stats.RepeatGuests++
+		}
+		for i, b := range buckets {
+			if u.TotalBookings >= b.min && u.TotalBookings <= b.max {
+				counts[i]++
+			}
+		}
+	}
+
+	stats.Guests = len(users)
+	stats.RepeatRate = percent(stats.RepeatGuests, stats.Guests)
+	if stats.Guests > 0 {
+		stats.AverageBookings = round1(float64(bookings) / float64(stats.Guests))
+	}
+	stats.Distribution = make([]models.CountedValue, len(buckets))
+	for i, b := range buckets {
+		stats.Distribution[i] = models.CountedValue{Value: b.name, Count: counts[i]}
+	}
+	return nil
+}
+
+// GetAnalytics reports occupancy and operations for ?from= to ?to=
+// (YYYY-MM-DD, IST, default the last 30 days). ?building= limits the
+// occupancy figures to one building; request figures always cover every
+// building as requests aren't tied to one.
+func GetAnalytics(c *gin.Context) {
+	start, end, ok := analyticsRange(c)
+	if !ok {
+		return
+	}
+	report := models.Analytics{
+		From:     start.Format("2006-01-02"),
+		To:       end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Building: c.Query("building"),
+	}
+
+	if err := occupancyAnalytics(&report, start, end, report.Building); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error calculating occupancy"})
+		return
+	}
+	if err := requestAnalytics(&report, start, end); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error calculating request stats"})
+		return
+	}
+	if err := repeatGuestAnalytics(&report.RepeatGuests); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error calculating repeat guest stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -225,6 +225,10 @@ func VerifySignupOTP(c *gin.Context) {
 	}
 
 	result, err := config.DB.Collection("users").InsertOne(context.Background(), user)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User with this email or phone number already exists"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating user"})
 		return
@@ -249,6 +253,37 @@ func VerifySignupOTP(c *gin.Context) {
 	})
 }
 
+// InsertUser hashes the password of a signup and stores the user. It makes
+// none of the checks CreateUser does on who may create which role.
+func InsertUser(req models.SignupRequest) (models.User, error) {
+	// Hash password
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, fmt.Errorf("error hashing password: %w", err)
+	}
+
+	// Create user
+	username, _, _ := strings.Cut(req.Email, "@")
+	user := models.User{
+		Email:       req.Email,
+		UserName:    username,
+		Password:    string(hashedPassword),
+		Name:        req.Name,
+		Role:        req.Role,
+		PhoneNumber: req.PhoneNumber,
+		UserType:    "Neelkanth",
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	result, err := config.DB.Collection("users").InsertOne(context.Background(), user)
+	if err != nil {
+		return models.User{}, fmt.Errorf("error creating user: %w", err)
+	}
+	user.ID = result.InsertedID.(primitive.ObjectID)
+	return user, nil
+}
+
 func CreateUser(c *gin.Context) {
 	var req models.SignupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -265,7 +300,7 @@ func CreateUser(c *gin.Context) {
 	}
 
 	// Validate role
-	if req.Role != models.RoleSuperAdmin && req.Role != models.RoleStaff && req.Role != models.RoleUser {
+	if req.Role != models.RoleSuperAdmin && req.Role != models.RoleStaff && req.Role != models.RoleHousekeeping && req.Role != models.RoleUser {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
 		return
 	}
@@ -279,7 +314,7 @@ func CreateUser(c *gin.Context) {
 
 	// If this is not the first user, apply the regular role checks
 	if count > 0 {
-		if req.Role == models.RoleSuperAdmin || req.Role == models.RoleStaff {
+		if req.Role == models.RoleSuperAdmin || req.Role == models.RoleStaff || req.Role == models.RoleHousekeeping {
 			userID, exists := c.Get("user_id")
 			if !exists {
 				c.JSON(http.StatusForbidden, gin.H{"error": "Only super admin can create admin or staff users"})
@@ -296,41 +331,15 @@ func CreateUser(c *gin.Context) {
 		}
 	}
 
-	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error hashing password"})
+	user, err := InsertUser(req)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User with this email or phone number already exists"})
 		return
 	}
-
-	parts := strings.Split(req.Email, "@")
-	username := ""
-	if len(parts) > 0 {
-		username = parts[0]
-		fmt.Println("Username:", username)
-	} else {
-		fmt.Println("Invalid email format")
-	}
-	// Create user
-	user := models.User{
-		Email:       req.Email,
-		UserName:    username,
-		Password:    string(hashedPassword),
-		Name:        req.Name,
-		Role:        req.Role,
-		PhoneNumber: req.PhoneNumber,
-		UserType:    "Neelkanth",
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}
-
-	result, err := config.DB.Collection("users").InsertOne(context.Background(), user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating user"})
 		return
 	}
-
-	user.ID = result.InsertedID.(primitive.ObjectID)
 	user.Password = "" // Remove password from response
 
 	// Generate JWT token
@@ -547,16 +556,8 @@ func AssignUserType(c *gin.Context) {
 		return
 	}
 
-	allowedUserTypes := map[string]bool{
-		"Shri Hari+": true,
-		"Shri Hari":  true,
-		"Sarju+":     true,
-		"Sarju":      true,
-		"Neelkanth+": true,
-		"Neelkanth":  true,
-	}
-
-	if !allowedUserTypes[req.UserType] {
+	userType, ok := canonicalUserType(req.UserType)
+	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_type"})
 		return
 	}
@@ -564,7 +565,7 @@ func AssignUserType(c *gin.Context) {
 	// Update user struct fields in "users" collection
 	filter := bson.M{"_id": userObjID}
 	update := bson.M{"$set": bson.M{
-		"user_type":  req.UserType,
+		"user_type":  userType,
 		"updated_at": time.Now(),
 	}}
 
@@ -736,3 +737,15 @@ func ResetPassword(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
 }
+
+// getCurrentUser loads the authenticated user from the token's user_id
+func getCurrentUser(c *gin.Context) (models.User, error) {
+	var user models.User
+	userID, _ := c.Get("user_id")
+	userObjID, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", userID))
+	if err != nil {
+		return user, err
+	}
+	err = config.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": userObjID}).Decode(&user)
+	return user, err
+}
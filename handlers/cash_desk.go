@@ -0,0 +1,559 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+var offlinePaymentMethods = map[models.PaymentMethod]bool{
+	models.PaymentMethodCash:         true,
+	models.PaymentMethodUPI:          true,
+	models.PaymentMethodCheque:       true,
+	models.PaymentMethodBankTransfer: true,
+}
+
+func receiptURL(paymentID primitive.ObjectID) string {
+	return "/payments/" + paymentID.Hex() + "/receipt.pdf"
+}
+
+// cashDeskDay parses a YYYY-MM-DD date, or today, as an IST day
+func cashDeskDay(date string) (time.Time, error) {
+	if date == "" {
+		return utils.StartOfDayIST(time.Now()), nil
+	}
+	return time.ParseInLocation("2006-01-02", date, utils.IST)
+}
+
+// cashDeskOpen checks the staff member hasn't closed their drawer for today,
+// after which it can't take or pay out more money
+func cashDeskOpen(c *gin.Context, staffID primitive.ObjectID) bool {
+	today := utils.StartOfDayIST(time.Now()).Format("2006-01-02")
+	closing, err := findCashDeskClosing(staffID, today)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking cash desk closing"})
+		return false
+	}
+	if closing != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Your cash desk is already closed for today"})
+		return false
+	}
+	return true
+}
+
+func findCashDeskClosing(staffID primitive.ObjectID, date string) (*models.CashDeskClosing, error) {
+	var closing models.CashDeskClosing
+	err := config.DB.Collection("cash_desk_closings").FindOne(
+		context.Background(),
+		bson.M{"staff_id": staffID, "date": date},
+	).Decode(&closing)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &closing, nil
+}
+
+// RecordOfflinePayment records a cash, UPI, cheque or bank transfer payment
+// taken at the desk. It settles the payment still open online for the same
+// charge if there is one, and goes through the same quote, deposit, guest
+// pass and invoice bookkeeping as a verified Razorpay payment.
+func RecordOfflinePayment(c *gin.Context) {
+	var req models.RecordOfflinePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	staff, err := getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	req.Method = models.PaymentMethod(strings.ToUpper(string(req.Method)))
+	if !offlinePaymentMethods[req.Method] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "method must be CASH, UPI, CHEQUE or BANK_TRANSFER"})
+		return
+	}
+	req.ReferenceNumber = strings.TrimSpace(req.ReferenceNumber)
+	if req.Method != models.PaymentMethodCash && req.ReferenceNumber == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reference_number is required for " + string(req.Method) + " payments"})
+		return
+	}
+
+	count, err := config.DB.Collection("users").CountDocuments(context.Background(), bson.M{"_id": req.UserID})
+	if err != nil || count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if !cashDeskOpen(c, staff.ID) {
+		return
+	}
+
+	// A payment given by ID is settled as it was created
+	var open *models.Payment
+	if req.PaymentID != nil {
+		paymentID, err := primitive.ObjectIDFromHex(*req.PaymentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+			return
+		}
+		var payment models.Payment
+		err = config.DB.Collection("payments").FindOne(context.Background(), bson.M{"_id": paymentID, "user_id": req.UserID}).Decode(&payment)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+			return
+		}
+		if payment.Status != models.PaymentStatusCreated {
+			c.JSON(http.StatusConflict, gin.H{"error": "Payment is not awaiting payment"})
+			return
+		}
+		if req.Amount != 0 && req.Amount != payment.Amount {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("amount must match the payment's %d", payment.Amount)})
+			return
+		}
+		open = &payment
+		req.Amount, req.Currency, req.Type, req.Description = payment.Amount, payment.Currency, payment.Type, payment.Description
+	}
+
+	var quoteID *primitive.ObjectID
+	if open != nil {
+		quoteID = open.QuoteID
+	} else if req.QuoteID != nil {
+		quote, err := loadPayableQuote(*req.QuoteID, req.UserID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		quoteID = &quote.ID
+		req.Amount = quote.Total
+		req.Currency = quote.Currency
+		req.Type = models.PaymentTypeRoomBooking
+		req.Description = fmt.Sprintf("Room booking, %d nights", quote.Nights)
+		if quote.RequestID != nil {
+			requestID := quote.RequestID.Hex()
+			req.RequestID = &requestID
+		}
+	} else if req.Amount <= 0 || req.Type == "" || req.Description == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount, type and description are required"})
+		return
+	}
+	req.Type = models.PaymentType(strings.ToUpper(string(req.Type)))
+	if !req.Type.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be ROOM_BOOKING, FOOD_PASS, DEPOSIT or OTHER"})
+		return
+	}
+	if req.Currency == "" {
+		req.Currency = "INR"
+	}
+
+	var requestID *primitive.ObjectID
+	if open != nil {
+		requestID = open.RequestID
+	} else if req.RequestID != nil {
+		id, err := primitive.ObjectIDFromHex(*req.RequestID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+			return
+		}
+		requestID = &id
+	}
+
+	// Settle the payment the guest was already asked for online, if any,
+	// rather than leaving it open next to a second payment
+	if open == nil && (requestID != nil || quoteID != nil) {
+		filter := bson.M{
+			"user_id": req.UserID,
+			"status":  models.PaymentStatusCreated,
+			"type":    req.Type,
+			"amount":  req.Amount,
+		}
+		if quoteID != nil {
+			filter["quote_id"] = *quoteID
+		} else {
+			filter["request_id"] = *requestID
+		}
+		var payment models.Payment
+		err := config.DB.Collection("payments").FindOne(
+			context.Background(),
+			filter,
+			options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+		).Decode(&payment)
+		if err == nil {
+			open = &payment
+		} else if err != mongo.ErrNoDocuments {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching payments"})
+			return
+		}
+	}
+
+	now := time.Now()
+	var payment models.Payment
+	if open != nil {
+		set := bson.M{
+			"status":           models.PaymentStatusPaid,
+			"method":           req.Method,
+			"reference_number": req.ReferenceNumber,
+			"collected_by":     staff.ID,
+			"updated_at":       now,
+			"paid_at":          now,
+		}
+		for key, value := range req.Notes {
+			set["notes."+key] = value
+		}
+		err = config.DB.Collection("payments").FindOneAndUpdate(
+			context.Background(),
+			bson.M{"_id": open.ID, "status": models.PaymentStatusCreated},
+			bson.M{"$set": set},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&payment)
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusConflict, gin.H{"error": "Payment was settled meanwhile"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update payment record"})
+			return
+		}
+	} else {
+		payment = models.Payment{
+			UserID:          req.UserID,
+			RequestID:       requestID,
+			QuoteID:         quoteID,
+			Amount:          req.Amount,
+			Currency:        req.Currency,
+			Type:            req.Type,
+			Status:          models.PaymentStatusPaid,
+			Method:          req.Method,
+			ReferenceNumber: req.ReferenceNumber,
+			CollectedBy:     &staff.ID,
+			Description:     req.Description,
+			Notes:           req.Notes,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+			PaidAt:          &now,
+		}
+		result, err := config.DB.Collection("payments").InsertOne(context.Background(), payment)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save payment record"})
+			return
+		}
+		payment.ID = result.InsertedID.(primitive.ObjectID)
+	}
+
+	completePayment(payment)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Payment recorded successfully",
+		"payment":     payment,
+		"receipt_url": receiptURL(payment.ID),
+	})
+}
+
+// refundOfflinePayment records a desk payment being paid back by the current
+// staff member, who is accountable for it in their cash desk report
+func refundOfflinePayment(c *gin.Context, payment models.Payment, amount int, reason string) {
+	staff, err := getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+	if !cashDeskOpen(c, staff.ID) {
+		return
+	}
+
+	now := time.Now()
+	refundID := "offline_" + primitive.NewObjectID().Hex()
+	result, err := config.DB.Collection("payments").UpdateOne(
+		context.Background(),
+		bson.M{"_id": payment.ID, "status": models.PaymentStatusPaid},
+		bson.M{"$set": bson.M{
+			"status":          models.PaymentStatusRefunded,
+			"refund_id":       refundID,
+			"refunded_amount": amount,
+			"refunded_by":     staff.ID,
+			"refunded_at":     now,
+			"updated_at":      now,
+		}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update payment record"})
+		return
+	}
+	if result.ModifiedCount == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payment cannot be refunded - not in paid status"})
+		return
+	}
+
+	payment.Status = models.PaymentStatusRefunded
+	notifyRefundProcessed(payment, amount, reason)
+	if _, err := issueCreditNote(payment, refundID, amount, reason); err != nil {
+		log.Printf("invoice: failed to issue credit note for payment %s: %v", payment.ID.Hex(), err)
+	}
+
+	c.JSON(http.StatusOK, models.RefundResponse{
+		RefundID:   refundID,
+		PaymentID:  payment.ID.Hex(),
+		Amount:     amount,
+		Status:     "processed",
+		ReceiptURL: receiptURL(payment.ID),
+	})
+}
+
+// buildCashDeskReport totals the desk payments a staff member collected and
+// refunded on an IST day
+func buildCashDeskReport(staff models.User, day time.Time) (models.CashDeskReport, error) {
+	report := models.CashDeskReport{
+		StaffID:   staff.ID,
+		StaffName: staff.Name,
+		Date:      day.Format("2006-01-02"),
+		Payments:  []models.Payment{},
+		Refunds:   []models.Payment{},
+	}
+	dayRange := bson.M{"$gte": day, "$lt": day.AddDate(0, 0, 1)}
+
+	cursor, err := config.DB.Collection("payments").Find(context.Background(), bson.M{
+		"collected_by": staff.ID,
+		"paid_at":      dayRange,
+	}, options.Find().SetSort(bson.D{{Key: "paid_at", Value: 1}}))
+	if err != nil {
+		return report, err
+	}
+	if err := cursor.All(context.Background(), &report.Payments); err != nil {
+		return report, err
+	}
+
+	cursor, err = config.DB.Collection("payments").Find(context.Background(), bson.M{
+		"refunded_by": staff.ID,
+		"refunded_at": dayRange,
+	}, options.Find().SetSort(bson.D{{Key: "refunded_at", Value: 1}}))
+	if err != nil {
+		return report, err
+	}
+	if err := cursor.All(context.Background(), &report.Refunds); err != nil {
+		return report, err
+	}
+
+	totals := map[models.PaymentMethod]*models.CashDeskMethodTotal{}
+	total := func(method models.PaymentMethod) *models.CashDeskMethodTotal {
+		if totals[method] == nil {
+			totals[method] = &models.CashDeskMethodTotal{Method: method}
+		}
+		return totals[method]
+	}
+	for _, p := range report.Payments {
+		t := total(p.Method)
+		t.Count++
+		t.Collected += p.Amount
+		report.TotalCollected += p.Amount
+	}
+	for _, p := range report.Refunds {
+		total(p.Method).Refunded += p.RefundedAmount
+		report.TotalRefunded += p.RefundedAmount
+	}
+
+	report.Methods = []models.CashDeskMethodTotal{}
+	for _, t := range totals {
+		t.Net = t.Collected - t.Refunded
+		report.Methods = append(report.Methods, *t)
+	}
+	sort.Slice(report.Methods, func(i, j int) bool { return report.Methods[i].Method < report.Methods[j].Method })
+	if cash := totals[models.PaymentMethodCash]; cash != nil {
+		report.ExpectedCash = cash.Net
+	}
+
+	report.Closing, err = findCashDeskClosing(staff.ID, report.Date)
+	return report, err
+}
+
+// cashDeskStaff is the staff member a report is for: the current user, or
+// with staff_id any user for super admins
+func cashDeskStaff(c *gin.Context) (models.User, bool) {
+	user, err := getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return user, false
+	}
+	staffID := c.Query("staff_id")
+	if staffID == "" || staffID == user.ID.Hex() {
+		return user, true
+	}
+	if user.Role != models.RoleSuperAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only super admins can view other staff members' cash desks"})
+		return user, false
+	}
+
+	id, err := primitive.ObjectIDFromHex(staffID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid staff ID"})
+		return user, false
+	}
+	var staff models.User
+	if err := config.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": id}).Decode(&staff); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Staff member not found"})
+		return staff, false
+	}
+	return staff, true
+}
+
+// GetCashDeskReport returns a staff member's desk takings for a day
+// (?date=YYYY-MM-DD, default today) and its closing if the day is closed
+func GetCashDeskReport(c *gin.Context) {
+	day, err := cashDeskDay(c.Query("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return
+	}
+	staff, ok := cashDeskStaff(c)
+	if !ok {
+		return
+	}
+
+	report, err := buildCashDeskReport(staff, day)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error building cash desk report"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// CloseCashDesk records the cash the current user counted in their drawer
+// against what their desk payments say it should hold. A day closes once.
+func CloseCashDesk(c *gin.Context) {
+	var req models.CloseCashDeskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if *req.DeclaredCash < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "declared_cash can't be negative"})
+		return
+	}
+	day, err := cashDeskDay(req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return
+	}
+	if day.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Can't close a future day"})
+		return
+	}
+
+	staff, err := getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	report, err := buildCashDeskReport(staff, day)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error building cash desk report"})
+		return
+	}
+	if report.Closing != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cash desk is already closed for " + report.Date, "closing": report.Closing})
+		return
+	}
+
+	closing := models.CashDeskClosing{
+		StaffID:      staff.ID,
+		StaffName:    staff.Name,
+		Date:         report.Date,
+		Methods:      report.Methods,
+		ExpectedCash: report.ExpectedCash,
+		DeclaredCash: *req.DeclaredCash,
+		Difference:   *req.DeclaredCash - report.ExpectedCash,
+		PaymentIDs:   []primitive.ObjectID{},
+		RefundIDs:    []primitive.ObjectID{},
+		Notes:        req.Notes,
+		ClosedAt:     time.Now(),
+	}
+	for _, p := range report.Payments {
+		closing.PaymentIDs = append(closing.PaymentIDs, p.ID)
+	}
+	for _, p := range report.Refunds {
+		closing.RefundIDs = append(closing.RefundIDs, p.ID)
+	}
+
+	result, err := config.DB.Collection("cash_desk_closings").InsertOne(context.Background(), closing)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error saving cash desk closing"})
+		return
+	}
+	closing.ID = result.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Cash desk closed successfully",
+		"closing": closing,
+	})
+}
+
+// GetCashDeskClosings lists closings, newest first, filtered by staff_id and
+// a from/to date range (YYYY-MM-DD). Staff only see their own.
+func GetCashDeskClosings(c *gin.Context) {
+	user, err := getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	filter := bson.M{}
+	if user.Role != models.RoleSuperAdmin {
+		filter["staff_id"] = user.ID
+	} else if staffID := c.Query("staff_id"); staffID != "" {
+		id, err := primitive.ObjectIDFromHex(staffID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid staff ID"})
+			return
+		}
+		filter["staff_id"] = id
+	}
+
+	dates := bson.M{}
+	for param, op := range map[string]string{"from": "$gte", "to": "$lte"} {
+		if value := c.Query(param); value != "" {
+			if _, err := time.Parse("2006-01-02", value); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + " date. Use YYYY-MM-DD"})
+				return
+			}
+			dates[op] = value
+		}
+	}
+	if len(dates) > 0 {
+		filter["date"] = dates
+	}
+
+	cursor, err := config.DB.Collection("cash_desk_closings").Find(
+		context.Background(),
+		filter,
+		options.Find().SetSort(bson.D{{Key: "date", Value: -1}, {Key: "staff_name", Value: 1}}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching cash desk closings"})
+		return
+	}
+	closings := []models.CashDeskClosing{}
+	if err := cursor.All(context.Background(), &closings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding cash desk closings"})
+		return
+	}
+	c.JSON(http.StatusOK, closings)
+}
@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/storage"
+	"utara_backend/utils"
+)
+
+// legacyChitthiDir is where chitthi files were written before they moved to
+// private storage. It is no longer served by the /uploads static route.
+const legacyChitthiDir = "./uploads/chitthi"
+
+var chitthiExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+func chitthiPath(requestID primitive.ObjectID) string {
+	return "/room-requests/" + requestID.Hex() + "/chitthi"
+}
+
+// storeChitthi validates an uploaded chitthi (JPEG, PNG or PDF within the
+// upload size limit) and writes it to private storage under a random name
+func storeChitthi(ctx context.Context, fh *multipart.FileHeader, requestID, by primitive.ObjectID) (*models.ChitthiFile, error) {
+	data, err := readUpload(fh)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := chitthiExtensions[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: chitthi must be a JPEG, PNG or PDF file", errInvalidUpload)
+	}
+	if strings.HasPrefix(contentType, "image/") {
+		if _, err := utils.InspectImage(data); err != nil {
+			return nil, fmt.Errorf("%w: chitthi: %v", errInvalidUpload, err)
+		}
+	}
+
+	chitthi := &models.ChitthiFile{
+		Key:         "chitthi/" + requestID.Hex() + "/" + primitive.NewObjectID().Hex() + ext,
+		FileName:    filepath.Base(fh.Filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		UploadedBy:  by,
+		UploadedAt:  time.Now(),
+	}
+	if err := storage.Private.Put(ctx, chitthi.Key, data, contentType); err != nil {
+		return nil, err
+	}
+	return chitthi, nil
+}
+
+// deleteChitthi removes a stored chitthi, or the legacy public file
+func deleteChitthi(request models.RoomRequest) {
+	if request.Chitthi != nil {
+		if err := storage.Private.Delete(context.Background(), request.Chitthi.Key); err != nil {
+			log.Printf("chitthi: failed to delete %s: %v", request.Chitthi.Key, err)
+		}
+		return
+	}
+	if path, ok := legacyChitthiFile(request.ChitthiURL); ok {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("chitthi: failed to delete %s: %v", path, err)
+		}
+	}
+}
+
+// legacyChitthiFile maps an old "/uploads/chitthi/<file>" URL to its path on disk
+func legacyChitthiFile(url string) (string, bool) {
+	name, ok := strings.CutPrefix(url, "/uploads/chitthi/")
+	if !ok || name == "" || name != filepath.Base(name) {
+		return "", false
+	}
+	return filepath.Join(legacyChitthiDir, name), true
+}
+
+// openLegacyChitthi opens a file uploaded before chitthis were validated and
+// sniffs its type
+func openLegacyChitthi(path string) (io.ReadCloser, string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return io.NopCloser(bytes.NewReader(data)), http.DetectContentType(data), nil
+}
+
+// MoveLegacyChitthis moves chitthi files still in the public uploads
+// directory into private storage and points their requests at the copies
+func MoveLegacyChitthis(ctx context.Context) error {
+	cursor, err := config.DB.Collection("room_requests").Find(
+		ctx,
+		bson.M{"chitthi": bson.M{"$exists": false}, "chitthi_url": bson.M{"$regex": "^/uploads/chitthi/"}},
+		options.Find().SetProjection(bson.M{"_id": 1, "user_id": 1, "chitthi_url": 1, "created_at": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var request models.RoomRequest
+		if err := cursor.Decode(&request); err != nil {
+			return err
+		}
+		path, ok := legacyChitthiFile(request.ChitthiURL)
+		if !ok {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			log.Printf("chitthi: %s of request %s is missing, leaving it", path, request.ID.Hex())
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		chitthi := models.ChitthiFile{
+			Key:         "chitthi/" + request.ID.Hex() + "/" + primitive.NewObjectID().Hex() + filepath.Ext(path),
+			FileName:    filepath.Base(path),
+			ContentType: http.DetectContentType(data),
+			Size:        int64(len(data)),
+			UploadedBy:  request.UserID,
+			UploadedAt:  request.CreatedAt,
+		}
+		if err := storage.Private.Put(ctx, chitthi.Key, data, chitthi.ContentType); err != nil {
+			return err
+		}
+		result, err := config.DB.Collection("room_requests").UpdateOne(
+			ctx,
+			bson.M{"_id": request.ID, "chitthi": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"chitthi": chitthi, "chitthi_url": chitthiPath(request.ID)}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount == 0 {
+			// Replaced by a new upload meanwhile
+			storage.Private.Delete(ctx, chitthi.Key)
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			log.Printf("chitthi: failed to delete %s: %v", path, err)
+		}
+	}
+	return cursor.Err()
+}
+
+// loadChitthiRequest fetches a room request the current user may see the
+// chitthi of: their own, or any request for staff
+func loadChitthiRequest(c *gin.Context) (models.RoomRequest, models.User, bool) {
+	var request models.RoomRequest
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+		return request, models.User{}, false
+	}
+
+	user, err := getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return request, user, false
+	}
+
+	err = config.DB.Collection("room_requests").FindOne(context.Background(), bson.M{"_id": id}).Decode(&request)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room request not found"})
+		return request, user, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching room request"})
+		return request, user, false
+	}
+
+	isStaff := user.Role == models.RoleSuperAdmin || user.Role == models.RoleStaff
+	if request.UserID != user.ID && !isStaff {
+		// Don't reveal whether someone else's request exists
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room request not found"})
+		return request, user, false
+	}
+	return request, user, true
+}
+
+// GetRoomRequestChitthi streams the chitthi of a room request to its owner or staff
+func GetRoomRequestChitthi(c *gin.Context) {
+	request, user, ok := loadChitthiRequest(c)
+	if !ok {
+		return
+	}
+
+	var file io.ReadCloser
+	var contentType, fileName string
+	var err error
+	if request.Chitthi != nil {
+		file, err = storage.Private.Open(c.Request.Context(), request.Chitthi.Key)
+		contentType, fileName = request.Chitthi.ContentType, request.Chitthi.FileName
+	} else if path, legacy := legacyChitthiFile(request.ChitthiURL); legacy {
+		file, contentType, err = openLegacyChitthi(path)
+		fileName = filepath.Base(path)
+	} else {
+		err = storage.ErrNotFound
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No chitthi uploaded for this request"})
+		return
+	}
+	if err != nil {
+		log.Printf("chitthi: failed to open for request %s: %v", request.ID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error reading chitthi"})
+		return
+	}
+	defer file.Close()
+
+	// Record staff access in the request's audit trail
+	if request.UserID != user.ID {
+		config.DB.Collection("room_requests").UpdateOne(
+			context.Background(),
+			bson.M{"_id": request.ID},
+			bson.M{"$push": bson.M{"history": models.RoomRequestEvent{
+				Action: models.RequestChitthiViewed,
+				By:     user.ID,
+				At:     time.Now(),
+			}}},
+		)
+	}
+
+	disposition := "inline"
+	if _, allowed := chitthiExtensions[contentType]; !allowed {
+		// Old uploads weren't validated, so never render them in the browser
+		contentType, disposition = "application/octet-stream", "attachment"
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, fileName))
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, -1, contentType, file, nil)
+}
+
+// ReplaceRoomRequestChitthi uploads a new chitthi (multipart field "chitthi")
+// for a pending request, replacing any earlier one
+func ReplaceRoomRequestChitthi(c *gin.Context) {
+	request, user, ok := loadChitthiRequest(c)
+	if !ok {
+		return
+	}
+	if request.Status != models.StatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Chitthi can only be changed while the request is pending"})
+		return
+	}
+
+	fh, err := c.FormFile("chitthi")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "chitthi file is required"})
+		return
+	}
+
+	chitthi, err := storeChitthi(c.Request.Context(), fh, request.ID, user.ID)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	action := models.RequestChitthiUploaded
+	if request.Chitthi != nil || request.ChitthiURL != "" {
+		action = models.RequestChitthiReplaced
+	}
+
+	var updated models.RoomRequest
+	err = config.DB.Collection("room_requests").FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": request.ID, "status": models.StatusPending},
+		bson.M{
+			"$set": bson.M{
+				"chitthi":     chitthi,
+				"chitthi_url": chitthiPath(request.ID),
+				"updated_at":  time.Now(),
+			},
+			"$push": bson.M{"history": models.RoomRequestEvent{
+				Action:  action,
+				Details: chitthi.FileName,
+				By:      user.ID,
+				At:      time.Now(),
+			}},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		storage.Private.Delete(context.Background(), chitthi.Key)
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusConflict, gin.H{"error": "Chitthi can only be changed while the request is pending"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error saving chitthi"})
+		return
+	}
+	deleteChitthi(request)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Chitthi uploaded successfully",
+		"request": updated,
+	})
+}
@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+)
+
+// consistencySystem explains food passes expired by the consistency check
+const consistencySystem = "consistency check"
+
+type consistencyCheck struct {
+	models.ConsistencyReport
+	*stayLookup
+	holding []models.RoomAssignment // Stays holding a room: not checked out and not a no-show
+}
+
+func (r *consistencyCheck) add(check models.ConsistencyCheck, collection string, id primitive.ObjectID, description string, fixable, fixed bool) {
+	r.Violations = append(r.Violations, models.ConsistencyViolation{
+		Check:       check,
+		Collection:  collection,
+		RefID:       id,
+		Description: description,
+		Fixable:     fixable,
+		Fixed:       fixed,
+	})
+	r.Counts[check]++
+	if fixed {
+		r.Fixed++
+	}
+}
+
+// orphan is a document referring to one that doesn't exist
+type orphan struct {
+	ID  primitive.ObjectID `bson:"_id"`
+	Ref primitive.ObjectID `bson:"ref"`
+}
+
+// findOrphans finds the documents of collection matching filter whose field
+// names no document in target
+func findOrphans(collection, field, target string, filter bson.M) ([]orphan, error) {
+	cursor, err := config.DB.Collection(collection).Aggregate(context.Background(), mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$lookup", Value: bson.M{"from": target, "localField": field, "foreignField": "_id", "as": "found"}}},
+		{{Key: "$match", Value: bson.M{"found": bson.M{"$size": 0}}}},
+		{{Key: "$project", Value: bson.M{"ref": "$" + field}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var orphans []orphan
+	err = cursor.All(context.Background(), &orphans)
+	return orphans, err
+}
+
+// checkRooms compares the occupied flag of each room with the stays holding
+// it. Fixing sets the flag from the stays, checking them again first in case
+// one was made or ended since they were loaded.
+func (r *consistencyCheck) checkRooms() error {
+	held := map[primitive.ObjectID]bool{}
+	for _, assignment := range r.holding {
+		held[assignment.RoomID] = true
+	}
+	for _, room := range r.order {
+		if room.IsOccupied == held[room.ID] {
+			continue
+		}
+		fixed := false
+		if r.Fix {
+			stillHeld, err := holdingRoom(context.Background(), room.ID)
+			if err != nil {
+				return err
+			}
+			if stillHeld == held[room.ID] {
+				if held[room.ID] {
+					result, err := config.DB.Collection("rooms").UpdateOne(
+						context.Background(),
+						bson.M{"_id": room.ID, "is_occupied": false},
+						bson.M{"$set": bson.M{"is_occupied": true, "updated_at": time.Now()}},
+					)
+					if err != nil {
+						return err
+					}
+					fixed = result.ModifiedCount > 0
+				} else if fixed, err = releaseRoom(context.Background(), room.ID); err != nil {
+					return err
+				}
+			}
+		}
+		if held[room.ID] {
+			r.add(models.CheckStayInFreeRoom, "rooms", room.ID,
+				fmt.Sprintf("Room %s, %s is marked free but a stay holds it", room.RoomNumber, room.Building),
+				true, fixed)
+		} else {
+			r.add(models.CheckOccupiedWithoutStay, "rooms", room.ID,
+				fmt.Sprintf("Room %s, %s is marked occupied but no stay holds it", room.RoomNumber, room.Building),
+				true, fixed)
+		}
+	}
+	return nil
+}
+
+// checkHoldingStays checks that each stay holding a room refers to a room,
+// guest and approved request that exist, that no request holds two rooms and
+// that no room is held twice for the same nights
+func (r *consistencyCheck) checkHoldingStays() error {
+	if err := r.loadUsers(r.holding); err != nil {
+		return err
+	}
+	var requestIDs []primitive.ObjectID
+	for _, assignment := range r.holding {
+		requestIDs = append(requestIDs, assignment.RequestID)
+	}
+	requests := map[primitive.ObjectID]models.RoomRequest{}
+	if len(requestIDs) > 0 {
+		cursor, err := config.DB.Collection("room_requests").Find(
+			context.Background(),
+			bson.M{"_id": bson.M{"$in": requestIDs}},
+			options.Find().SetProjection(bson.M{"_id": 1, "status": 1, "public_id": 1, "name": 1}),
+		)
+		if err != nil {
+			return err
+		}
+		var found []models.RoomRequest
+		if err := cursor.All(context.Background(), &found); err != nil {
+			return err
+		}
+		for _, request := range found {
+			requests[request.ID] = request
+		}
+	}
+
+	byRequest := map[primitive.ObjectID][]models.RoomAssignment{}
+	byRoom := map[primitive.ObjectID][]models.RoomAssignment{}
+	for _, assignment := range r.holding {
+		stay := r.describeStay(assignment)
+		if _, ok := r.rooms[assignment.RoomID]; !ok {
+			r.add(models.CheckMissingRoom, "room_assignments", assignment.ID,
+				fmt.Sprintf("Stay of %s has not checked out", stay), false, false)
+		}
+		if _, ok := r.users[assignment.UserID]; !ok {
+			r.add(models.CheckMissingUser, "room_assignments", assignment.ID,
+				fmt.Sprintf("Stay of %s belongs to a deleted user", stay), false, false)
+		}
+		request, ok := requests[assignment.RequestID]
+		if !ok {
+			r.add(models.CheckMissingRequest, "room_assignments", assignment.ID,
+				fmt.Sprintf("Stay of %s was made for deleted request %s", stay, assignment.RequestID.Hex()), false, false)
+		} else if request.Status != models.StatusApproved {
+			r.add(models.CheckRequestNotApproved, "room_assignments", assignment.ID,
+				fmt.Sprintf("Stay of %s holds a room for request %s which is %s", stay, request.PublicID, request.Status), false, false)
+		}
+		byRequest[assignment.RequestID] = append(byRequest[assignment.RequestID], assignment)
+		byRoom[assignment.RoomID] = append(byRoom[assignment.RoomID], assignment)
+	}
+
+	reported := map[primitive.ObjectID]bool{}
+	for _, assignment := range r.holding {
+		stays := byRequest[assignment.RequestID]
+		if len(stays) > 1 && !reported[assignment.RequestID] {
+			reported[assignment.RequestID] = true
+			r.add(models.CheckDuplicateStay, "room_requests", assignment.RequestID,
+				fmt.Sprintf("Request %s has %d stays holding rooms", requests[assignment.RequestID].PublicID, len(stays)), false, false)
+		}
+	}
+	// Stays are sorted by check-in, so each only needs comparing with later ones
+	for _, room := range r.order {
+		stays := byRoom[room.ID]
+		for i, earlier := range stays {
+			for _, later := range stays[i+1:] {
+				if later.CheckInDate.Before(earlier.CheckOutDate) {
+					r.add(models.CheckDoubleBooked, "rooms", room.ID,
+						fmt.Sprintf("Stays of %s (%s to %s) and %s (%s to %s) overlap",
+							r.describeStay(earlier), notificationDate(earlier.CheckInDate), notificationDate(earlier.CheckOutDate),
+							r.describeStay(later), notificationDate(later.CheckInDate), notificationDate(later.CheckOutDate)),
+						false, false)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkStayStates reports stays checked out without checking in, and stays
+// whose checkout isn't after their check-in
+func (r *consistencyCheck) checkStayStates() error {
+	assignments, err := findAssignments(context.Background(), bson.M{"$or": []bson.M{
+		{"checked_out": true, "checked_in": false},
+		{"$expr": bson.M{"$lte": bson.A{"$check_out_date", "$check_in_date"}}},
+	}})
+	if err != nil {
+		return err
+	}
+	if err := r.loadUsers(assignments); err != nil {
+		return err
+	}
+	for _, assignment := range assignments {
+		description := fmt.Sprintf("Stay of %s checked out without checking in", r.describeStay(assignment))
+		if !assignment.CheckOutDate.After(assignment.CheckInDate) {
+			description = fmt.Sprintf("Stay of %s checks out on %s, not after its check-in on %s",
+				r.describeStay(assignment), notificationDate(assignment.CheckOutDate), notificationDate(assignment.CheckInDate))
+		}
+		r.add(models.CheckInvalidStay, "room_assignments", assignment.ID, description, false, false)
+	}
+	return nil
+}
+
+// checkRequests reports open requests of users that were deleted
+func (r *consistencyCheck) checkRequests() error {
+	orphans, err := findOrphans("room_requests", "user_id", "users", bson.M{
+		"status": bson.M{"$in": []models.RequestStatus{models.StatusPending, models.StatusApproved}},
+	})
+	if err != nil {
+		return err
+	}
+	for _, o := range orphans {
+		r.add(models.CheckMissingUser, "room_requests", o.ID,
+			fmt.Sprintf("Open request belongs to deleted user %s", o.Ref.Hex()), false, false)
+	}
+	return nil
+}
+
+// checkPayments reports payments of deleted requests, paid payments without
+// a paid date and payments refunded more than was paid
+func (r *consistencyCheck) checkPayments() error {
+	orphans, err := findOrphans("payments", "request_id", "room_requests", bson.M{"request_id": bson.M{"$exists": true, "$ne": nil}})
+	if err != nil {
+		return err
+	}
+	for _, o := range orphans {
+		r.add(models.CheckMissingRequest, "payments", o.ID,
+			fmt.Sprintf("Payment is for deleted request %s", o.Ref.Hex()), false, false)
+	}
+
+	payments, err := findPayments(bson.M{"$or": []bson.M{
+		{"status": bson.M{"$in": []models.PaymentStatus{models.PaymentStatusPaid, models.PaymentStatusRefunded}}, "paid_at": nil},
+		{"$expr": bson.M{"$gt": bson.A{"$refunded_amount", "$amount"}}},
+	}}, "created_at")
+	if err != nil {
+		return err
+	}
+	for _, p := range payments {
+		description := fmt.Sprintf("%s payment is %s without a paid date", p.Type, p.Status)
+		if p.RefundedAmount > p.Amount {
+			description = fmt.Sprintf("%s payment of %d paise was refunded %d paise", p.Type, p.Amount, p.RefundedAmount)
+		}
+		r.add(models.CheckInvalidPayment, "payments", p.ID, description, false, false)
+	}
+	return nil
+}
+
+// checkFoodPasses reports unused food passes of stays that were deleted or
+// have checked out. Fixing expires them so they can't be scanned.
+func (r *consistencyCheck) checkFoodPasses() error {
+	orphans, err := findOrphans("food_passes", "assignment_id", "room_assignments", bson.M{
+		"is_used":       false,
+		"is_expired":    bson.M{"$ne": true},
+		"assignment_id": bson.M{"$exists": true},
+	})
+	if err != nil {
+		return err
+	}
+	for _, o := range orphans {
+		fixed := false
+		if r.Fix {
+			if err := expireFoodPasses(context.Background(), bson.M{"_id": o.ID, "is_used": false}, consistencySystem+": stay deleted"); err != nil {
+				return err
+			}
+			fixed = true
+		}
+		r.add(models.CheckPassWithoutStay, "food_passes", o.ID,
+			fmt.Sprintf("Unused food pass of deleted stay %s", o.Ref.Hex()), true, fixed)
+	}
+
+	assignments, err := checkedOutWithPasses(context.Background())
+	if err != nil {
+		return err
+	}
+	if err := r.loadUsers(assignments); err != nil {
+		return err
+	}
+	for _, assignment := range assignments {
+		count, err := config.DB.Collection("food_passes").CountDocuments(context.Background(), unusedPasses(assignment.ID))
+		if err != nil {
+			return err
+		}
+		fixed := false
+		if r.Fix {
+			if err := expireFoodPasses(context.Background(), unusedPasses(assignment.ID), consistencySystem+": stay checked out"); err != nil {
+				return err
+			}
+			fixed = true
+		}
+		r.add(models.CheckPassAfterCheckout, "room_assignments", assignment.ID,
+			fmt.Sprintf("%d unused food passes of %s after checkout", count, r.describeStay(assignment)),
+			true, fixed)
+	}
+	return nil
+}
+
+// RunConsistencyCheck cross-checks rooms, stays, requests, payments and food
+// passes, fixing the fixable violations when fix is set. Nothing is stored.
+func RunConsistencyCheck(fix bool) (*models.ConsistencyReport, error) {
+	lookup, err := newStayLookup(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	r := &consistencyCheck{
+		ConsistencyReport: models.ConsistencyReport{
+			Fix:        fix,
+			Violations: []models.ConsistencyViolation{},
+			Counts:     map[models.ConsistencyCheck]int{},
+			CheckedAt:  time.Now(),
+		},
+		stayLookup: lookup,
+	}
+	r.holding, err = findAssignments(context.Background(), bson.M{"checked_out": false, "no_show": bson.M{"$ne": true}})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching stays: %w", err)
+	}
+
+	checks := []struct {
+		name string
+		run  func() error
+	}{
+		{"rooms", r.checkRooms},
+		{"stays", r.checkHoldingStays},
+		{"stay states", r.checkStayStates},
+		{"requests", r.checkRequests},
+		{"payments", r.checkPayments},
+		{"food passes", r.checkFoodPasses},
+	}
+	for _, check := range checks {
+		if err := check.run(); err != nil {
+			return nil, fmt.Errorf("error checking %s: %w", check.name, err)
+		}
+	}
+	return &r.ConsistencyReport, nil
+}
+
+// CheckConsistency runs the consistency checks, fixing the fixable
+// violations if fix is set in the body or ?fix=true is given
+func CheckConsistency(c *gin.Context) {
+	var req models.ConsistencyCheckRequest
+	_ = c.ShouldBindJSON(&req) // The body is optional
+	fix, err := strconv.ParseBool(c.DefaultQuery("fix", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fix value"})
+		return
+	}
+	if fix {
+		req.Fix = true
+	}
+
+	report, err := RunConsistencyCheck(req.Fix)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error running consistency check: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
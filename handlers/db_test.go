@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+)
+
+// useTestDB points config.DB at a new database on TEST_MONGO_URI for the
+// test and drops it afterwards. Tests that need MongoDB are skipped when
+// TEST_MONGO_URI is unset, e.g. TEST_MONGO_URI=mongodb://localhost:27017.
+func useTestDB(t *testing.T) {
+	t.Helper()
+	uri := os.Getenv("TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("TEST_MONGO_URI is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := config.DB
+	config.DB = client.Database("utara_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		config.DB.Drop(context.Background())
+		config.DB = previous
+		client.Disconnect(context.Background())
+	})
+}
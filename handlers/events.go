@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"utara_backend/config"
+	"utara_backend/events"
+	"utara_backend/models"
+)
+
+// eventHeartbeat keeps idle streams from being closed by proxies
+const eventHeartbeat = 25 * time.Second
+
+// roomBuilding returns the building of a room, or "" if it can't be found
+func roomBuilding(roomID primitive.ObjectID) (models.Room, string) {
+	var room models.Room
+	if err := config.DB.Collection("rooms").FindOne(context.Background(), bson.M{"_id": roomID}).Decode(&room); err != nil {
+		return room, ""
+	}
+	return room, room.Building
+}
+
+func publishRequestEvent(eventType events.Type, request models.RoomRequest, building string) {
+	events.Publish(events.Event{
+		Type:     eventType,
+		Building: building,
+		UserID:   &request.UserID,
+		Data: map[string]any{
+			"request_id": request.ID,
+			"status":     request.Status,
+			"check_in":   request.CheckInDate,
+			"check_out":  request.CheckOutDate,
+		},
+	})
+}
+
+func publishStayEvent(eventType events.Type, assignment models.RoomAssignment) {
+	room, building := roomBuilding(assignment.RoomID)
+	events.Publish(events.Event{
+		Type:     eventType,
+		Building: building,
+		UserID:   &assignment.UserID,
+		Data: map[string]any{
+			"assignment_id": assignment.ID,
+			"request_id":    assignment.RequestID,
+			"room_id":       assignment.RoomID,
+			"room_number":   room.RoomNumber,
+		},
+	})
+}
+
+// StreamEvents streams the events the current user may see as Server-Sent
+// Events. A building query param, comma separated, limits the stream to
+// those buildings.
+func StreamEvents(c *gin.Context) {
+	user, err := getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	subscriber := events.Subscriber{UserID: user.ID, Role: user.Role}
+	for _, building := range strings.Split(c.Query("building"), ",") {
+		if building = strings.TrimSpace(building); building != "" {
+			subscriber.Buildings = append(subscriber.Buildings, building)
+		}
+	}
+
+	stream, unsubscribe := events.Subscribe(subscriber)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Stop nginx buffering the stream
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, ": connected\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+		case e, ok := <-stream:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", e.ID.Hex(), e.Type, data)
+		}
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,661 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+// exportFlushEvery is how many rows are written between flushes to the output
+const exportFlushEvery = 500
+
+// exportColumn is one column of a CSV or XLSX export
+type exportColumn[T any] struct {
+	Header string
+	Value  func(T) any
+}
+
+// exportWriter writes export rows in one output format. NDJSON writes the
+// whole record, CSV and XLSX write the column values.
+type exportWriter interface {
+	WriteHeader(headers []string) error
+	WriteRow(values []any, record any) error
+	Flush()
+	Close() error
+}
+
+type csvExportWriter struct {
+	w   *csv.Writer
+	out io.Writer
+}
+
+func (e *csvExportWriter) WriteHeader(headers []string) error { return e.w.Write(headers) }
+
+func (e *csvExportWriter) WriteRow(values []any, _ any) error {
+	row := make([]string, len(values))
+	for i, v := range values {
+		row[i] = formatExportValue(v)
+	}
+	return e.w.Write(row)
+}
+
+func (e *csvExportWriter) Flush() {
+	e.w.Flush()
+	flushExport(e.out)
+}
+
+func (e *csvExportWriter) Close() error {
+	e.Flush()
+	return e.w.Error()
+}
+
+type ndjsonExportWriter struct {
+	enc *json.Encoder
+	out io.Writer
+}
+
+func (e *ndjsonExportWriter) WriteHeader([]string) error { return nil }
+
+func (e *ndjsonExportWriter) WriteRow(_ []any, record any) error { return e.enc.Encode(record) }
+
+func (e *ndjsonExportWriter) Flush() { flushExport(e.out) }
+
+func (e *ndjsonExportWriter) Close() error {
+	e.Flush()
+	return nil
+}
+
+// flushExport sends what has been written so far when out is an HTTP response
+func flushExport(out io.Writer) {
+	if f, ok := out.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// xlsxExportWriter uses excelize's stream writer, which spills rows to a
+// temp file instead of keeping the sheet in memory. The workbook can only be
+// sent once it is complete.
+type xlsxExportWriter struct {
+	f   *excelize.File
+	sw  *excelize.StreamWriter
+	out io.Writer
+	row int
+}
+
+func (e *xlsxExportWriter) WriteHeader(headers []string) error {
+	cells := make([]interface{}, len(headers))
+	for i, h := range headers {
+		cells[i] = h
+	}
+	return e.writeCells(cells)
+}
+
+func (e *xlsxExportWriter) WriteRow(values []any, _ any) error {
+	cells := make([]interface{}, len(values))
+	for i, v := range values {
+		switch val := v.(type) {
+		case time.Time:
+			cells[i] = formatExportValue(val)
+		case *time.Time:
+			cells[i] = formatExportValue(val)
+		case int, int64, float64, bool, string:
+			cells[i] = val
+		default:
+			cells[i] = formatExportValue(val)
+		}
+	}
+	return e.writeCells(cells)
+}
+
+func (e *xlsxExportWriter) writeCells(cells []interface{}) error {
+	e.row++
+	cell, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+	return e.sw.SetRow(cell, cells)
+}
+
+func (e *xlsxExportWriter) Flush() {}
+
+func (e *xlsxExportWriter) Close() error {
+	defer e.f.Close()
+	if err := e.sw.Flush(); err != nil {
+		return err
+	}
+	return e.f.Write(e.out)
+}
+
+// formatExportValue renders a column value as text. Times are shown in IST.
+func formatExportValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case time.Time:
+		if val.IsZero() {
+			return ""
+		}
+		return val.In(utils.IST).Format("2006-01-02 15:04:05")
+	case *time.Time:
+		if val == nil {
+			return ""
+		}
+		return formatExportValue(*val)
+	case primitive.ObjectID:
+		if val.IsZero() {
+			return ""
+		}
+		return val.Hex()
+	case *primitive.ObjectID:
+		if val == nil {
+			return ""
+		}
+		return val.Hex()
+	case bool:
+		return strconv.FormatBool(val)
+	case int:
+		return strconv.Itoa(val)
+	case *int:
+		if val == nil {
+			return ""
+		}
+		return strconv.Itoa(*val)
+	case *bool:
+		if val == nil {
+			return ""
+		}
+		return strconv.FormatBool(*val)
+	case []string:
+		return strings.Join(val, ", ")
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// exportContentTypes are the supported export formats
+var exportContentTypes = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"ndjson": "application/x-ndjson",
+	"xlsx":   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// invalidExportError is an export refused for its arguments, such as an
+// unknown format or a bad filter value
+type invalidExportError struct{ error }
+
+// exportOutput is where an export goes. start, when set, is called before
+// the first row is written, so an HTTP response can still report errors
+// until then.
+type exportOutput struct {
+	w       io.Writer
+	format  string
+	start   func(dataset, format string)
+	started bool
+}
+
+// newWriter returns the writer of the output's format
+func (o *exportOutput) newWriter() (exportWriter, error) {
+	switch o.format {
+	case "csv":
+		return &csvExportWriter{w: csv.NewWriter(o.w), out: o.w}, nil
+	case "ndjson":
+		return &ndjsonExportWriter{enc: json.NewEncoder(o.w), out: o.w}, nil
+	case "xlsx":
+		f := excelize.NewFile()
+		sw, err := f.NewStreamWriter("Sheet1")
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &xlsxExportWriter{f: f, sw: sw, out: o.w}, nil
+	}
+	return nil, invalidExportError{fmt.Errorf("unsupported format %q, use csv, xlsx or ndjson", o.format)}
+}
+
+// exportDatasets are the exports by name. user scopes an export to what
+// that user may list. It is nil for exports run from the command line,
+// which cover every record.
+var exportDatasets = map[string]func(out *exportOutput, query url.Values, user *models.User) error{
+	"rooms":         exportRooms,
+	"room-requests": exportRoomRequests,
+	"users":         exportUsers,
+	"payments":      exportPayments,
+	"food-passes":   exportFoodPasses,
+}
+
+// ExportDatasets returns the names of the datasets Export accepts
+func ExportDatasets() []string {
+	names := make([]string, 0, len(exportDatasets))
+	for name := range exportDatasets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Export writes a dataset to out in format (csv, xlsx or ndjson), taking
+// the same filters as its export endpoint. It isn't scoped to a user.
+func Export(out io.Writer, dataset, format string, filters url.Values) error {
+	export, ok := exportDatasets[dataset]
+	if !ok {
+		return fmt.Errorf("unknown dataset %q, use %s", dataset, strings.Join(ExportDatasets(), ", "))
+	}
+	return export(&exportOutput{w: out, format: strings.ToLower(format)}, filters, nil)
+}
+
+// httpExportOutput returns the output of an export response. The format
+// comes from ?format=csv|xlsx|ndjson.
+func httpExportOutput(c *gin.Context) *exportOutput {
+	return &exportOutput{
+		w:      c.Writer,
+		format: strings.ToLower(c.DefaultQuery("format", "csv")),
+		start: func(dataset, format string) {
+			filename := fmt.Sprintf("%s-%s.%s", dataset, time.Now().In(utils.IST).Format("20060102-1504"), format)
+			c.Header("Content-Type", exportContentTypes[format])
+			c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+			c.Status(http.StatusOK)
+		},
+	}
+}
+
+// serveExport runs the named dataset's export as the current user
+func serveExport(c *gin.Context, dataset string) {
+	user, err := getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching user"})
+		return
+	}
+	out := httpExportOutput(c)
+	respondExport(c, dataset, out, exportDatasets[dataset](out, c.Request.URL.Query(), &user))
+}
+
+// respondExport reports an export's error. Once rows have been sent the
+// response has started, so the error can only be logged.
+func respondExport(c *gin.Context, dataset string, out *exportOutput, err error) {
+	if err == nil {
+		return
+	}
+	if out.started {
+		log.Printf("Error writing %s export: %v", dataset, err)
+		return
+	}
+	var invalid invalidExportError
+	if errors.As(err, &invalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("Error exporting %s: %v", dataset, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error exporting %s", dataset)})
+}
+
+// streamExport writes every document of the cursor as it is read, so large
+// collections are never held in memory
+func streamExport[T any](out *exportOutput, dataset string, cursor *mongo.Cursor, columns []exportColumn[T]) error {
+	defer cursor.Close(context.Background())
+	return writeExport(out, dataset, columns, func(record *T) (bool, error) {
+		if !cursor.Next(context.Background()) {
+			return false, cursor.Err()
+		}
+		return true, cursor.Decode(record)
+	})
+}
+
+// exportRecords responds with records already built in memory, such as
+// report rows
+func exportRecords[T any](c *gin.Context, dataset string, records []T, columns []exportColumn[T]) {
+	i := 0
+	out := httpExportOutput(c)
+	respondExport(c, dataset, out, writeExport(out, dataset, columns, func(record *T) (bool, error) {
+		if i == len(records) {
+			return false, nil
+		}
+		*record = records[i]
+		i++
+		return true, nil
+	}))
+}
+
+// writeExport writes the records next returns until it reports there are no
+// more
+func writeExport[T any](out *exportOutput, dataset string, columns []exportColumn[T], next func(*T) (bool, error)) error {
+	w, err := out.newWriter()
+	if err != nil {
+		return err
+	}
+
+	headers := make([]string, len(columns))
+	for i, col := range columns {
+		headers[i] = col.Header
+	}
+
+	if out.start != nil {
+		out.start(dataset, out.format)
+	}
+	out.started = true
+	if err := w.WriteHeader(headers); err != nil {
+		return err
+	}
+
+	rows := 0
+	for {
+		var record T
+		ok, err := next(&record)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+
+		values := make([]any, len(columns))
+		for i, col := range columns {
+			values[i] = col.Value(record)
+		}
+		if err := w.WriteRow(values, record); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%exportFlushEvery == 0 {
+			w.Flush()
+		}
+	}
+	return w.Close()
+}
+
+// ExportRooms exports rooms with the same filters as GET /rooms
+func ExportRooms(c *gin.Context) { serveExport(c, "rooms") }
+
+func exportRooms(out *exportOutput, query url.Values, user *models.User) error {
+	outOfOrder, err := outOfOrderRoomIDs()
+	if err != nil {
+		return fmt.Errorf("error checking room maintenance: %w", err)
+	}
+	filter, err := roomListFilter(query, user, outOfOrder)
+	if err != nil {
+		return invalidExportError{err}
+	}
+
+	cursor, err := config.DB.Collection("rooms").Find(
+		context.Background(),
+		filter,
+		options.Find().SetSort(bson.D{{Key: "building", Value: 1}, {Key: "room_number", Value: 1}}),
+	)
+	if err != nil {
+		return fmt.Errorf("error fetching rooms: %w", err)
+	}
+
+	blocked := make(map[primitive.ObjectID]bool, len(outOfOrder))
+	for _, id := range outOfOrder {
+		blocked[id] = true
+	}
+
+	return streamExport(out, "rooms", cursor, []exportColumn[models.Room]{
+		{"id", func(r models.Room) any { return r.ID }},
+		{"building", func(r models.Room) any { return r.Building }},
+		{"floor", func(r models.Room) any { return r.Floor }},
+		{"room_number", func(r models.Room) any { return r.RoomNumber }},
+		{"type", func(r models.Room) any { return string(r.Type) }},
+		{"beds", func(r models.Room) any {
+			var beds []string
+			for _, b := range r.Beds {
+				beds = append(beds, fmt.Sprintf("%s:%d", b.Type, max(b.Quantity, 1)))
+			}
+			return beds
+		}},
+		{"has_geyser", func(r models.Room) any { return r.HasGeyser }},
+		{"has_ac", func(r models.Room) any { return r.HasAC }},
+		{"has_sofa_set", func(r models.Room) any { return r.HasSofaSet }},
+		{"sofa_set_quantity", func(r models.Room) any { return r.SofaSetQuantity }},
+		{"extra_amenities", func(r models.Room) any { return r.ExtraAmenities }},
+		{"is_visible", func(r models.Room) any { return r.IsVisible }},
+		{"is_occupied", func(r models.Room) any { return r.IsOccupied }},
+		{"needs_cleaning", func(r models.Room) any { return r.NeedsCleaning }},
+		{"out_of_order", func(r models.Room) any { return blocked[r.ID] }},
+		{"room_category_id", func(r models.Room) any { return r.RoomCategoryId }},
+		{"created_at", func(r models.Room) any { return r.CreatedAt }},
+	})
+}
+
+// roomRequestExport is a room request joined with its user, latest
+// assignment, room and latest payment
+type roomRequestExport struct {
+	models.RoomRequest `bson:",inline"`
+	User               *models.User           `json:"user,omitempty" bson:"user,omitempty"`
+	Assignment         *models.RoomAssignment `json:"assignment,omitempty" bson:"assignment,omitempty"`
+	Room               *models.Room           `json:"room,omitempty" bson:"room,omitempty"`
+	Payment            *models.Payment        `json:"payment,omitempty" bson:"payment,omitempty"`
+}
+
+// ExportRoomRequests exports room requests with the same filters as
+// GET /room-requests, joining the assignment, room and payment in Mongo
+func ExportRoomRequests(c *gin.Context) { serveExport(c, "room-requests") }
+
+func exportRoomRequests(out *exportOutput, query url.Values, user *models.User) error {
+	lookupOne := func(from, localField, foreignField, as string, sort bson.D, project bson.M) bson.D {
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"$expr": bson.M{"$eq": []string{"$" + foreignField, "$$key"}}}}},
+		}
+		if sort != nil {
+			pipeline = append(pipeline, bson.D{{Key: "$sort", Value: sort}})
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: 1}})
+		if project != nil {
+			pipeline = append(pipeline, bson.D{{Key: "$project", Value: project}})
+		}
+		return bson.D{{Key: "$lookup", Value: bson.M{
+			"from":     from,
+			"let":      bson.M{"key": "$" + localField},
+			"pipeline": pipeline,
+			"as":       as,
+		}}}
+	}
+	unwind := func(field string) bson.D {
+		return bson.D{{Key: "$unwind", Value: bson.M{"path": "$" + field, "preserveNullAndEmptyArrays": true}}}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: roomRequestListFilter(query, user)}},
+		{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: -1}}}},
+		lookupOne("users", "user_id", "_id", "user", nil, bson.M{"password": 0, "otp": 0, "otp_expiry": 0}),
+		unwind("user"),
+		lookupOne("room_assignments", "_id", "request_id", "assignment", bson.D{{Key: "assigned_at", Value: -1}}, nil),
+		unwind("assignment"),
+		lookupOne("rooms", "assignment.room_id", "_id", "room", nil, nil),
+		unwind("room"),
+		lookupOne("payments", "_id", "request_id", "payment", bson.D{{Key: "created_at", Value: -1}}, nil),
+		unwind("payment"),
+	}
+
+	cursor, err := config.DB.Collection("room_requests").Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return fmt.Errorf("error fetching room requests: %w", err)
+	}
+
+	return streamExport(out, "room-requests", cursor, []exportColumn[roomRequestExport]{
+		{"id", func(r roomRequestExport) any { return r.ID }},
+		{"public_id", func(r roomRequestExport) any { return r.PublicID }},
+		{"status", func(r roomRequestExport) any { return string(r.Status) }},
+		{"name", func(r roomRequestExport) any { return r.Name }},
+		{"phone_number", func(r roomRequestExport) any {
+			if r.User == nil {
+				return ""
+			}
+			return r.User.PhoneNumber
+		}},
+		{"place", func(r roomRequestExport) any { return r.Place }},
+		{"purpose", func(r roomRequestExport) any { return r.Purpose }},
+		{"check_in_date", func(r roomRequestExport) any { return r.CheckInDate }},
+		{"check_out_date", func(r roomRequestExport) any { return r.CheckOutDate }},
+		{"people_total", func(r roomRequestExport) any { return r.NumberOfPeople.Total }},
+		{"people_male", func(r roomRequestExport) any { return r.NumberOfPeople.Male }},
+		{"people_female", func(r roomRequestExport) any { return r.NumberOfPeople.Female }},
+		{"people_children", func(r roomRequestExport) any { return r.NumberOfPeople.Children }},
+		{"reference", func(r roomRequestExport) any { return r.Reference }},
+		{"special_requests", func(r roomRequestExport) any { return r.SpecialRequests }},
+		{"building", func(r roomRequestExport) any {
+			if r.Room == nil {
+				return ""
+			}
+			return r.Room.Building
+		}},
+		{"room_number", func(r roomRequestExport) any {
+			if r.Room == nil {
+				return ""
+			}
+			return r.Room.RoomNumber
+		}},
+		{"checked_in", func(r roomRequestExport) any { return r.Assignment != nil && r.Assignment.CheckedIn }},
+		{"checked_out", func(r roomRequestExport) any { return r.Assignment != nil && r.Assignment.CheckedOut }},
+		{"payment_status", func(r roomRequestExport) any {
+			if r.Payment == nil {
+				return ""
+			}
+			return string(r.Payment.Status)
+		}},
+		{"payment_amount", func(r roomRequestExport) any {
+			if r.Payment == nil {
+				return ""
+			}
+			return r.Payment.Amount
+		}},
+		{"created_at", func(r roomRequestExport) any { return r.CreatedAt }},
+	})
+}
+
+// ExportUsers exports users without credentials or OTPs
+func ExportUsers(c *gin.Context) { serveExport(c, "users") }
+
+func exportUsers(out *exportOutput, query url.Values, _ *models.User) error {
+	cursor, err := config.DB.Collection("users").Find(
+		context.Background(),
+		userListFilter(query),
+		options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}}).
+			SetProjection(bson.M{"password": 0, "otp": 0, "otp_expiry": 0}),
+	)
+	if err != nil {
+		return fmt.Errorf("error fetching users: %w", err)
+	}
+
+	return streamExport(out, "users", cursor, []exportColumn[models.User]{
+		{"id", func(u models.User) any { return u.ID }},
+		{"name", func(u models.User) any { return u.Name }},
+		{"email", func(u models.User) any { return u.Email }},
+		{"phone_number", func(u models.User) any { return u.PhoneNumber }},
+		{"role", func(u models.User) any { return string(u.Role) }},
+		{"user_type", func(u models.User) any { return u.UserType }},
+		{"gaam", func(u models.User) any { return u.Gaam }},
+		{"is_important", func(u models.User) any { return u.IsImportant }},
+		{"total_bookings", func(u models.User) any { return u.TotalBookings }},
+		{"last_booking_at", func(u models.User) any { return u.LastBookingAt }},
+		{"created_at", func(u models.User) any { return u.CreatedAt }},
+	})
+}
+
+// ExportPayments exports payments with the same filters as GET /payments
+func ExportPayments(c *gin.Context) { serveExport(c, "payments") }
+
+func exportPayments(out *exportOutput, query url.Values, _ *models.User) error {
+	cursor, err := config.DB.Collection("payments").Find(
+		context.Background(),
+		paymentListFilter(query),
+		options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}}).
+			SetProjection(bson.M{"razorpay_signature": 0}),
+	)
+	if err != nil {
+		return fmt.Errorf("error fetching payments: %w", err)
+	}
+
+	return streamExport(out, "payments", cursor, []exportColumn[models.Payment]{
+		{"id", func(p models.Payment) any { return p.ID }},
+		{"user_id", func(p models.Payment) any { return p.UserID }},
+		{"request_id", func(p models.Payment) any { return p.RequestID }},
+		{"type", func(p models.Payment) any { return string(p.Type) }},
+		{"status", func(p models.Payment) any { return string(p.Status) }},
+		{"method", func(p models.Payment) any { return string(p.Method) }},
+		{"reference_number", func(p models.Payment) any { return p.ReferenceNumber }},
+		{"collected_by", func(p models.Payment) any { return p.CollectedBy }},
+		{"amount_paise", func(p models.Payment) any { return p.Amount }},
+		{"refunded_amount_paise", func(p models.Payment) any { return p.RefundedAmount }},
+		{"currency", func(p models.Payment) any { return p.Currency }},
+		{"description", func(p models.Payment) any { return p.Description }},
+		{"razorpay_order_id", func(p models.Payment) any { return p.RazorpayOrderID }},
+		{"razorpay_payment_id", func(p models.Payment) any { return p.RazorpayPaymentID }},
+		{"refund_id", func(p models.Payment) any { return p.RefundID }},
+		{"failure_reason", func(p models.Payment) any { return p.FailureReason }},
+		{"created_at", func(p models.Payment) any { return p.CreatedAt }},
+		{"paid_at", func(p models.Payment) any { return p.PaidAt }},
+	})
+}
+
+// ExportFoodPasses exports food passes with the same filters as
+// GET /food-passes/user/:user_id, plus user_id, dining_hall and meal_type
+func ExportFoodPasses(c *gin.Context) { serveExport(c, "food-passes") }
+
+func exportFoodPasses(out *exportOutput, query url.Values, _ *models.User) error {
+	filter := foodPassListFilter(query)
+	if userID := query.Get("user_id"); userID != "" {
+		userObjID, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			return invalidExportError{errors.New("Invalid user ID")}
+		}
+		filter["user_id"] = userObjID
+	}
+	if diningHall := query.Get("dining_hall"); diningHall != "" {
+		filter["dining_hall"] = diningHall
+	}
+	if mealType := query.Get("meal_type"); mealType != "" {
+		filter["meal_type"] = mealType
+	}
+
+	cursor, err := config.DB.Collection("food_passes").Find(
+		context.Background(),
+		filter,
+		options.Find().
+			SetSort(bson.D{{Key: "date", Value: 1}, {Key: "user_id", Value: 1}}).
+			SetProjection(bson.M{"qr_code": 0, "qr_token": 0}),
+	)
+	if err != nil {
+		return fmt.Errorf("error fetching food passes: %w", err)
+	}
+
+	return streamExport(out, "food-passes", cursor, []exportColumn[models.FoodPass]{
+		{"id", func(p models.FoodPass) any { return p.ID }},
+		{"user_id", func(p models.FoodPass) any { return p.UserID }},
+		{"assignment_id", func(p models.FoodPass) any { return p.AssignmentID }},
+		{"member_name", func(p models.FoodPass) any { return p.MemberName }},
+		{"date", func(p models.FoodPass) any { return p.Date.In(utils.IST).Format("2006-01-02") }},
+		{"meal_type", func(p models.FoodPass) any { return string(p.MealType) }},
+		{"dining_hall", func(p models.FoodPass) any { return p.DiningHall }},
+		{"is_used", func(p models.FoodPass) any { return p.IsUsed }},
+		{"used_at", func(p models.FoodPass) any { return p.UsedAt }},
+		{"is_expired", func(p models.FoodPass) any { return p.IsExpired }},
+		{"is_guest_pass", func(p models.FoodPass) any { return p.IsGuestPass }},
+		{"awaiting_payment", func(p models.FoodPass) any { return p.AwaitingPayment }},
+		{"created_at", func(p models.FoodPass) any { return p.CreatedAt }},
+	})
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type exportTestRow struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+var exportTestColumns = []exportColumn[exportTestRow]{
+	{"name", func(r exportTestRow) any { return r.Name }},
+	{"count", func(r exportTestRow) any { return r.Count }},
+}
+
+func exportTestRows(rows ...exportTestRow) func(*exportTestRow) (bool, error) {
+	return func(record *exportTestRow) (bool, error) {
+		if len(rows) == 0 {
+			return false, nil
+		}
+		*record, rows = rows[0], rows[1:]
+		return true, nil
+	}
+}
+
+func TestWriteExport(t *testing.T) {
+	rows := []exportTestRow{{"Room, 101", 2}, {"Hall", 0}}
+	for _, tt := range []struct {
+		format string
+		want   string
+	}{
+		{"csv", "name,count\n\"Room, 101\",2\nHall,0\n"},
+		{"ndjson", "{\"name\":\"Room, 101\",\"count\":2}\n{\"name\":\"Hall\",\"count\":0}\n"},
+	} {
+		var buf bytes.Buffer
+		started := ""
+		out := &exportOutput{w: &buf, format: tt.format, start: func(dataset, format string) { started = dataset + "." + format }}
+		if err := writeExport(out, "rooms", exportTestColumns, exportTestRows(rows...)); err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s export = %q, want %q", tt.format, got, tt.want)
+		}
+		if started != "rooms."+tt.format || !out.started {
+			t.Errorf("%s export started as %q", tt.format, started)
+		}
+	}
+
+	var buf bytes.Buffer
+	out := &exportOutput{w: &buf, format: "xlsx"}
+	if err := writeExport(out, "rooms", exportTestColumns, exportTestRows(rows...)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("PK")) {
+		t.Error("xlsx export is not a zip archive")
+	}
+}
+
+func TestWriteExportErrors(t *testing.T) {
+	out := &exportOutput{w: &bytes.Buffer{}, format: "pdf"}
+	err := writeExport(out, "rooms", exportTestColumns, exportTestRows())
+	var invalid invalidExportError
+	if !errors.As(err, &invalid) || out.started {
+		t.Errorf("unsupported format: err %v, started %v", err, out.started)
+	}
+
+	failed := errors.New("cursor closed")
+	out = &exportOutput{w: &bytes.Buffer{}, format: "csv"}
+	err = writeExport(out, "rooms", exportTestColumns, func(*exportTestRow) (bool, error) { return false, failed })
+	if !errors.Is(err, failed) || !out.started {
+		t.Errorf("read error: err %v, started %v", err, out.started)
+	}
+
+	if err := Export(&bytes.Buffer{}, "bookings", "csv", nil); err == nil || !strings.Contains(err.Error(), "room-requests") {
+		t.Errorf("unknown dataset: err %v", err)
+	}
+}
+
+func TestExportRecordsResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, tt := range []struct {
+		query       string
+		status      int
+		contentType string
+	}{
+		{"", http.StatusOK, "text/csv; charset=utf-8"},
+		{"?format=NDJSON", http.StatusOK, "application/x-ndjson"},
+		{"?format=pdf", http.StatusBadRequest, "application/json; charset=utf-8"},
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/reports/collections"+tt.query, nil)
+		exportRecords(c, "collections", []exportTestRow{{"Hall", 1}}, exportTestColumns)
+
+		if w.Code != tt.status || w.Header().Get("Content-Type") != tt.contentType {
+			t.Errorf("%q: status %d, content type %q, want %d, %q", tt.query, w.Code, w.Header().Get("Content-Type"), tt.status, tt.contentType)
+		}
+		if tt.status == http.StatusOK && !strings.HasPrefix(w.Header().Get("Content-Disposition"), `attachment; filename="collections-`) {
+			t.Errorf("%q: content disposition %q", tt.query, w.Header().Get("Content-Disposition"))
+		}
+	}
+}
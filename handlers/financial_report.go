@@ -0,0 +1,504 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+// Financial reports answer JSON, or with ?format=csv|xlsx|ndjson export
+// their rows like the /exports endpoints.
+
+func wantsExport(c *gin.Context) bool {
+	return c.Query("format") != ""
+}
+
+// paymentMethod names the method of a payment, which is Razorpay when unset
+func paymentMethod(p models.Payment) models.PaymentMethod {
+	if p.Method == "" {
+		return models.PaymentMethodRazorpay
+	}
+	return p.Method
+}
+
+// refundedAt is when a payment was refunded. Refunds recorded before
+// refunded_at was kept fall back to the last update.
+func refundedAt(p models.Payment) time.Time {
+	if p.RefundedAt != nil {
+		return *p.RefundedAt
+	}
+	return p.UpdatedAt
+}
+
+// refundedInRange matches payments refunded between start and end
+func refundedInRange(start, end time.Time) bson.M {
+	inRange := bson.M{"$gte": start, "$lt": end}
+	return bson.M{
+		"refunded_amount": bson.M{"$gt": 0},
+		"$or": []bson.M{
+			{"refunded_at": inRange},
+			{"refunded_at": bson.M{"$exists": false}, "updated_at": inRange},
+		},
+	}
+}
+
+func findPayments(filter bson.M, sort string) ([]models.Payment, error) {
+	cursor, err := config.DB.Collection("payments").Find(
+		context.Background(),
+		filter,
+		options.Find().
+			SetSort(bson.D{{Key: sort, Value: 1}}).
+			SetProjection(bson.M{"razorpay_signature": 0}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	payments := []models.Payment{}
+	err = cursor.All(context.Background(), &payments)
+	return payments, err
+}
+
+// usersByID loads the given users
+func usersByID(ids []primitive.ObjectID) (map[primitive.ObjectID]models.User, error) {
+	users := map[primitive.ObjectID]models.User{}
+	if len(ids) == 0 {
+		return users, nil
+	}
+	cursor, err := config.DB.Collection("users").Find(
+		context.Background(),
+		bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"name": 1, "phone_number": 1, "user_type": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var list []models.User
+	if err := cursor.All(context.Background(), &list); err != nil {
+		return nil, err
+	}
+	for _, u := range list {
+		users[u.ID] = u
+	}
+	return users, nil
+}
+
+func paymentUserIDs(payments []models.Payment) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(payments))
+	for _, p := range payments {
+		ids = append(ids, p.UserID)
+	}
+	return ids
+}
+
+// GetCollectionsReport totals collections and refunds by ?period=day|month
+// (default day), payment type and method over ?from= to ?to=
+func GetCollectionsReport(c *gin.Context) {
+	start, end, ok := analyticsRange(c)
+	if !ok {
+		return
+	}
+	period, layout := c.DefaultQuery("period", "day"), "2006-01-02"
+	switch period {
+	case "day":
+	case "month":
+		layout = "2006-01"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "period must be day or month"})
+		return
+	}
+
+	paid, err := findPayments(bson.M{
+		"status":  bson.M{"$in": []models.PaymentStatus{models.PaymentStatusPaid, models.PaymentStatusRefunded}},
+		"paid_at": bson.M{"$gte": start, "$lt": end},
+	}, "paid_at")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payments"})
+		return
+	}
+	refunded, err := findPayments(refundedInRange(start, end), "updated_at")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch refunds"})
+		return
+	}
+
+	report := models.CollectionsReport{
+		From:   start.Format("2006-01-02"),
+		To:     end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Period: period,
+	}
+	rows := map[models.CollectionRow]*models.CollectionRow{}
+	byType := map[models.PaymentType]*models.CollectionRow{}
+	byMethod := map[models.PaymentMethod]*models.CollectionRow{}
+	add := func(p models.Payment, at time.Time, collected, refund int) {
+		key := models.CollectionRow{Period: at.In(utils.IST).Format(layout), Type: p.Type, Method: paymentMethod(p)}
+		if rows[key] == nil {
+			row := key
+			rows[key] = &row
+		}
+		if byType[key.Type] == nil {
+			byType[key.Type] = &models.CollectionRow{Type: key.Type}
+		}
+		if byMethod[key.Method] == nil {
+			byMethod[key.Method] = &models.CollectionRow{Method: key.Method}
+		}
+		for _, row := range []*models.CollectionRow{rows[key], byType[key.Type], byMethod[key.Method]} {
+			if collected > 0 {
+				row.Payments++
+				row.Collected += collected
+			}
+			if refund > 0 {
+				row.Refunds++
+				row.Refunded += refund
+			}
+			row.Net = row.Collected - row.Refunded
+		}
+		report.TotalCollected += collected
+		report.TotalRefunded += refund
+	}
+	for _, p := range paid {
+		add(p, *p.PaidAt, p.Amount, 0)
+	}
+	for _, p := range refunded {
+		add(p, refundedAt(p), 0, p.RefundedAmount)
+	}
+	report.Net = report.TotalCollected - report.TotalRefunded
+
+	report.Rows = []models.CollectionRow{}
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+	slices.SortFunc(report.Rows, func(a, b models.CollectionRow) int {
+		if n := strings.Compare(a.Period, b.Period); n != 0 {
+			return n
+		}
+		if n := strings.Compare(string(a.Type), string(b.Type)); n != 0 {
+			return n
+		}
+		return strings.Compare(string(a.Method), string(b.Method))
+	})
+	report.ByType = []models.CollectionRow{}
+	for _, row := range byType {
+		report.ByType = append(report.ByType, *row)
+	}
+	slices.SortFunc(report.ByType, func(a, b models.CollectionRow) int { return strings.Compare(string(a.Type), string(b.Type)) })
+	report.ByMethod = []models.CollectionRow{}
+	for _, row := range byMethod {
+		report.ByMethod = append(report.ByMethod, *row)
+	}
+	slices.SortFunc(report.ByMethod, func(a, b models.CollectionRow) int { return strings.Compare(string(a.Method), string(b.Method)) })
+
+	if wantsExport(c) {
+		exportRecords(c, "collections", report.Rows, []exportColumn[models.CollectionRow]{
+			{"period", func(r models.CollectionRow) any { return r.Period }},
+			{"type", func(r models.CollectionRow) any { return string(r.Type) }},
+			{"method", func(r models.CollectionRow) any { return string(r.Method) }},
+			{"payments", func(r models.CollectionRow) any { return r.Payments }},
+			{"collected_paise", func(r models.CollectionRow) any { return r.Collected }},
+			{"refunds", func(r models.CollectionRow) any { return r.Refunds }},
+			{"refunded_paise", func(r models.CollectionRow) any { return r.Refunded }},
+			{"net_paise", func(r models.CollectionRow) any { return r.Net }},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetRefundsReport lists refunds issued over ?from= to ?to=
+func GetRefundsReport(c *gin.Context) {
+	start, end, ok := analyticsRange(c)
+	if !ok {
+		return
+	}
+	payments, err := findPayments(refundedInRange(start, end), "updated_at")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch refunds"})
+		return
+	}
+	users, err := usersByID(paymentUserIDs(payments))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	report := models.RefundsReport{
+		From:    start.Format("2006-01-02"),
+		To:      end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Refunds: []models.RefundRow{},
+	}
+	for _, p := range payments {
+		report.Refunds = append(report.Refunds, models.RefundRow{
+			PaymentID:  p.ID,
+			UserID:     p.UserID,
+			UserName:   users[p.UserID].Name,
+			RequestID:  p.RequestID,
+			Type:       p.Type,
+			Method:     paymentMethod(p),
+			Amount:     p.Amount,
+			Refunded:   p.RefundedAmount,
+			RefundID:   p.RefundID,
+			RefundedAt: refundedAt(p),
+		})
+		report.TotalRefunded += p.RefundedAmount
+	}
+	slices.SortFunc(report.Refunds, func(a, b models.RefundRow) int { return a.RefundedAt.Compare(b.RefundedAt) })
+	report.Count = len(report.Refunds)
+
+	if wantsExport(c) {
+		exportRecords(c, "refunds", report.Refunds, []exportColumn[models.RefundRow]{
+			{"payment_id", func(r models.RefundRow) any { return r.PaymentID }},
+			{"user_id", func(r models.RefundRow) any { return r.UserID }},
+			{"user_name", func(r models.RefundRow) any { return r.UserName }},
+			{"request_id", func(r models.RefundRow) any { return r.RequestID }},
+			{"type", func(r models.RefundRow) any { return string(r.Type) }},
+			{"method", func(r models.RefundRow) any { return string(r.Method) }},
+			{"amount_paise", func(r models.RefundRow) any { return r.Amount }},
+			{"refunded_paise", func(r models.RefundRow) any { return r.Refunded }},
+			{"refund_id", func(r models.RefundRow) any { return r.RefundID }},
+			{"refunded_at", func(r models.RefundRow) any { return r.RefundedAt }},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetDepositsReport compares deposits held with those returned, listing the
+// held deposits and which of them are due back because the guest has left
+func GetDepositsReport(c *gin.Context) {
+	start, end, ok := analyticsRange(c)
+	if !ok {
+		return
+	}
+	deposits, err := findPayments(bson.M{
+		"type":   models.PaymentTypeDeposit,
+		"status": bson.M{"$in": []models.PaymentStatus{models.PaymentStatusPaid, models.PaymentStatusRefunded}},
+	}, "paid_at")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deposits"})
+		return
+	}
+
+	report := models.DepositsReport{
+		From: start.Format("2006-01-02"),
+		To:   end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Held: []models.DepositRow{},
+	}
+	var held []models.Payment
+	var requestIDs []primitive.ObjectID
+	for _, p := range deposits {
+		if p.PaidAt != nil && !p.PaidAt.Before(start) && p.PaidAt.Before(end) {
+			report.CollectedInRange += p.Amount
+		}
+		if p.Status == models.PaymentStatusRefunded {
+			report.ReturnedCount++
+			report.ReturnedAmount += p.RefundedAmount
+			report.RetainedAmount += p.Amount - p.RefundedAmount
+			if at := refundedAt(p); !at.Before(start) && at.Before(end) {
+				report.ReturnedInRange += p.RefundedAmount
+			}
+			continue
+		}
+		held = append(held, p)
+		if p.RequestID != nil {
+			requestIDs = append(requestIDs, *p.RequestID)
+		}
+	}
+
+	checkedOut := map[primitive.ObjectID]*time.Time{}
+	if len(requestIDs) > 0 {
+		cursor, err := config.DB.Collection("room_assignments").Find(context.Background(), bson.M{
+			"request_id":  bson.M{"$in": requestIDs},
+			"checked_out": true,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch room assignments"})
+			return
+		}
+		var assignments []models.RoomAssignment
+		if err := cursor.All(context.Background(), &assignments); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode room assignments"})
+			return
+		}
+		for _, a := range assignments {
+			checkedOut[a.RequestID] = a.CheckedOutAt
+		}
+	}
+	users, err := usersByID(paymentUserIDs(held))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	for _, p := range held {
+		row := models.DepositRow{
+			PaymentID: p.ID,
+			UserID:    p.UserID,
+			UserName:  users[p.UserID].Name,
+			RequestID: p.RequestID,
+			Method:    paymentMethod(p),
+			Amount:    p.Amount,
+			PaidAt:    p.PaidAt,
+		}
+		if p.RequestID != nil {
+			if at, ok := checkedOut[*p.RequestID]; ok {
+				row.CheckedOutAt = at
+				report.DueCount++
+				report.DueAmount += p.Amount
+			}
+		}
+		report.HeldCount++
+		report.HeldAmount += p.Amount
+		report.Held = append(report.Held, row)
+	}
+
+	if wantsExport(c) {
+		exportRecords(c, "deposits-held", report.Held, []exportColumn[models.DepositRow]{
+			{"payment_id", func(r models.DepositRow) any { return r.PaymentID }},
+			{"user_id", func(r models.DepositRow) any { return r.UserID }},
+			{"user_name", func(r models.DepositRow) any { return r.UserName }},
+			{"request_id", func(r models.DepositRow) any { return r.RequestID }},
+			{"method", func(r models.DepositRow) any { return string(r.Method) }},
+			{"amount_paise", func(r models.DepositRow) any { return r.Amount }},
+			{"paid_at", func(r models.DepositRow) any { return r.PaidAt }},
+			{"checked_out_at", func(r models.DepositRow) any { return r.CheckedOutAt }},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetFOCReport counts stays arriving over ?from= to ?to= that were free of
+// charge, by the guest's user type
+func GetFOCReport(c *gin.Context) {
+	start, end, ok := analyticsRange(c)
+	if !ok {
+		return
+	}
+	cursor, err := config.DB.Collection("room_assignments").Find(context.Background(), bson.M{
+		"check_in_date": bson.M{"$gte": start, "$lt": end},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch room assignments"})
+		return
+	}
+	var assignments []models.RoomAssignment
+	if err := cursor.All(context.Background(), &assignments); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode room assignments"})
+		return
+	}
+	userIDs := make([]primitive.ObjectID, 0, len(assignments))
+	for _, a := range assignments {
+		userIDs = append(userIDs, a.UserID)
+	}
+	users, err := usersByID(userIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	report := models.FOCReport{
+		From: start.Format("2006-01-02"),
+		To:   end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Rows: []models.FOCRow{},
+	}
+	rows := map[string]*models.FOCRow{}
+	for _, a := range assignments {
+		userType := users[a.UserID].UserType
+		if userType == "" {
+			userType = "Unknown"
+		}
+		if rows[userType] == nil {
+			rows[userType] = &models.FOCRow{UserType: userType}
+		}
+		rows[userType].Stays++
+		report.Stays++
+		if a.IsFOC != nil && *a.IsFOC {
+			rows[userType].FOCStays++
+			report.FOCStays++
+		}
+	}
+	for _, row := range rows {
+		row.FOCRate = percent(row.FOCStays, row.Stays)
+		report.Rows = append(report.Rows, *row)
+	}
+	slices.SortFunc(report.Rows, func(a, b models.FOCRow) int { return strings.Compare(a.UserType, b.UserType) })
+
+	if wantsExport(c) {
+		exportRecords(c, "foc-stays", report.Rows, []exportColumn[models.FOCRow]{
+			{"user_type", func(r models.FOCRow) any { return r.UserType }},
+			{"stays", func(r models.FOCRow) any { return r.Stays }},
+			{"foc_stays", func(r models.FOCRow) any { return r.FOCStays }},
+			{"foc_rate", func(r models.FOCRow) any { return r.FOCRate }},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetPendingRefundsReport lists paid payments ProcessRoomRequest flagged with
+// notes.refund_pending when their request was rejected, oldest first
+func GetPendingRefundsReport(c *gin.Context) {
+	payments, err := findPayments(bson.M{
+		"status":               models.PaymentStatusPaid,
+		"notes.refund_pending": "true",
+	}, "updated_at")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payments"})
+		return
+	}
+	users, err := usersByID(paymentUserIDs(payments))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	rows := []models.PendingRefundRow{}
+	total := 0
+	for _, p := range payments {
+		user := users[p.UserID]
+		rows = append(rows, models.PendingRefundRow{
+			PaymentID:   p.ID,
+			UserID:      p.UserID,
+			UserName:    user.Name,
+			PhoneNumber: user.PhoneNumber,
+			RequestID:   p.RequestID,
+			Type:        p.Type,
+			Method:      paymentMethod(p),
+			Amount:      p.Amount,
+			Reason:      p.Notes["refund_reason"],
+			PaidAt:      p.PaidAt,
+			FlaggedAt:   p.UpdatedAt,
+			DaysPending: int(time.Since(p.UpdatedAt).Hours() / 24),
+		})
+		total += p.Amount
+	}
+
+	if wantsExport(c) {
+		exportRecords(c, "pending-refunds", rows, []exportColumn[models.PendingRefundRow]{
+			{"payment_id", func(r models.PendingRefundRow) any { return r.PaymentID }},
+			{"user_id", func(r models.PendingRefundRow) any { return r.UserID }},
+			{"user_name", func(r models.PendingRefundRow) any { return r.UserName }},
+			{"phone_number", func(r models.PendingRefundRow) any { return r.PhoneNumber }},
+			{"request_id", func(r models.PendingRefundRow) any { return r.RequestID }},
+			{"type", func(r models.PendingRefundRow) any { return string(r.Type) }},
+			{"method", func(r models.PendingRefundRow) any { return string(r.Method) }},
+			{"amount_paise", func(r models.PendingRefundRow) any { return r.Amount }},
+			{"reason", func(r models.PendingRefundRow) any { return r.Reason }},
+			{"paid_at", func(r models.PendingRefundRow) any { return r.PaidAt }},
+			{"flagged_at", func(r models.PendingRefundRow) any { return r.FlaggedAt }},
+			{"days_pending", func(r models.PendingRefundRow) any { return r.DaysPending }},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"payments": rows, "count": len(rows), "total_amount": total})
+}
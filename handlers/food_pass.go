@@ -4,18 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-co-op/gocron" // New dependency
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"utara_backend/config"
+	"utara_backend/events"
 	"utara_backend/models"
 	"utara_backend/utils"
 )
@@ -39,13 +39,8 @@ func ExecuteFoodPassGeneration(req models.GenerateFoodPassRequest, staffID primi
 	}
 
 	// Convert dates to IST midnight
-	loc := time.FixedZone("IST", 5*60*60+30*60)
-
-	startIST := req.StartDate.In(loc)
-	endIST := req.EndDate.In(loc)
-
-	startDate := time.Date(startIST.Year(), startIST.Month(), startIST.Day(), 0, 0, 0, 0, loc)
-	endDate := time.Date(endIST.Year(), endIST.Month(), endIST.Day(), 0, 0, 0, 0, loc)
+	startDate := utils.StartOfDayIST(req.StartDate)
+	endDate := utils.StartOfDayIST(req.EndDate)
 
 	currentDate := startDate
 
@@ -104,17 +99,18 @@ func ExecuteFoodPassGeneration(req models.GenerateFoodPassRequest, staffID primi
 				}
 
 				pass := models.FoodPass{
-					ID:         id,
-					UserID:     req.UserID,
-					MemberName: memberName,
-					MealType:   mealType,
-					Date:       currentDate,
-					QRCode:     qrCode,
-					IsUsed:     false,
-					DiningHall: req.DiningHall,
-					ColorCode:  colorCode,
-					CreatedBy:  staffID,
-					CreatedAt:  time.Now(),
+					ID:           id,
+					UserID:       req.UserID,
+					AssignmentID: req.AssignmentID,
+					MemberName:   memberName,
+					MealType:     mealType,
+					Date:         currentDate,
+					QRCode:       qrCode,
+					IsUsed:       false,
+					DiningHall:   req.DiningHall,
+					ColorCode:    colorCode,
+					CreatedBy:    staffID,
+					CreatedAt:    time.Now(),
 				}
 				passes = append(passes, pass)
 			}
@@ -260,6 +256,26 @@ func GenerateFoodPasses(c *gin.Context) {
 // 	})
 // }
 
+// foodPassListFilter builds the food pass filter from the list query params
+func foodPassListFilter(query url.Values) bson.M {
+	filter := bson.M{}
+
+	if date := query.Get("date"); date != "" {
+		parsedDate, err := time.Parse("2006-01-02", date)
+		if err == nil {
+			filter["date"] = bson.M{
+				"$gte": parsedDate,
+				"$lt":  parsedDate.Add(24 * time.Hour),
+			}
+		}
+	}
+	if isUsed := query.Get("is_used"); isUsed != "" {
+		filter["is_used"] = isUsed == "true"
+	}
+
+	return filter
+}
+
 // GetUserFoodPasses returns food passes for a specific user
 func GetUserFoodPasses(c *gin.Context) {
 	targetUserID, err := primitive.ObjectIDFromHex(c.Param("user_id"))
@@ -278,27 +294,15 @@ func GetUserFoodPasses(c *gin.Context) {
 		return
 	}
 
-	// Regular users can only see their own passes
-	if currentUser.Role == models.RoleUser && currentUserObjID != targetUserID {
+	// Anyone but staff can only see their own passes
+	isStaff := currentUser.Role == models.RoleSuperAdmin || currentUser.Role == models.RoleStaff
+	if !isStaff && currentUserObjID != targetUserID {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You can only view your own food passes"})
 		return
 	}
 
-	filter := bson.M{"user_id": targetUserID}
-
-	// Apply additional filters if provided
-	if date := c.Query("date"); date != "" {
-		parsedDate, err := time.Parse("2006-01-02", date)
-		if err == nil {
-			filter["date"] = bson.M{
-				"$gte": parsedDate,
-				"$lt":  parsedDate.Add(24 * time.Hour),
-			}
-		}
-	}
-	if isUsed := c.Query("is_used"); isUsed != "" {
-		filter["is_used"] = isUsed == "true"
-	}
+	filter := foodPassListFilter(c.Request.URL.Query())
+	filter["user_id"] = targetUserID
 
 	cursor, err := config.DB.Collection("food_passes").Find(context.Background(), filter)
 	if err != nil {
@@ -336,9 +340,14 @@ func ScanFoodPass(c *gin.Context) {
 	err := config.DB.Collection("food_passes").FindOneAndUpdate(
 		context.Background(),
 		bson.M{
-			"_id":     req.PassID,
-			"is_used": false,
-			"date":    bson.M{"$gte": now.Truncate(24 * time.Hour)}, // Only allow scanning passes for today or future
+			// Passes with a regenerated QR only scan with their new token
+			"$or": []bson.M{
+				{"_id": req.PassID, "qr_token": bson.M{"$exists": false}},
+				{"qr_token": req.PassID},
+			},
+			"is_used":          false,
+			"awaiting_payment": bson.M{"$ne": true},
+			"date":             bson.M{"$gte": now.Truncate(24 * time.Hour)}, // Only allow scanning passes for today or future
 		},
 		update,
 	).Decode(&pass)
@@ -352,6 +361,19 @@ func ScanFoodPass(c *gin.Context) {
 		return
 	}
 
+	events.Publish(events.Event{
+		Type:     events.FoodPassScanned,
+		Building: pass.DiningHall,
+		UserID:   &pass.UserID,
+		Data: map[string]any{
+			"pass_id":       pass.ID,
+			"member_name":   pass.MemberName,
+			"meal_type":     pass.MealType,
+			"dining_hall":   pass.DiningHall,
+			"is_guest_pass": pass.IsGuestPass,
+		},
+	})
+
 	c.JSON(http.StatusOK, gin.H{"message": "Food pass scanned successfully"})
 }
 
@@ -482,8 +504,10 @@ func UpdateFoodPassCategory(c *gin.Context) {
 	}
 
 	var req struct {
-		BuildingName string `json:"building_name"`
-		ColorCode    string `json:"color_code"`
+		BuildingName    string `json:"building_name"`
+		ColorCode       string `json:"color_code"`
+		DailyGuestQuota *int   `json:"daily_guest_quota"`
+		GuestPassPrice  *int   `json:"guest_pass_price"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -495,6 +519,12 @@ func UpdateFoodPassCategory(c *gin.Context) {
 	if req.BuildingName != "" {
 		update["building_name"] = req.BuildingName
 	}
+	if req.DailyGuestQuota != nil {
+		update["daily_guest_quota"] = *req.DailyGuestQuota
+	}
+	if req.GuestPassPrice != nil {
+		update["guest_pass_price"] = *req.GuestPassPrice
+	}
 	if req.ColorCode != "" {
 		if len(req.ColorCode) != 7 || req.ColorCode[0] != '#' {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid color code. Use hex format like #FF5733"})
@@ -538,49 +568,30 @@ func DeleteFoodPassCategory(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
 }
 
-func ExecuteFoodPassCleanup() (int64, error) {
-
-	now := time.Now().In(time.UTC)
-	year, month, day := now.Date()
-
-	cutoffTime := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
-
-	filter := bson.M{
-		"date": bson.M{"$lt": cutoffTime},
-	}
-
-	deleteResult, err := config.DB.Collection("food_passes").DeleteMany(
-		context.Background(),
-		filter,
+// ExecuteFoodPassCleanup marks the unused passes dated before today (IST)
+// expired. Passes are kept, since their history, guest quota counts and
+// payment links are still reported on.
+func ExecuteFoodPassCleanup(ctx context.Context) (int64, error) {
+	now := time.Now()
+	result, err := config.DB.Collection("food_passes").UpdateMany(
+		ctx,
+		bson.M{
+			"date":       bson.M{"$lt": utils.StartOfDayIST(now)},
+			"is_used":    false,
+			"is_expired": bson.M{"$ne": true},
+		},
+		bson.M{
+			"$set": bson.M{"is_expired": true, "expired_at": now},
+			"$push": bson.M{"history": models.FoodPassEvent{
+				Action: models.FoodPassExpired,
+				Reason: "Past its date",
+				At:     now,
+			}},
+		},
 	)
-
-	if err != nil {
-		return 0, fmt.Errorf("error deleting expired food passes: %w", err)
-	}
-
-	return deleteResult.DeletedCount, nil
-}
-
-func StartAutomaticCleanup() {
-
-	s := gocron.NewScheduler(time.Local)
-
-	_, err := s.Every(1).Day().At("16:35").Do(func() {
-		log.Println("Starting automatic cleanup of expired food passes...")
-
-		deletedCount, cleanupErr := ExecuteFoodPassCleanup()
-
-		if cleanupErr != nil {
-			log.Printf("ERROR: Automatic food pass cleanup failed: %v", cleanupErr)
-			return
-		}
-		log.Printf("SUCCESS: Automatic food pass cleanup deleted %d expired passes.", deletedCount)
-	})
-
 	if err != nil {
-		log.Fatalf("Could not schedule cleanup job: %v", err)
+		return 0, fmt.Errorf("error expiring old food passes: %w", err)
 	}
 
-	s.StartAsync()
-	log.Println("Cleanup scheduler started. Next run scheduled for 02:05 in the local timezone.")
+	return result.ModifiedCount, nil
 }
@@ -0,0 +1,479 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+// CreateGuestFoodPasses issues single-meal passes for day visitors or extra
+// guests joining a host for one meal. Guest passes count against the hall's
+// daily guest quota and, when chargeable, can't be scanned until paid.
+func CreateGuestFoodPasses(c *gin.Context) {
+	var req models.GuestFoodPassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if mealOrder(req.MealType) == len(mealWindows) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal type"})
+		return
+	}
+
+	day := utils.StartOfDayIST(req.Date)
+	if day.Before(utils.StartOfDayIST(time.Now())) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot issue passes for a past date"})
+		return
+	}
+
+	var host models.User
+	if err := config.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": req.UserID}).Decode(&host); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Host user not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching user"})
+		return
+	}
+
+	// Link the passes to the host's stay
+	var assignment *models.RoomAssignment
+	if req.AssignmentID != nil {
+		var a models.RoomAssignment
+		err := config.DB.Collection("room_assignments").FindOne(context.Background(), bson.M{"_id": req.AssignmentID}).Decode(&a)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room assignment not found"})
+			return
+		}
+		if a.UserID != req.UserID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Room assignment does not belong to this user"})
+			return
+		}
+		assignment = &a
+	} else {
+		var a models.RoomAssignment
+		err := config.DB.Collection("room_assignments").FindOne(context.Background(), bson.M{
+			"user_id":        req.UserID,
+			"checked_out":    false,
+			"check_in_date":  bson.M{"$lt": day.AddDate(0, 0, 1)},
+			"check_out_date": bson.M{"$gte": day},
+		}).Decode(&a)
+		if err == nil {
+			assignment = &a
+		}
+	}
+
+	diningHall := req.DiningHall
+	if diningHall == "" && assignment != nil {
+		diningHall = assignment.DiningHallPreference
+	}
+
+	var category models.FoodPassCategory
+	categoryFilter := bson.M{}
+	if diningHall != "" {
+		categoryFilter["building_name"] = diningHall
+	}
+	if err := config.DB.Collection("food_pass_categories").FindOne(context.Background(), categoryFilter).Decode(&category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dining hall not found"})
+		return
+	}
+
+	// Validate the price before taking quota slots
+	amountPerPass := category.GuestPassPrice
+	if req.AmountPerPass != nil {
+		amountPerPass = *req.AmountPerPass
+	}
+	if req.Chargeable && amountPerPass <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount_per_pass is required for chargeable passes"})
+		return
+	}
+
+	quotaKey := guestQuotaKey(category.BuildingName, day)
+	reserved, err := reserveGuestPasses(quotaKey, req.Count, category.DailyGuestQuota)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking guest pass quota"})
+		return
+	}
+	if !reserved {
+		issued, err := guestPassesIssued(quotaKey)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking guest pass quota"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{
+			"error":     "Daily guest pass quota exceeded for " + category.BuildingName,
+			"quota":     category.DailyGuestQuota,
+			"issued":    issued,
+			"remaining": max(category.DailyGuestQuota-issued, 0),
+		})
+		return
+	}
+	// Give the slots back if the passes don't get created
+	created := false
+	defer func() {
+		if !created {
+			if err := adjustGuestQuota(quotaKey, -req.Count); err != nil {
+				fmt.Printf("Error releasing guest pass quota %s: %v\n", quotaKey, err)
+			}
+		}
+	}()
+
+	staffIDVal, _ := c.Get("user_id")
+	staffObjID, _ := primitive.ObjectIDFromHex(fmt.Sprintf("%v", staffIDVal))
+
+	var payment *models.Payment
+	if req.Chargeable {
+		payment, err = createGuestPassPayment(host, assignment, req, category, amountPerPass, day)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create payment order: " + err.Error()})
+			return
+		}
+	}
+
+	var passes []interface{}
+	for i := 0; i < req.Count; i++ {
+		memberName := fmt.Sprintf("Guest %d", i+1)
+		if i < len(req.GuestNames) && req.GuestNames[i] != "" {
+			memberName = req.GuestNames[i]
+		}
+
+		id := primitive.NewObjectID()
+		qrCode, err := utils.GenerateQRCode(id.Hex())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating QR code"})
+			return
+		}
+
+		pass := models.FoodPass{
+			ID:            id,
+			UserID:        req.UserID,
+			MemberName:    memberName,
+			MealType:      req.MealType,
+			Date:          day,
+			QRCode:        qrCode,
+			DiningHall:    category.BuildingName,
+			ColorCode:     category.ColorCode,
+			CreatedBy:     staffObjID,
+			CreatedAt:     time.Now(),
+			IsGuestPass:   true,
+			GuestQuotaKey: quotaKey,
+		}
+		if assignment != nil {
+			pass.AssignmentID = &assignment.ID
+		}
+		if payment != nil {
+			pass.PaymentID = &payment.ID
+			pass.AwaitingPayment = true
+		}
+		passes = append(passes, pass)
+	}
+
+	if _, err := config.DB.Collection("food_passes").InsertMany(context.Background(), passes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating guest passes"})
+		return
+	}
+	created = true
+
+	response := gin.H{
+		"message":      "Guest food passes created successfully",
+		"total_passes": len(passes),
+		"passes":       passes,
+	}
+	if assignment != nil {
+		response["assignment_id"] = assignment.ID
+	}
+	if payment != nil {
+		razorpayKey, _ := getRazorpayCredentials()
+		response["payment"] = models.PaymentResponse{Payment: *payment, RazorpayKey: razorpayKey}
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// createGuestPassPayment creates the Razorpay order and payment record for chargeable guest passes
+func createGuestPassPayment(host models.User, assignment *models.RoomAssignment, req models.GuestFoodPassRequest, category models.FoodPassCategory, amountPerPass int, day time.Time) (*models.Payment, error) {
+	amount := amountPerPass * req.Count
+	description := fmt.Sprintf("%d guest %s pass(es) on %s at %s", req.Count, req.MealType, day.Format("02 Jan 2006"), category.BuildingName)
+	notes := map[string]string{
+		"guest_passes":    strconv.Itoa(req.Count),
+		"meal_type":       string(req.MealType),
+		"date":            day.Format("2006-01-02"),
+		"dining_hall":     category.BuildingName,
+		"amount_per_pass": strconv.Itoa(amountPerPass),
+	}
+	if assignment != nil {
+		notes["assignment_id"] = assignment.ID.Hex()
+	}
+
+	order, err := createRazorpayOrder(amount, "INR", description, notes)
+	if err != nil {
+		return nil, err
+	}
+
+	payment := models.Payment{
+		UserID:          host.ID,
+		Amount:          amount,
+		Currency:        "INR",
+		Type:            models.PaymentTypeFoodPass,
+		Status:          models.PaymentStatusCreated,
+		RazorpayOrderID: order.ID,
+		Description:     description,
+		Notes:           notes,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+	if assignment != nil {
+		payment.AssignmentID = &assignment.ID
+	}
+
+	result, err := config.DB.Collection("payments").InsertOne(context.Background(), payment)
+	if err != nil {
+		return nil, err
+	}
+	payment.ID = result.InsertedID.(primitive.ObjectID)
+
+	return &payment, nil
+}
+
+// Guest passes hold a slot of their hall's daily quota from when they are
+// issued until they expire or their payment fails. The slots are counted in
+// guest_pass_quotas and taken with a conditional update, so staff issuing
+// passes at the same time can't overshoot the quota.
+
+// guestQuotaKey identifies the guest quota of a hall on an IST day
+func guestQuotaKey(diningHall string, day time.Time) string {
+	return diningHall + "/" + utils.StartOfDayIST(day).Format("2006-01-02")
+}
+
+// reserveGuestPasses takes count slots of a guest quota, reporting false when
+// they don't fit. A quota of 0 is unlimited.
+func reserveGuestPasses(key string, count, quota int) (bool, error) {
+	filter := bson.M{"_id": key}
+	if quota > 0 {
+		if count > quota {
+			return false, nil
+		}
+		filter["issued"] = bson.M{"$lte": quota - count}
+	}
+	_, err := config.DB.Collection("guest_pass_quotas").UpdateOne(
+		context.Background(),
+		filter,
+		bson.M{"$inc": bson.M{"issued": count}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		// The quota exists but is too full to match the filter
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// adjustGuestQuota moves a guest quota by delta without checking it
+func adjustGuestQuota(key string, delta int) error {
+	_, err := config.DB.Collection("guest_pass_quotas").UpdateOne(
+		context.Background(),
+		bson.M{"_id": key},
+		bson.M{"$inc": bson.M{"issued": delta}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// guestPassesIssued returns the slots taken of a guest quota
+func guestPassesIssued(key string) (int, error) {
+	var quota models.GuestPassQuota
+	err := config.DB.Collection("guest_pass_quotas").FindOne(context.Background(), bson.M{"_id": key}).Decode(&quota)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	return quota.Issued, err
+}
+
+// releaseGuestPasses gives back the quota slots held by the guest passes
+// matching filter. Each pass is released on its own so a slot is never given
+// back twice.
+func releaseGuestPasses(ctx context.Context, filter bson.M) error {
+	holding := bson.M{"guest_quota_key": bson.M{"$exists": true}}
+	for key, value := range filter {
+		holding[key] = value
+	}
+	cursor, err := config.DB.Collection("food_passes").Find(ctx, holding, options.Find().SetProjection(bson.M{"_id": 1, "guest_quota_key": 1}))
+	if err != nil {
+		return err
+	}
+	var passes []models.FoodPass
+	if err := cursor.All(ctx, &passes); err != nil {
+		return err
+	}
+
+	for _, pass := range passes {
+		result, err := config.DB.Collection("food_passes").UpdateOne(
+			ctx,
+			bson.M{"_id": pass.ID, "guest_quota_key": pass.GuestQuotaKey},
+			bson.M{"$unset": bson.M{"guest_quota_key": ""}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+		if err := adjustGuestQuota(pass.GuestQuotaKey, -1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// takeGuestQuota gives the guest passes matching filter that hold no slot one
+// in the quota of their hall and day, without checking the quota
+func takeGuestQuota(ctx context.Context, filter bson.M) error {
+	released := bson.M{"is_guest_pass": true, "guest_quota_key": bson.M{"$exists": false}}
+	for key, value := range filter {
+		released[key] = value
+	}
+	cursor, err := config.DB.Collection("food_passes").Find(ctx, released, options.Find().SetProjection(bson.M{"_id": 1, "dining_hall": 1, "date": 1}))
+	if err != nil {
+		return err
+	}
+	var passes []models.FoodPass
+	if err := cursor.All(ctx, &passes); err != nil {
+		return err
+	}
+
+	for _, pass := range passes {
+		key := guestQuotaKey(pass.DiningHall, pass.Date)
+		result, err := config.DB.Collection("food_passes").UpdateOne(
+			ctx,
+			bson.M{"_id": pass.ID, "guest_quota_key": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"guest_quota_key": key}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+		if err := adjustGuestQuota(key, 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BackfillGuestPassQuotas counts the unexpired guest passes for today or
+// later that were issued before quotas were counted
+func BackfillGuestPassQuotas(ctx context.Context) error {
+	return takeGuestQuota(ctx, bson.M{
+		"is_expired": bson.M{"$ne": true},
+		"date":       bson.M{"$gte": utils.StartOfDayIST(time.Now())},
+	})
+}
+
+// activateGuestFoodPasses makes the guest passes a payment paid for
+// scannable, whether it was paid through Razorpay or at the desk
+func activateGuestFoodPasses(payment models.Payment) {
+	if payment.Type != models.PaymentTypeFoodPass {
+		return
+	}
+
+	// Passes that gave their slots back when an earlier attempt failed take
+	// them again. They are paid for now, so the quota isn't checked.
+	err := takeGuestQuota(context.Background(), bson.M{"payment_id": payment.ID, "awaiting_payment": true})
+	if err != nil {
+		fmt.Printf("Error restoring guest pass quota for payment %s: %v\n", payment.ID.Hex(), err)
+	}
+
+	_, err = config.DB.Collection("food_passes").UpdateMany(
+		context.Background(),
+		bson.M{"payment_id": payment.ID, "awaiting_payment": true},
+		bson.M{"$unset": bson.M{"awaiting_payment": ""}},
+	)
+	if err != nil {
+		fmt.Printf("Error activating guest passes for payment %s: %v\n", payment.ID.Hex(), err)
+	}
+}
+
+// releaseUnpaidGuestPasses gives back the quota slots of guest passes whose
+// payment through a Razorpay order failed. The passes stay unscannable and
+// take their slots again if a later attempt on the order succeeds.
+func releaseUnpaidGuestPasses(orderID string) {
+	var payment models.Payment
+	err := config.DB.Collection("payments").FindOne(context.Background(), bson.M{"razorpay_order_id": orderID}).Decode(&payment)
+	if err != nil || payment.Type != models.PaymentTypeFoodPass {
+		return
+	}
+
+	err = releaseGuestPasses(context.Background(), bson.M{"payment_id": payment.ID, "awaiting_payment": true})
+	if err != nil {
+		fmt.Printf("Error releasing guest pass quota for payment %s: %v\n", payment.ID.Hex(), err)
+	}
+}
+
+// GetGuestPassQuota returns the guest pass quota usage of each hall for a day
+func GetGuestPassQuota(c *gin.Context) {
+	day := utils.StartOfDayIST(time.Now())
+	if date := c.Query("date"); date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", date, utils.IST)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		day = parsed
+	}
+
+	filter := bson.M{}
+	if diningHall := c.Query("dining_hall"); diningHall != "" {
+		filter["building_name"] = diningHall
+	}
+
+	cursor, err := config.DB.Collection("food_pass_categories").Find(context.Background(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching categories"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var categories []models.FoodPassCategory
+	if err := cursor.All(context.Background(), &categories); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding categories"})
+		return
+	}
+
+	quotas := []gin.H{}
+	for _, category := range categories {
+		issued, err := guestPassesIssued(guestQuotaKey(category.BuildingName, day))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking guest pass quota"})
+			return
+		}
+
+		quota := gin.H{
+			"dining_hall": category.BuildingName,
+			"quota":       category.DailyGuestQuota,
+			"issued":      issued,
+		}
+		if category.DailyGuestQuota > 0 {
+			quota["remaining"] = max(category.DailyGuestQuota-issued, 0)
+		}
+		quotas = append(quotas, quota)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"date":   day.Format("2006-01-02"),
+		"quotas": quotas,
+	})
+}
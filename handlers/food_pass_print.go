@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-pdf/fpdf"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+// Card grid on an A4 page, in mm
+const (
+	printMargin      = 10.0
+	printHeaderH     = 10.0
+	printCardW       = 92.0
+	printCardH       = 62.0
+	printCardGap     = 6.0
+	printCardsPerRow = 2
+	printRowsPerPage = 4
+)
+
+// printSheet is the set of passes printed for one room assignment
+type printSheet struct {
+	Title  string
+	Passes []models.FoodPass
+}
+
+// assignmentPassFilter matches the food passes of an assignment. Passes
+// generated before passes were linked to assignments are matched by user and
+// stay dates instead.
+func assignmentPassFilter(assignment models.RoomAssignment) bson.M {
+	return bson.M{
+		"$or": []bson.M{
+			{"assignment_id": assignment.ID},
+			{
+				"user_id":       assignment.UserID,
+				"assignment_id": bson.M{"$exists": false},
+				"date": bson.M{
+					"$gte": utils.StartOfDayIST(assignment.CheckInDate),
+					"$lte": utils.StartOfDayIST(assignment.CheckOutDate),
+				},
+			},
+		},
+	}
+}
+
+// PrintFoodPasses renders food passes as a printable PDF with one card per
+// meal. Use assignment_id for a single stay or date (YYYY-MM-DD) to print
+// every stay checking in on that day.
+func PrintFoodPasses(c *gin.Context) {
+	var assignments []models.RoomAssignment
+	filename := "food-passes.pdf"
+
+	if assignmentIDStr := c.Query("assignment_id"); assignmentIDStr != "" {
+		assignmentID, err := primitive.ObjectIDFromHex(assignmentIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+			return
+		}
+
+		var assignment models.RoomAssignment
+		err = config.DB.Collection("room_assignments").FindOne(context.Background(), bson.M{"_id": assignmentID}).Decode(&assignment)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Room assignment not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching room assignment"})
+			return
+		}
+		assignments = append(assignments, assignment)
+		filename = "food-passes-" + assignmentIDStr + ".pdf"
+	} else if date := c.Query("date"); date != "" {
+		day, err := time.ParseInLocation("2006-01-02", date, utils.IST)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+
+		cursor, err := config.DB.Collection("room_assignments").Find(
+			context.Background(),
+			bson.M{
+				"check_in_date": bson.M{"$gte": day, "$lt": day.AddDate(0, 0, 1)},
+				"checked_out":   false,
+			},
+			options.Find().SetSort(bson.D{{Key: "check_in_date", Value: 1}}),
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching room assignments"})
+			return
+		}
+		defer cursor.Close(context.Background())
+
+		if err := cursor.All(context.Background(), &assignments); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding room assignments"})
+			return
+		}
+		filename = "food-passes-" + date + ".pdf"
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "assignment_id or date is required"})
+		return
+	}
+
+	var sheets []printSheet
+	for _, assignment := range assignments {
+		sheet, err := buildPrintSheet(assignment)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(sheet.Passes) > 0 {
+			sheets = append(sheets, sheet)
+		}
+	}
+
+	if len(sheets) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No food passes found to print"})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := renderFoodPassPDF(&buf, sheets); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating PDF: " + err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+func buildPrintSheet(assignment models.RoomAssignment) (printSheet, error) {
+	cursor, err := config.DB.Collection("food_passes").Find(
+		context.Background(),
+		assignmentPassFilter(assignment),
+		options.Find().SetProjection(bson.M{"qr_code": 0}),
+	)
+	if err != nil {
+		return printSheet{}, fmt.Errorf("error fetching food passes: %w", err)
+	}
+	defer cursor.Close(context.Background())
+
+	var passes []models.FoodPass
+	if err := cursor.All(context.Background(), &passes); err != nil {
+		return printSheet{}, fmt.Errorf("error decoding food passes: %w", err)
+	}
+
+	sort.SliceStable(passes, func(i, j int) bool {
+		if !passes[i].Date.Equal(passes[j].Date) {
+			return passes[i].Date.Before(passes[j].Date)
+		}
+		if mealOrder(passes[i].MealType) != mealOrder(passes[j].MealType) {
+			return mealOrder(passes[i].MealType) < mealOrder(passes[j].MealType)
+		}
+		return passes[i].MemberName < passes[j].MemberName
+	})
+
+	title := fmt.Sprintf("%s - %s",
+		assignment.CheckInDate.In(utils.IST).Format("02 Jan 2006"),
+		assignment.CheckOutDate.In(utils.IST).Format("02 Jan 2006"))
+
+	var room models.Room
+	if err := config.DB.Collection("rooms").FindOne(context.Background(), bson.M{"_id": assignment.RoomID}).Decode(&room); err == nil {
+		title = fmt.Sprintf("Room %s, %s  |  %s", room.RoomNumber, room.Building, title)
+	}
+
+	var user models.User
+	if err := config.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": assignment.UserID}).Decode(&user); err == nil {
+		title = user.Name + "  |  " + title
+	}
+
+	return printSheet{Title: title, Passes: passes}, nil
+}
+
+// renderFoodPassPDF lays out each sheet starting on a new A4 page, with a
+// grid of cut-out cards showing the dining hall colour, guest, meal and QR.
+func renderFoodPassPDF(w io.Writer, sheets []printSheet) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(printMargin, printMargin, printMargin)
+	pdf.SetAutoPageBreak(false, printMargin)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	perPage := printCardsPerRow * printRowsPerPage
+	for _, sheet := range sheets {
+		for i, pass := range sheet.Passes {
+			slot := i % perPage
+			if slot == 0 {
+				pdf.AddPage()
+				pdf.SetFont("Helvetica", "B", 11)
+				pdf.SetTextColor(0, 0, 0)
+				pdf.CellFormat(0, printHeaderH-2, tr(sheet.Title), "", 0, "L", false, 0, "")
+			}
+
+			x := printMargin + float64(slot%printCardsPerRow)*(printCardW+printCardGap)
+			y := printMargin + printHeaderH + float64(slot/printCardsPerRow)*(printCardH+printCardGap)
+			if err := drawFoodPassCard(pdf, tr, pass, x, y); err != nil {
+				return err
+			}
+		}
+	}
+
+	return pdf.Output(w)
+}
+
+func drawFoodPassCard(pdf *fpdf.Fpdf, tr func(string) string, pass models.FoodPass, x, y float64) error {
+	r, g, b := parseHexColor(pass.ColorCode)
+	const bandH = 11.0
+	const qrSize = 42.0
+
+	// Coloured band with the dining hall name
+	pdf.SetFillColor(r, g, b)
+	pdf.RoundedRect(x, y, printCardW, bandH, 3, "12", "F")
+	if (r*299+g*587+b*114)/1000 > 150 {
+		pdf.SetTextColor(0, 0, 0)
+	} else {
+		pdf.SetTextColor(255, 255, 255)
+	}
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.SetXY(x+4, y)
+	pdf.CellFormat(printCardW-8, bandH, tr(pass.DiningHall), "", 0, "L", false, 0, "")
+
+	// Dashed cut line around the card
+	pdf.SetDrawColor(150, 150, 150)
+	pdf.SetDashPattern([]float64{1.5, 1}, 0)
+	pdf.RoundedRect(x, y, printCardW, printCardH, 3, "1234", "D")
+	pdf.SetDashPattern([]float64{}, 0)
+
+	// Guest, meal and date
+	textW := printCardW - qrSize - 10
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetXY(x+4, y+bandH+4)
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.MultiCell(textW, 6, tr(pass.MemberName), "", "L", false)
+
+	pdf.SetX(x + 4)
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.SetTextColor(r, g, b)
+	pdf.CellFormat(textW, 10, string(pass.MealType), "", 2, "L", false, 0, "")
+
+	pdf.SetX(x + 4)
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetTextColor(60, 60, 60)
+	pdf.CellFormat(textW, 6, pass.Date.In(utils.IST).Format("Mon, 02 Jan 2006"), "", 2, "L", false, 0, "")
+
+	pdf.SetXY(x+4, y+printCardH-7)
+	pdf.SetFont("Helvetica", "", 7)
+	pdf.SetTextColor(130, 130, 130)
+	pdf.CellFormat(textW, 4, pass.ID.Hex(), "", 0, "L", false, 0, "")
+
+	// QR code
+	png, err := utils.GenerateQRCodePNG(pass.QRPayload(), 256)
+	if err != nil {
+		return fmt.Errorf("error generating QR code: %w", err)
+	}
+	opts := fpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader(pass.ID.Hex(), opts, bytes.NewReader(png))
+	pdf.ImageOptions(pass.ID.Hex(), x+printCardW-qrSize-4, y+bandH+(printCardH-bandH-qrSize)/2, qrSize, qrSize, false, opts, 0, "")
+
+	return pdf.Error()
+}
+
+// parseHexColor converts a #RRGGBB color code, falling back to grey
+func parseHexColor(code string) (int, int, int) {
+	if len(code) != 7 || code[0] != '#' {
+		return 120, 120, 120
+	}
+	v, err := strconv.ParseUint(code[1:], 16, 32)
+	if err != nil {
+		return 120, 120, 120
+	}
+	return int(v >> 16 & 0xff), int(v >> 8 & 0xff), int(v & 0xff)
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+func TestFoodPassCleanupKeepsPasses(t *testing.T) {
+	useTestDB(t)
+	ctx := context.Background()
+	today := utils.StartOfDayIST(time.Now())
+
+	passes := []models.FoodPass{
+		{ID: primitive.NewObjectID(), Date: today.AddDate(0, 0, -1)},               // Unused yesterday
+		{ID: primitive.NewObjectID(), Date: today.AddDate(0, 0, -1), IsUsed: true}, // Eaten yesterday
+		{ID: primitive.NewObjectID(), Date: today},                                 // Today's
+	}
+	for _, pass := range passes {
+		if _, err := config.DB.Collection("food_passes").InsertOne(ctx, pass); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expired, err := ExecuteFoodPassCleanup(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired != 1 {
+		t.Errorf("expired %d passes, want 1", expired)
+	}
+
+	for i, want := range []bool{true, false, false} {
+		var pass models.FoodPass
+		if err := config.DB.Collection("food_passes").FindOne(ctx, bson.M{"_id": passes[i].ID}).Decode(&pass); err != nil {
+			t.Fatalf("pass %d: %v", i, err)
+		}
+		if pass.IsExpired != want {
+			t.Errorf("pass %d is_expired = %v, want %v", i, pass.IsExpired, want)
+		}
+	}
+}
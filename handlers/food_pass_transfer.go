@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+// TransferFoodPasses moves all future unused passes of a user or assignment
+// from one dining hall to another. Moved passes get a new QR payload so cards
+// printed for the old hall no longer scan.
+func TransferFoodPasses(c *gin.Context) {
+	var req models.TransferFoodPassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.UserID == nil && req.AssignmentID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id or assignment_id is required"})
+		return
+	}
+	if req.FromCategoryID == req.ToCategoryID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Source and destination dining halls must be different"})
+		return
+	}
+
+	var fromCategory, toCategory models.FoodPassCategory
+	if err := config.DB.Collection("food_pass_categories").FindOne(context.Background(), bson.M{"_id": req.FromCategoryID}).Decode(&fromCategory); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Source food pass category not found"})
+		return
+	}
+	if err := config.DB.Collection("food_pass_categories").FindOne(context.Background(), bson.M{"_id": req.ToCategoryID}).Decode(&toCategory); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Destination food pass category not found"})
+		return
+	}
+
+	fromDate := utils.StartOfDayIST(time.Now())
+	if req.FromDate != nil && utils.StartOfDayIST(*req.FromDate).After(fromDate) {
+		fromDate = utils.StartOfDayIST(*req.FromDate)
+	}
+
+	filter := bson.M{
+		"dining_hall": fromCategory.BuildingName,
+		"is_used":     false,
+		"date":        bson.M{"$gte": fromDate},
+	}
+
+	var userID primitive.ObjectID
+	if req.AssignmentID != nil {
+		var assignment models.RoomAssignment
+		err := config.DB.Collection("room_assignments").FindOne(context.Background(), bson.M{"_id": req.AssignmentID}).Decode(&assignment)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Room assignment not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching room assignment"})
+			return
+		}
+		userID = assignment.UserID
+		for key, value := range assignmentPassFilter(assignment) {
+			filter[key] = value
+		}
+	} else {
+		userID = *req.UserID
+		filter["user_id"] = userID
+	}
+
+	if len(req.MemberNames) > 0 {
+		filter["member_name"] = bson.M{"$in": req.MemberNames}
+	}
+
+	cursor, err := config.DB.Collection("food_passes").Find(
+		context.Background(),
+		filter,
+		options.Find().SetProjection(bson.M{"qr_code": 0, "history": 0}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching food passes"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var passes []models.FoodPass
+	if err := cursor.All(context.Background(), &passes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding food passes"})
+		return
+	}
+
+	if len(passes) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No future unused food passes found in " + fromCategory.BuildingName})
+		return
+	}
+
+	staffIDVal, _ := c.Get("user_id")
+	staffObjID, _ := primitive.ObjectIDFromHex(fmt.Sprintf("%v", staffIDVal))
+	now := time.Now()
+
+	transfer := models.FoodPassTransfer{
+		ID:             primitive.NewObjectID(),
+		UserID:         userID,
+		AssignmentID:   req.AssignmentID,
+		MemberNames:    req.MemberNames,
+		FromCategoryID: fromCategory.ID,
+		ToCategoryID:   toCategory.ID,
+		FromDiningHall: fromCategory.BuildingName,
+		ToDiningHall:   toCategory.BuildingName,
+		FromDate:       fromDate,
+		Reason:         req.Reason,
+		TransferredBy:  staffObjID,
+		CreatedAt:      now,
+	}
+
+	event := models.FoodPassEvent{
+		Action:         models.FoodPassTransferred,
+		FromDiningHall: fromCategory.BuildingName,
+		ToDiningHall:   toCategory.BuildingName,
+		TransferID:     &transfer.ID,
+		Reason:         req.Reason,
+		By:             staffObjID,
+		At:             now,
+	}
+
+	var writes []mongo.WriteModel
+	for _, pass := range passes {
+		token := primitive.NewObjectID()
+		qrCode, err := utils.GenerateQRCode(token.Hex())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating QR code"})
+			return
+		}
+
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": pass.ID, "is_used": false}).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"dining_hall": toCategory.BuildingName,
+					"color_code":  toCategory.ColorCode,
+					"qr_token":    token,
+					"qr_code":     qrCode,
+				},
+				"$push": bson.M{"history": event},
+			}))
+	}
+
+	result, err := config.DB.Collection("food_passes").BulkWrite(
+		context.Background(),
+		writes,
+		options.BulkWrite().SetOrdered(false),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error transferring food passes"})
+		return
+	}
+	transfer.PassCount = int(result.ModifiedCount)
+
+	if _, err := config.DB.Collection("food_pass_transfers").InsertOne(context.Background(), transfer); err != nil {
+		// Passes are already moved and carry the transfer in their history
+		fmt.Printf("Error recording food pass transfer %s: %v\n", transfer.ID.Hex(), err)
+	}
+
+	// The whole stay moved, so future passes for it should default to the new hall
+	if req.AssignmentID != nil && len(req.MemberNames) == 0 {
+		_, err = config.DB.Collection("room_assignments").UpdateOne(
+			context.Background(),
+			bson.M{"_id": req.AssignmentID},
+			bson.M{"$set": bson.M{"dining_hall_preference": toCategory.BuildingName}},
+		)
+		if err != nil {
+			fmt.Printf("Error updating dining hall preference for assignment %s: %v\n", req.AssignmentID.Hex(), err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Food passes transferred successfully",
+		"transfer": transfer,
+	})
+}
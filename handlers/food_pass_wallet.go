@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+// mealWindows are the serving hours of each meal in IST, in serving order
+var mealWindows = []struct {
+	MealType models.MealType
+	Start    time.Duration
+	End      time.Duration
+}{
+	{models.Breakfast, 7 * time.Hour, 10 * time.Hour},
+	{models.Lunch, 11*time.Hour + 30*time.Minute, 14*time.Hour + 30*time.Minute},
+	{models.Dinner, 19 * time.Hour, 22 * time.Hour},
+}
+
+func mealOrder(mealType models.MealType) int {
+	for i, w := range mealWindows {
+		if w.MealType == mealType {
+			return i
+		}
+	}
+	return len(mealWindows)
+}
+
+// mealService returns the serving window of a meal on the IST day of date
+func mealService(date time.Time, mealType models.MealType) models.WalletMeal {
+	day := utils.StartOfDayIST(date)
+	meal := models.WalletMeal{
+		Date:     day.Format("2006-01-02"),
+		MealType: mealType,
+		StartsAt: day,
+		EndsAt:   day.Add(24 * time.Hour),
+	}
+	for _, w := range mealWindows {
+		if w.MealType == mealType {
+			meal.StartsAt = day.Add(w.Start)
+			meal.EndsAt = day.Add(w.End)
+		}
+	}
+	return meal
+}
+
+// GetFoodPassWallet returns a user's food passes grouped by day and member,
+// without QR images. Regular users always get their own wallet.
+func GetFoodPassWallet(c *gin.Context) {
+	currentUserID, _ := c.Get("user_id")
+	currentUserObjID, _ := primitive.ObjectIDFromHex(currentUserID.(string))
+	var currentUser models.User
+	err := config.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": currentUserObjID}).Decode(&currentUser)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching user"})
+		return
+	}
+
+	// Only staff can open someone else's wallet
+	targetUserID := currentUserObjID
+	isStaff := currentUser.Role == models.RoleSuperAdmin || currentUser.Role == models.RoleStaff
+	if userIDStr := c.Query("user_id"); userIDStr != "" && isStaff {
+		targetUserID, err = primitive.ObjectIDFromHex(userIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+	}
+
+	now := time.Now()
+	filter := bson.M{"user_id": targetUserID}
+	if c.Query("include_past") != "true" {
+		filter["date"] = bson.M{"$gte": utils.StartOfDayIST(now)}
+	}
+
+	cursor, err := config.DB.Collection("food_passes").Find(
+		context.Background(),
+		filter,
+		options.Find().SetProjection(bson.M{"qr_code": 0}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching food passes"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var passes []models.FoodPass
+	if err := cursor.All(context.Background(), &passes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding food passes"})
+		return
+	}
+
+	sort.SliceStable(passes, func(i, j int) bool {
+		if !passes[i].Date.Equal(passes[j].Date) {
+			return passes[i].Date.Before(passes[j].Date)
+		}
+		if passes[i].MemberName != passes[j].MemberName {
+			return passes[i].MemberName < passes[j].MemberName
+		}
+		return mealOrder(passes[i].MealType) < mealOrder(passes[j].MealType)
+	})
+
+	// Find the meal being served now and the next one the guest has a pass for
+	var currentMeal, nextMeal *models.WalletMeal
+	for _, pass := range passes {
+		meal := mealService(pass.Date, pass.MealType)
+		if !now.Before(meal.StartsAt) && now.Before(meal.EndsAt) {
+			if currentMeal == nil {
+				currentMeal = &meal
+			}
+			continue
+		}
+		if meal.StartsAt.After(now) && (nextMeal == nil || meal.StartsAt.Before(nextMeal.StartsAt)) {
+			nextMeal = &meal
+		}
+	}
+
+	wallet := models.FoodPassWallet{
+		UserID:      targetUserID,
+		CurrentMeal: currentMeal,
+		NextMeal:    nextMeal,
+		TotalPasses: len(passes),
+		Days:        []models.WalletDay{},
+	}
+
+	for _, pass := range passes {
+		meal := mealService(pass.Date, pass.MealType)
+
+		if len(wallet.Days) == 0 || wallet.Days[len(wallet.Days)-1].Date != meal.Date {
+			wallet.Days = append(wallet.Days, models.WalletDay{Date: meal.Date})
+		}
+		day := &wallet.Days[len(wallet.Days)-1]
+
+		if len(day.Members) == 0 || day.Members[len(day.Members)-1].MemberName != pass.MemberName {
+			day.Members = append(day.Members, models.WalletMember{MemberName: pass.MemberName})
+		}
+		member := &day.Members[len(day.Members)-1]
+
+		member.Passes = append(member.Passes, models.WalletPass{
+			ID:         pass.ID,
+			MealType:   pass.MealType,
+			DiningHall: pass.DiningHall,
+			ColorCode:  pass.ColorCode,
+			IsUsed:     pass.IsUsed,
+			UsedAt:     pass.UsedAt,
+			QRURL:      "/food-passes/" + pass.ID.Hex() + "/qr.png",
+			IsCurrent:  currentMeal != nil && currentMeal.Date == meal.Date && currentMeal.MealType == meal.MealType,
+			IsNext:     nextMeal != nil && nextMeal.Date == meal.Date && nextMeal.MealType == meal.MealType,
+		})
+	}
+
+	body, err := json.Marshal(wallet)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error encoding wallet"})
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// GetFoodPassQR renders the QR code of a single food pass as PNG or SVG,
+// depending on the route extension. The size query param is in pixels.
+func GetFoodPassQR(c *gin.Context) {
+	passID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid food pass ID"})
+		return
+	}
+
+	size := 256
+	if sizeStr := c.Query("size"); sizeStr != "" {
+		size, err = strconv.Atoi(sizeStr)
+		if err != nil || size < 64 || size > 1024 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid size. Use a value between 64 and 1024"})
+			return
+		}
+	}
+
+	var pass models.FoodPass
+	err = config.DB.Collection("food_passes").FindOne(
+		context.Background(),
+		bson.M{"_id": passID},
+		options.FindOne().SetProjection(bson.M{"qr_code": 0}),
+	).Decode(&pass)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Food pass not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching food pass"})
+		return
+	}
+
+	// Anyone but staff can only see their own passes
+	currentUserID, _ := c.Get("user_id")
+	currentUserObjID, _ := primitive.ObjectIDFromHex(currentUserID.(string))
+	if pass.UserID != currentUserObjID {
+		var currentUser models.User
+		err = config.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": currentUserObjID}).Decode(&currentUser)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching user"})
+			return
+		}
+		isStaff := currentUser.Role == models.RoleSuperAdmin || currentUser.Role == models.RoleStaff
+		if !isStaff {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can only view your own food passes"})
+			return
+		}
+	}
+
+	payload := pass.QRPayload()
+	format := "png"
+	if strings.HasSuffix(c.FullPath(), ".svg") {
+		format = "svg"
+	}
+
+	sum := sha256.Sum256([]byte(payload + "|" + format + "|" + strconv.Itoa(size)))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, max-age=86400")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	if format == "svg" {
+		svg, err := utils.GenerateQRCodeSVG(payload, size)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating QR code"})
+			return
+		}
+		c.Data(http.StatusOK, "image/svg+xml", svg)
+		return
+	}
+
+	png, err := utils.GenerateQRCodePNG(payload, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating QR code"})
+		return
+	}
+	c.Data(http.StatusOK, "image/png", png)
+}
@@ -0,0 +1,695 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/events"
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+// openCleaningStatuses are the states in which a room is still waiting to be cleaned
+var openCleaningStatuses = []models.CleaningTaskStatus{models.CleaningQueued, models.CleaningInProgress}
+
+// roomsWithArrivalToday returns the rooms that have a guest checking in today
+func roomsWithArrivalToday() (map[primitive.ObjectID]bool, error) {
+	today := utils.StartOfDayIST(time.Now())
+	cursor, err := config.DB.Collection("room_assignments").Find(
+		context.Background(),
+		bson.M{
+			"check_in_date": bson.M{"$gte": today, "$lt": today.AddDate(0, 0, 1)},
+			"checked_in":    false,
+		},
+		options.Find().SetProjection(bson.M{"room_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var assignments []models.RoomAssignment
+	if err := cursor.All(context.Background(), &assignments); err != nil {
+		return nil, err
+	}
+
+	rooms := make(map[primitive.ObjectID]bool)
+	for _, a := range assignments {
+		rooms[a.RoomID] = true
+	}
+	return rooms, nil
+}
+
+func cleaningPriority(sameDayArrival bool) models.CleaningPriority {
+	if sameDayArrival {
+		return models.CleaningPriorityHigh
+	}
+	return models.CleaningPriorityNormal
+}
+
+// createCleaningTask queues a cleaning task for a room. If the room already
+// has an open task, that task is returned instead of creating a duplicate,
+// and created is false. The task is upserted against the open task of the
+// room, and the unique index on open tasks stops a racing request from
+// inserting a second one.
+func createCleaningTask(room models.Room, assignmentID *primitive.ObjectID, source models.CleaningTaskSource, createdBy primitive.ObjectID) (*models.CleaningTask, bool, error) {
+	arrivals, err := roomsWithArrivalToday()
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now()
+	newTask := models.CleaningTask{
+		ID:                primitive.NewObjectID(),
+		RoomID:            room.ID,
+		RoomNumber:        room.RoomNumber,
+		Building:          room.Building,
+		Floor:             room.Floor,
+		AssignmentID:      assignmentID,
+		Source:            source,
+		Status:            models.CleaningQueued,
+		Priority:          cleaningPriority(arrivals[room.ID]),
+		HasSameDayArrival: arrivals[room.ID],
+		CreatedBy:         createdBy,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+
+	open := bson.M{"room_id": room.ID, "status": bson.M{"$in": openCleaningStatuses}}
+	var saved models.CleaningTask
+	err = config.DB.Collection("housekeeping_tasks").FindOneAndUpdate(
+		context.Background(),
+		open,
+		bson.M{"$setOnInsert": newTask},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&saved)
+	if mongo.IsDuplicateKeyError(err) {
+		// Another request opened a task for the room first
+		err = config.DB.Collection("housekeeping_tasks").FindOne(context.Background(), open).Decode(&saved)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &saved, saved.ID == newTask.ID, nil
+}
+
+// closeCleaningTasks marks the open tasks of a room done when staff clear
+// the needs_cleaning flag by hand
+func closeCleaningTasks(roomID, by primitive.ObjectID) error {
+	now := time.Now()
+	_, err := config.DB.Collection("housekeeping_tasks").UpdateMany(
+		context.Background(),
+		bson.M{"room_id": roomID, "status": bson.M{"$in": openCleaningStatuses}},
+		bson.M{"$set": bson.M{
+			"status":       models.CleaningDone,
+			"completed_at": now,
+			"completed_by": by,
+			"updated_at":   now,
+		}},
+	)
+	return err
+}
+
+// GetHousekeepingBoard returns every room grouped by building and floor with
+// its current cleaning task. Rooms with a same-day arrival are listed first
+// on each floor.
+func GetHousekeepingBoard(c *gin.Context) {
+	roomFilter := bson.M{}
+	if building := c.Query("building"); building != "" {
+		roomFilter["building"] = building
+	}
+	if floorStr := c.Query("floor"); floorStr != "" {
+		floor, err := strconv.Atoi(floorStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid floor"})
+			return
+		}
+		roomFilter["floor"] = floor
+	}
+
+	cursor, err := config.DB.Collection("rooms").Find(context.Background(), roomFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching rooms"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var rooms []models.Room
+	if err := cursor.All(context.Background(), &rooms); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding rooms"})
+		return
+	}
+
+	// Open tasks plus anything finished today, so the board shows the day's work
+	today := utils.StartOfDayIST(time.Now())
+	taskFilter := bson.M{
+		"$or": []bson.M{
+			{"status": bson.M{"$in": openCleaningStatuses}},
+			{"updated_at": bson.M{"$gte": today}},
+		},
+	}
+	if building := c.Query("building"); building != "" {
+		taskFilter["building"] = building
+	}
+	if floor, ok := roomFilter["floor"]; ok {
+		taskFilter["floor"] = floor
+	}
+
+	taskCursor, err := config.DB.Collection("housekeeping_tasks").Find(
+		context.Background(),
+		taskFilter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching cleaning tasks"})
+		return
+	}
+	defer taskCursor.Close(context.Background())
+
+	var tasks []models.CleaningTask
+	if err := taskCursor.All(context.Background(), &tasks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding cleaning tasks"})
+		return
+	}
+
+	// Later tasks win, so each room shows its most recent task
+	roomTasks := make(map[primitive.ObjectID]models.CleaningTask)
+	for _, task := range tasks {
+		roomTasks[task.RoomID] = task
+	}
+
+	arrivals, err := roomsWithArrivalToday()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching arrivals"})
+		return
+	}
+
+	housekeepers := make(map[primitive.ObjectID]string)
+	userCursor, err := config.DB.Collection("users").Find(
+		context.Background(),
+		bson.M{"role": bson.M{"$in": []models.UserRole{models.RoleHousekeeping, models.RoleStaff, models.RoleSuperAdmin}}},
+		options.Find().SetProjection(bson.M{"name": 1}),
+	)
+	if err == nil {
+		var users []models.User
+		if userCursor.All(context.Background(), &users) == nil {
+			for _, u := range users {
+				housekeepers[u.ID] = u.Name
+			}
+		}
+	}
+
+	sort.SliceStable(rooms, func(i, j int) bool {
+		if rooms[i].Building != rooms[j].Building {
+			return rooms[i].Building < rooms[j].Building
+		}
+		if rooms[i].Floor != rooms[j].Floor {
+			return rooms[i].Floor < rooms[j].Floor
+		}
+		if arrivals[rooms[i].ID] != arrivals[rooms[j].ID] {
+			return arrivals[rooms[i].ID]
+		}
+		return rooms[i].RoomNumber < rooms[j].RoomNumber
+	})
+
+	summary := gin.H{
+		string(models.CleaningQueued):     0,
+		string(models.CleaningInProgress): 0,
+		string(models.CleaningDone):       0,
+		string(models.CleaningInspected):  0,
+	}
+
+	buildings := []gin.H{}
+	var floors []gin.H
+	var floorRooms []gin.H
+	lastBuilding, lastFloor := "", 0
+
+	flushFloor := func() {
+		if floorRooms != nil {
+			floors = append(floors, gin.H{"floor": lastFloor, "rooms": floorRooms})
+			floorRooms = nil
+		}
+	}
+	flushBuilding := func() {
+		flushFloor()
+		if floors != nil {
+			buildings = append(buildings, gin.H{"building": lastBuilding, "floors": floors})
+			floors = nil
+		}
+	}
+
+	for i, room := range rooms {
+		if i == 0 || room.Building != lastBuilding {
+			flushBuilding()
+		} else if room.Floor != lastFloor {
+			flushFloor()
+		}
+		lastBuilding, lastFloor = room.Building, room.Floor
+
+		entry := gin.H{
+			"room_id":              room.ID,
+			"room_number":          room.RoomNumber,
+			"is_occupied":          room.IsOccupied,
+			"needs_cleaning":       room.NeedsCleaning,
+			"has_same_day_arrival": arrivals[room.ID],
+		}
+		if task, ok := roomTasks[room.ID]; ok {
+			task.HasSameDayArrival = arrivals[room.ID]
+			task.Priority = cleaningPriority(arrivals[room.ID])
+			entry["task"] = task
+			if task.AssignedTo != nil {
+				entry["assigned_to_name"] = housekeepers[*task.AssignedTo]
+			}
+			summary[string(task.Status)] = summary[string(task.Status)].(int) + 1
+		}
+		floorRooms = append(floorRooms, entry)
+	}
+	flushBuilding()
+
+	c.JSON(http.StatusOK, gin.H{
+		"date":      today.Format("2006-01-02"),
+		"summary":   summary,
+		"buildings": buildings,
+	})
+}
+
+// GetCleaningTasks lists cleaning tasks. Housekeepers only see tasks assigned
+// to them and unassigned tasks they can pick up.
+func GetCleaningTasks(c *gin.Context) {
+	currentUser, err := getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching user"})
+		return
+	}
+
+	filter := bson.M{}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	} else {
+		filter["status"] = bson.M{"$in": openCleaningStatuses}
+	}
+	if building := c.Query("building"); building != "" {
+		filter["building"] = building
+	}
+
+	if currentUser.Role == models.RoleHousekeeping {
+		filter["$or"] = []bson.M{
+			{"assigned_to": currentUser.ID},
+			{"assigned_to": bson.M{"$exists": false}},
+		}
+	} else if assignedTo := c.Query("assigned_to"); assignedTo != "" {
+		assignedToID, err := primitive.ObjectIDFromHex(assignedTo)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assigned_to ID"})
+			return
+		}
+		filter["assigned_to"] = assignedToID
+	}
+
+	cursor, err := config.DB.Collection("housekeeping_tasks").Find(
+		context.Background(),
+		filter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching cleaning tasks"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var tasks []models.CleaningTask
+	if err := cursor.All(context.Background(), &tasks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding cleaning tasks"})
+		return
+	}
+
+	arrivals, err := roomsWithArrivalToday()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching arrivals"})
+		return
+	}
+	for i := range tasks {
+		tasks[i].HasSameDayArrival = arrivals[tasks[i].RoomID]
+		tasks[i].Priority = cleaningPriority(arrivals[tasks[i].RoomID])
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].HasSameDayArrival && !tasks[j].HasSameDayArrival
+	})
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// CreateCleaningTask queues a cleaning task for a room outside of checkout
+func CreateCleaningTask(c *gin.Context) {
+	var req models.CreateCleaningTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var room models.Room
+	if err := config.DB.Collection("rooms").FindOne(context.Background(), bson.M{"_id": req.RoomID}).Decode(&room); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching room"})
+		return
+	}
+
+	if req.AssignedTo != nil {
+		if err := validateHousekeeper(*req.AssignedTo); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	staffIDVal, _ := c.Get("user_id")
+	staffObjID, _ := primitive.ObjectIDFromHex(fmt.Sprintf("%v", staffIDVal))
+
+	task, created, err := createCleaningTask(room, nil, models.CleaningSourceManual, staffObjID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating cleaning task"})
+		return
+	}
+	if !created {
+		// The room's open task keeps its own notes and assignee
+		c.JSON(http.StatusOK, task)
+		return
+	}
+
+	update := bson.M{"notes": req.Notes, "updated_at": time.Now()}
+	if req.AssignedTo != nil {
+		now := time.Now()
+		update["assigned_to"] = req.AssignedTo
+		update["assigned_by"] = staffObjID
+		update["assigned_at"] = now
+	}
+
+	err = config.DB.Collection("housekeeping_tasks").FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": task.ID},
+		bson.M{"$set": update},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(task)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating cleaning task"})
+		return
+	}
+
+	_, err = config.DB.Collection("rooms").UpdateOne(
+		context.Background(),
+		bson.M{"_id": room.ID},
+		bson.M{"$set": bson.M{"needs_cleaning": true, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating room status"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, task)
+}
+
+func validateHousekeeper(userID primitive.ObjectID) error {
+	var user models.User
+	if err := config.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		return fmt.Errorf("assignee not found")
+	}
+	if user.Role != models.RoleHousekeeping && user.Role != models.RoleStaff {
+		return fmt.Errorf("tasks can only be assigned to housekeeping or staff users")
+	}
+	return nil
+}
+
+// AssignCleaningTask assigns an open task to a housekeeper
+func AssignCleaningTask(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var req models.AssignCleaningTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validateHousekeeper(req.AssignedTo); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	staffIDVal, _ := c.Get("user_id")
+	staffObjID, _ := primitive.ObjectIDFromHex(fmt.Sprintf("%v", staffIDVal))
+	now := time.Now()
+
+	var task models.CleaningTask
+	err = config.DB.Collection("housekeeping_tasks").FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": id, "status": bson.M{"$in": openCleaningStatuses}},
+		bson.M{"$set": bson.M{
+			"assigned_to": req.AssignedTo,
+			"assigned_by": staffObjID,
+			"assigned_at": now,
+			"updated_at":  now,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&task)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Cleaning task not found or already finished"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error assigning cleaning task"})
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// loadTaskForHousekeeper fetches a task and checks that a housekeeper is
+// allowed to work on it. Unassigned tasks are open to any housekeeper.
+func loadTaskForHousekeeper(c *gin.Context) (models.CleaningTask, models.User, bool) {
+	var task models.CleaningTask
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return task, models.User{}, false
+	}
+
+	currentUser, err := getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching user"})
+		return task, currentUser, false
+	}
+
+	if err := config.DB.Collection("housekeeping_tasks").FindOne(context.Background(), bson.M{"_id": id}).Decode(&task); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Cleaning task not found"})
+			return task, currentUser, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching cleaning task"})
+		return task, currentUser, false
+	}
+
+	if currentUser.Role == models.RoleHousekeeping && task.AssignedTo != nil && *task.AssignedTo != currentUser.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This task is assigned to someone else"})
+		return task, currentUser, false
+	}
+
+	return task, currentUser, true
+}
+
+// StartCleaningTask moves a queued task to in progress and starts the clock
+func StartCleaningTask(c *gin.Context) {
+	task, currentUser, ok := loadTaskForHousekeeper(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	set := bson.M{
+		"status":     models.CleaningInProgress,
+		"started_at": now,
+		"updated_at": now,
+	}
+	// Housekeepers claim unassigned tasks by starting them
+	if task.AssignedTo == nil {
+		set["assigned_to"] = currentUser.ID
+		set["assigned_at"] = now
+	}
+
+	err := config.DB.Collection("housekeeping_tasks").FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": task.ID, "status": models.CleaningQueued},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&task)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusConflict, gin.H{"error": "Only queued tasks can be started"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error starting cleaning task"})
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// CompleteCleaningTask finishes an in-progress task, records the time spent
+// and releases the room
+func CompleteCleaningTask(c *gin.Context) {
+	task, currentUser, ok := loadTaskForHousekeeper(c)
+	if !ok {
+		return
+	}
+	if task.Status != models.CleaningInProgress || task.StartedAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only tasks in progress can be completed"})
+		return
+	}
+
+	var req struct {
+		Notes string `json:"notes"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	now := time.Now()
+	set := bson.M{
+		"status":       models.CleaningDone,
+		"completed_at": now,
+		"completed_by": currentUser.ID,
+		"updated_at":   now,
+	}
+	if req.Notes != "" {
+		set["notes"] = req.Notes
+	}
+
+	err := config.DB.Collection("housekeeping_tasks").FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": task.ID, "status": models.CleaningInProgress},
+		bson.M{
+			"$set": set,
+			"$inc": bson.M{"duration_minutes": int(now.Sub(*task.StartedAt).Minutes())},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&task)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusConflict, gin.H{"error": "Only tasks in progress can be completed"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error completing cleaning task"})
+		return
+	}
+
+	_, err = config.DB.Collection("rooms").UpdateOne(
+		context.Background(),
+		bson.M{"_id": task.RoomID},
+		bson.M{"$set": bson.M{"needs_cleaning": false, "updated_at": now}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating room status"})
+		return
+	}
+
+	events.Publish(events.Event{
+		Type:     events.RoomCleaned,
+		Building: task.Building,
+		Data: map[string]any{
+			"task_id":     task.ID,
+			"room_id":     task.RoomID,
+			"room_number": task.RoomNumber,
+			"floor":       task.Floor,
+		},
+	})
+
+	c.JSON(http.StatusOK, task)
+}
+
+// InspectCleaningTask records a supervisor's inspection of a cleaned room.
+// A failed inspection sends the task back to the queue and marks the room
+// dirty again.
+func InspectCleaningTask(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var req models.InspectCleaningTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	staffIDVal, _ := c.Get("user_id")
+	staffObjID, _ := primitive.ObjectIDFromHex(fmt.Sprintf("%v", staffIDVal))
+	now := time.Now()
+
+	var update bson.M
+	if *req.Passed {
+		update = bson.M{"$set": bson.M{
+			"status":       models.CleaningInspected,
+			"inspected_at": now,
+			"inspected_by": staffObjID,
+			"updated_at":   now,
+		}}
+	} else {
+		update = bson.M{
+			"$set":   bson.M{"status": models.CleaningQueued, "updated_at": now},
+			"$unset": bson.M{"started_at": "", "completed_at": "", "completed_by": ""},
+			"$inc":   bson.M{"reopen_count": 1},
+		}
+	}
+	if req.Notes != "" {
+		update["$set"].(bson.M)["notes"] = req.Notes
+	}
+
+	var task models.CleaningTask
+	err = config.DB.Collection("housekeeping_tasks").FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": id, "status": models.CleaningDone},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&task)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cleaning task not found or not awaiting inspection"})
+			return
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Room already has another open cleaning task"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error inspecting cleaning task"})
+		return
+	}
+
+	if !*req.Passed {
+		_, err = config.DB.Collection("rooms").UpdateOne(
+			context.Background(),
+			bson.M{"_id": task.RoomID},
+			bson.M{"$set": bson.M{"needs_cleaning": true, "updated_at": now}},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating room status"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, task)
+}
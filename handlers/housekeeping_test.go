@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"utara_backend/config"
+	"utara_backend/models"
+)
+
+func TestCreateCleaningTaskKeepsOpenTask(t *testing.T) {
+	useTestDB(t)
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	room := models.Room{ID: primitive.NewObjectID(), RoomNumber: "101", Building: "A"}
+	if _, err := config.DB.Collection("rooms").InsertOne(ctx, room); err != nil {
+		t.Fatal(err)
+	}
+	first := models.User{ID: primitive.NewObjectID(), Role: models.RoleHousekeeping}
+	second := models.User{ID: primitive.NewObjectID(), Role: models.RoleHousekeeping}
+	for _, user := range []models.User{first, second} {
+		if _, err := config.DB.Collection("users").InsertOne(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	post := func(assignee primitive.ObjectID, notes string) (int, models.CleaningTask) {
+		body := fmt.Sprintf(`{"room_id":%q,"assigned_to":%q,"notes":%q}`, room.ID.Hex(), assignee.Hex(), notes)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/housekeeping/tasks", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", primitive.NewObjectID().Hex())
+		CreateCleaningTask(c)
+		var task models.CleaningTask
+		json.Unmarshal(w.Body.Bytes(), &task)
+		return w.Code, task
+	}
+
+	code, created := post(first.ID, "Change the sheets")
+	if code != http.StatusCreated || created.AssignedTo == nil || *created.AssignedTo != first.ID {
+		t.Fatalf("first task: status %d, task %+v", code, created)
+	}
+
+	code, existing := post(second.ID, "Just the bathroom")
+	if code != http.StatusOK {
+		t.Errorf("second task for the room: status %d, want %d", code, http.StatusOK)
+	}
+	if existing.ID != created.ID || existing.Notes != "Change the sheets" || existing.AssignedTo == nil || *existing.AssignedTo != first.ID {
+		t.Errorf("second post changed the open task to %+v", existing)
+	}
+}
@@ -0,0 +1,543 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-pdf/fpdf"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+var errNotInvoiceable = errors.New("only paid or refunded payments have an invoice")
+
+var invoicePrefixes = map[models.InvoiceKind]string{
+	models.InvoiceKindInvoice:    "INV",
+	models.InvoiceKindCreditNote: "CN",
+}
+
+// invoiceTaxRate is the GST percent charged on a kind of line, configured
+// with INVOICE_TAX_ROOM_PERCENT, INVOICE_TAX_FOOD_PERCENT and
+// INVOICE_TAX_OTHER_PERCENT. Deposits are refundable and never taxed.
+func invoiceTaxRate(kind models.InvoiceLineKind) float64 {
+	name := "INVOICE_TAX_OTHER_PERCENT"
+	switch kind {
+	case models.InvoiceLineRoom, models.InvoiceLineDiscount:
+		name = "INVOICE_TAX_ROOM_PERCENT"
+	case models.InvoiceLineFoodPass:
+		name = "INVOICE_TAX_FOOD_PERCENT"
+	case models.InvoiceLineDeposit:
+		return 0
+	}
+	rate, err := strconv.ParseFloat(os.Getenv(name), 64)
+	if err != nil || rate < 0 {
+		return 0
+	}
+	return rate
+}
+
+// withTax splits a tax inclusive line amount into taxable value and tax
+func withTax(line models.InvoiceLine, rate float64) models.InvoiceLine {
+	line.TaxRate = rate
+	line.TaxableAmount = int(math.Round(float64(line.Amount) * 100 / (100 + rate)))
+	line.TaxAmount = line.Amount - line.TaxableAmount
+	return line
+}
+
+// financialYear returns the Indian financial year (April to March, IST) of t,
+// e.g. "2026-27"
+func financialYear(t time.Time) string {
+	t = t.In(utils.IST)
+	start := t.Year()
+	if t.Month() < time.April {
+		start--
+	}
+	return fmt.Sprintf("%d-%02d", start, (start+1)%100)
+}
+
+// nextInvoiceNumber allocates the next number in a kind's sequence for the
+// financial year from the invoice_counters collection
+func nextInvoiceNumber(kind models.InvoiceKind, fy string) (string, error) {
+	series := invoicePrefixes[kind] + "/" + fy
+	var counter struct {
+		Seq int `bson:"seq"`
+	}
+	err := config.DB.Collection("invoice_counters").FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": series},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%06d", series, counter.Seq), nil
+}
+
+func invoiceSeller() models.InvoiceParty {
+	name := os.Getenv("INVOICE_SELLER_NAME")
+	if name == "" {
+		name = "Utara"
+	}
+	return models.InvoiceParty{
+		Name:    name,
+		Address: os.Getenv("INVOICE_SELLER_ADDRESS"),
+		GSTIN:   os.Getenv("INVOICE_GSTIN"),
+	}
+}
+
+func invoiceBilledTo(userID primitive.ObjectID) models.InvoiceParty {
+	var user models.User
+	if err := config.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		return models.InvoiceParty{Name: userID.Hex()}
+	}
+	return models.InvoiceParty{Name: user.Name, Email: user.Email, PhoneNumber: user.PhoneNumber}
+}
+
+// invoiceLines itemizes a payment: room bookings from their quote, guest food
+// passes from the pass count, and anything else as a single line
+func invoiceLines(payment models.Payment) []models.InvoiceLine {
+	var lines []models.InvoiceLine
+	switch {
+	case payment.QuoteID != nil:
+		var quote models.Quote
+		if err := config.DB.Collection("quotes").FindOne(context.Background(), bson.M{"_id": *payment.QuoteID}).Decode(&quote); err != nil {
+			break
+		}
+		total := 0
+		for _, q := range quote.Lines {
+			kind := models.InvoiceLineRoom
+			if q.Kind == models.QuoteLineDiscount {
+				kind = models.InvoiceLineDiscount
+			}
+			lines = append(lines, models.InvoiceLine{
+				Kind:        kind,
+				Description: q.Description,
+				Quantity:    q.Quantity,
+				UnitAmount:  q.UnitAmount,
+				Amount:      q.Amount,
+			})
+			total += q.Amount
+		}
+		if total != payment.Amount {
+			lines = nil
+		}
+	case payment.Type == models.PaymentTypeFoodPass:
+		count, _ := strconv.Atoi(payment.Notes["guest_passes"])
+		perPass, _ := strconv.Atoi(payment.Notes["amount_per_pass"])
+		if count > 0 && count*perPass == payment.Amount {
+			lines = []models.InvoiceLine{{
+				Kind:        models.InvoiceLineFoodPass,
+				Description: payment.Description,
+				Quantity:    count,
+				UnitAmount:  perPass,
+				Amount:      payment.Amount,
+			}}
+		}
+	}
+
+	if lines == nil {
+		kind := models.InvoiceLineOther
+		switch payment.Type {
+		case models.PaymentTypeRoomBooking:
+			kind = models.InvoiceLineRoom
+		case models.PaymentTypeDeposit:
+			kind = models.InvoiceLineDeposit
+		case models.PaymentTypeFoodPass:
+			kind = models.InvoiceLineFoodPass
+		}
+		description := payment.Description
+		if description == "" {
+			description = string(payment.Type)
+		}
+		lines = []models.InvoiceLine{{
+			Kind:        kind,
+			Description: description,
+			Quantity:    1,
+			UnitAmount:  payment.Amount,
+			Amount:      payment.Amount,
+		}}
+	}
+
+	for i := range lines {
+		lines[i] = withTax(lines[i], invoiceTaxRate(lines[i].Kind))
+	}
+	return lines
+}
+
+func sumInvoice(invoice *models.Invoice) {
+	invoice.TaxableTotal, invoice.TaxTotal, invoice.Total = 0, 0, 0
+	for _, line := range invoice.Lines {
+		invoice.TaxableTotal += line.TaxableAmount
+		invoice.TaxTotal += line.TaxAmount
+		invoice.Total += line.Amount
+	}
+}
+
+// claimInvoice reserves a payment's invoice_id or credit_note_id field for a
+// new document so concurrent callers (verify and webhook) issue it only once.
+// It reports false if the payment already has one.
+func claimInvoice(paymentID primitive.ObjectID, field string, id primitive.ObjectID) (bool, error) {
+	result, err := config.DB.Collection("payments").UpdateOne(
+		context.Background(),
+		bson.M{"_id": paymentID, field: bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{field: id}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func releaseInvoice(paymentID primitive.ObjectID, field string) {
+	config.DB.Collection("payments").UpdateOne(
+		context.Background(),
+		bson.M{"_id": paymentID},
+		bson.M{"$unset": bson.M{field: ""}},
+	)
+}
+
+func findPaymentInvoice(paymentID primitive.ObjectID, kind models.InvoiceKind) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := config.DB.Collection("invoices").FindOne(
+		context.Background(),
+		bson.M{"payment_id": paymentID, "kind": kind},
+	).Decode(&invoice)
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// insertInvoice numbers and stores an invoice after its payment field has
+// been claimed, releasing the claim if that fails
+func insertInvoice(invoice *models.Invoice, field string) error {
+	number, err := nextInvoiceNumber(invoice.Kind, invoice.FinancialYear)
+	if err == nil {
+		invoice.Number = number
+		_, err = config.DB.Collection("invoices").InsertOne(context.Background(), invoice)
+	}
+	if err != nil {
+		releaseInvoice(invoice.PaymentID, field)
+	}
+	return err
+}
+
+// issueInvoice returns the invoice of a paid payment, issuing it on first use
+func issueInvoice(payment models.Payment) (*models.Invoice, error) {
+	if payment.Status != models.PaymentStatusPaid && payment.Status != models.PaymentStatusRefunded {
+		return nil, errNotInvoiceable
+	}
+
+	id := primitive.NewObjectID()
+	claimed, err := claimInvoice(payment.ID, "invoice_id", id)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return findPaymentInvoice(payment.ID, models.InvoiceKindInvoice)
+	}
+
+	issuedAt := time.Now()
+	if payment.PaidAt != nil {
+		issuedAt = *payment.PaidAt
+	}
+	invoice := &models.Invoice{
+		ID:            id,
+		Kind:          models.InvoiceKindInvoice,
+		FinancialYear: financialYear(issuedAt),
+		PaymentID:     payment.ID,
+		UserID:        payment.UserID,
+		RequestID:     payment.RequestID,
+		Seller:        invoiceSeller(),
+		BilledTo:      invoiceBilledTo(payment.UserID),
+		Lines:         invoiceLines(payment),
+		Currency:      payment.Currency,
+		IssuedAt:      issuedAt,
+	}
+	sumInvoice(invoice)
+	if err := insertInvoice(invoice, "invoice_id"); err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// issueCreditNote records a refund against a payment's invoice. Tax is
+// reversed in the same proportion as it was charged.
+func issueCreditNote(payment models.Payment, refundID string, amount int, reason string) (*models.Invoice, error) {
+	invoice, err := issueInvoice(payment)
+	if err != nil {
+		return nil, err
+	}
+
+	id := primitive.NewObjectID()
+	claimed, err := claimInvoice(payment.ID, "credit_note_id", id)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return findPaymentInvoice(payment.ID, models.InvoiceKindCreditNote)
+	}
+
+	line := models.InvoiceLine{
+		Kind:          models.InvoiceLineRefund,
+		Description:   "Refund against invoice " + invoice.Number,
+		Quantity:      1,
+		UnitAmount:    amount,
+		Amount:        amount,
+		TaxableAmount: amount,
+	}
+	if invoice.Total != 0 && invoice.TaxTotal != 0 {
+		line.TaxableAmount = int(math.Round(float64(amount) * float64(invoice.TaxableTotal) / float64(invoice.Total)))
+		line.TaxAmount = amount - line.TaxableAmount
+		line.TaxRate = math.Round(float64(invoice.TaxTotal)*10000/float64(invoice.TaxableTotal)) / 100
+	}
+
+	now := time.Now()
+	note := &models.Invoice{
+		ID:            id,
+		Kind:          models.InvoiceKindCreditNote,
+		FinancialYear: financialYear(now),
+		PaymentID:     payment.ID,
+		UserID:        payment.UserID,
+		RequestID:     payment.RequestID,
+		InvoiceID:     &invoice.ID,
+		RefundID:      refundID,
+		Reason:        reason,
+		Seller:        invoice.Seller,
+		BilledTo:      invoice.BilledTo,
+		Lines:         []models.InvoiceLine{line},
+		Currency:      payment.Currency,
+		IssuedAt:      now,
+	}
+	sumInvoice(note)
+	if err := insertInvoice(note, "credit_note_id"); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// issueCreditNoteForRefund issues the credit note of a refund reported by webhook
+func issueCreditNoteForRefund(razorpayPaymentID, refundID string, amount int) {
+	var payment models.Payment
+	if err := config.DB.Collection("payments").FindOne(context.Background(), bson.M{"razorpay_payment_id": razorpayPaymentID}).Decode(&payment); err != nil {
+		return
+	}
+	notifyRefundProcessed(payment, amount, "")
+	if _, err := issueCreditNote(payment, refundID, amount, ""); err != nil {
+		log.Printf("invoice: failed to issue credit note for payment %s: %v", payment.ID.Hex(), err)
+	}
+}
+
+// GetPaymentReceipt renders the invoice of a paid payment, followed by its
+// credit note if it was refunded, as a PDF. Invoices are issued here for
+// payments made before invoicing existed.
+func GetPaymentReceipt(c *gin.Context) {
+	paymentID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return
+	}
+
+	var payment models.Payment
+	err = config.DB.Collection("payments").FindOne(context.Background(), bson.M{"_id": paymentID}).Decode(&payment)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		return
+	}
+
+	user, err := getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+	if payment.UserID != user.ID && user.Role != models.RoleSuperAdmin && user.Role != models.RoleStaff {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	invoice, err := issueInvoice(payment)
+	if errors.Is(err, errNotInvoiceable) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Receipt is only available for paid payments"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error issuing invoice"})
+		return
+	}
+	documents := []models.Invoice{*invoice}
+
+	if payment.Status == models.PaymentStatusRefunded {
+		note, err := issueCreditNote(payment, payment.RefundID, payment.RefundedAmount, "")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error issuing credit note"})
+			return
+		}
+		documents = append(documents, *note)
+	}
+
+	var buf bytes.Buffer
+	if err := renderInvoicePDF(&buf, documents); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating PDF: " + err.Error()})
+		return
+	}
+
+	filename := "receipt-" + payment.ID.Hex() + ".pdf"
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// formatPaise formats an amount in paise as rupees with Indian digit grouping
+func formatPaise(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	rupees := strconv.Itoa(amount / 100)
+	if len(rupees) > 3 {
+		head, tail := rupees[:len(rupees)-3], rupees[len(rupees)-3:]
+		grouped := ""
+		for len(head) > 2 {
+			grouped = "," + head[len(head)-2:] + grouped
+			head = head[:len(head)-2]
+		}
+		rupees = head + grouped + "," + tail
+	}
+	return fmt.Sprintf("%s%s.%02d", sign, rupees, amount%100)
+}
+
+// renderInvoicePDF puts each invoice or credit note on its own A4 page
+func renderInvoicePDF(w io.Writer, invoices []models.Invoice) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(printMargin, printMargin, printMargin)
+	pdf.SetAutoPageBreak(true, printMargin)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	// Description, qty, rate, taxable, tax %, tax, amount
+	widths := []float64{70, 12, 24, 24, 14, 20, 26}
+	for _, invoice := range invoices {
+		pdf.AddPage()
+		title := "TAX INVOICE / RECEIPT"
+		if invoice.Kind == models.InvoiceKindCreditNote {
+			title = "CREDIT NOTE"
+		}
+
+		pdf.SetFont("Helvetica", "B", 16)
+		pdf.CellFormat(0, 9, title, "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(0, 6, tr(invoice.Seller.Name), "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		if invoice.Seller.Address != "" {
+			pdf.MultiCell(0, 4.5, tr(invoice.Seller.Address), "", "L", false)
+		}
+		if invoice.Seller.GSTIN != "" {
+			pdf.CellFormat(0, 4.5, "GSTIN: "+invoice.Seller.GSTIN, "", 1, "L", false, 0, "")
+		}
+		pdf.Ln(4)
+
+		details := [][2]string{
+			{"Number", invoice.Number},
+			{"Date", invoice.IssuedAt.In(utils.IST).Format("02 Jan 2006")},
+			{"Payment", invoice.PaymentID.Hex()},
+		}
+		if invoice.Kind == models.InvoiceKindCreditNote {
+			if invoice.RefundID != "" {
+				details = append(details, [2]string{"Refund", invoice.RefundID})
+			}
+			if invoice.Reason != "" {
+				details = append(details, [2]string{"Reason", invoice.Reason})
+			}
+		}
+		details = append(details, [2]string{"Billed to", invoice.BilledTo.Name})
+		if invoice.BilledTo.Email != "" {
+			details = append(details, [2]string{"", invoice.BilledTo.Email})
+		}
+		if invoice.BilledTo.PhoneNumber != "" {
+			details = append(details, [2]string{"", invoice.BilledTo.PhoneNumber})
+		}
+		for _, d := range details {
+			pdf.SetFont("Helvetica", "B", 9)
+			pdf.CellFormat(25, 5, d[0], "", 0, "L", false, 0, "")
+			pdf.SetFont("Helvetica", "", 9)
+			pdf.CellFormat(0, 5, tr(d[1]), "", 1, "L", false, 0, "")
+		}
+		pdf.Ln(5)
+
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(230, 230, 230)
+		for i, heading := range []string{"Description", "Qty", "Rate", "Taxable", "Tax %", "Tax", "Amount"} {
+			align := "R"
+			if i == 0 {
+				align = "L"
+			}
+			pdf.CellFormat(widths[i], 7, heading, "1", 0, align, true, 0, "")
+		}
+		pdf.Ln(-1)
+
+		pdf.SetFont("Helvetica", "", 9)
+		for _, line := range invoice.Lines {
+			cells := []string{
+				line.Description,
+				strconv.Itoa(line.Quantity),
+				formatPaise(line.UnitAmount),
+				formatPaise(line.TaxableAmount),
+				strconv.FormatFloat(line.TaxRate, 'f', -1, 64),
+				formatPaise(line.TaxAmount),
+				formatPaise(line.Amount),
+			}
+			for i, cell := range cells {
+				align := "R"
+				if i == 0 {
+					align = "L"
+					for pdf.GetStringWidth(tr(cell)) > widths[0]-2 && len(cell) > 3 {
+						cell = cell[:len(cell)-4] + "..."
+					}
+				}
+				pdf.CellFormat(widths[i], 6, tr(cell), "1", 0, align, false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+
+		totals := [][2]string{
+			{"Taxable value", formatPaise(invoice.TaxableTotal)},
+			{"GST", formatPaise(invoice.TaxTotal)},
+			{"Total (" + invoice.Currency + ")", formatPaise(invoice.Total)},
+		}
+		if invoice.Kind == models.InvoiceKindCreditNote {
+			totals[2][0] = "Refunded (" + invoice.Currency + ")"
+		}
+		labelW := widths[0] + widths[1] + widths[2] + widths[3] + widths[4] + widths[5]
+		for i, t := range totals {
+			style := ""
+			if i == len(totals)-1 {
+				style = "B"
+			}
+			pdf.SetFont("Helvetica", style, 9)
+			pdf.CellFormat(labelW, 6, t[0], "", 0, "R", false, 0, "")
+			pdf.CellFormat(widths[6], 6, t[1], "", 1, "R", false, 0, "")
+		}
+
+		pdf.Ln(8)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(110, 110, 110)
+		pdf.MultiCell(0, 4, "Amounts are inclusive of GST. This is a computer generated document and needs no signature.", "", "L", false)
+		pdf.SetTextColor(0, 0, 0)
+	}
+
+	return pdf.Output(w)
+}
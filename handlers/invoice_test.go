@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+func TestFinancialYear(t *testing.T) {
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2026, 3, 31, 23, 59, 0, 0, utils.IST), "2025-26"},
+		{time.Date(2026, 4, 1, 0, 0, 0, 0, utils.IST), "2026-27"},
+		// 31 Mar 18:30 UTC is already 1 Apr in IST
+		{time.Date(2026, 3, 31, 18, 29, 0, 0, time.UTC), "2025-26"},
+		{time.Date(2026, 3, 31, 18, 30, 0, 0, time.UTC), "2026-27"},
+		{time.Date(2099, 12, 31, 12, 0, 0, 0, utils.IST), "2099-00"},
+	}
+	for _, tt := range tests {
+		if got := financialYear(tt.at); got != tt.want {
+			t.Errorf("financialYear(%s) = %s, want %s", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestNextInvoiceNumber(t *testing.T) {
+	useTestDB(t)
+
+	steps := []struct {
+		kind models.InvoiceKind
+		fy   string
+		want string
+	}{
+		{models.InvoiceKindInvoice, "2025-26", "INV/2025-26/000001"},
+		{models.InvoiceKindInvoice, "2025-26", "INV/2025-26/000002"},
+		{models.InvoiceKindCreditNote, "2025-26", "CN/2025-26/000001"},
+		{models.InvoiceKindInvoice, "2026-27", "INV/2026-27/000001"},
+		{models.InvoiceKindInvoice, "2025-26", "INV/2025-26/000003"},
+	}
+	for _, step := range steps {
+		got, err := nextInvoiceNumber(step.kind, step.fy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != step.want {
+			t.Errorf("next %s number for %s = %s, want %s", step.kind, step.fy, got, step.want)
+		}
+	}
+}
+
+func TestIssueInvoiceOnce(t *testing.T) {
+	useTestDB(t)
+
+	paidAt := time.Date(2026, 4, 1, 0, 30, 0, 0, utils.IST)
+	payment := models.Payment{
+		ID:          primitive.NewObjectID(),
+		UserID:      primitive.NewObjectID(),
+		Amount:      5000,
+		Currency:    "INR",
+		Type:        models.PaymentTypeDeposit,
+		Status:      models.PaymentStatusPaid,
+		Description: "Deposit",
+		PaidAt:      &paidAt,
+	}
+	if _, err := config.DB.Collection("payments").InsertOne(context.Background(), payment); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := issueInvoice(payment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Number != "INV/2026-27/000001" {
+		t.Errorf("number = %s, want INV/2026-27/000001", first.Number)
+	}
+
+	again, err := issueInvoice(payment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != first.ID || again.Number != first.Number {
+		t.Errorf("re-issue returned %s %s, want %s %s", again.ID.Hex(), again.Number, first.ID.Hex(), first.Number)
+	}
+
+	count, err := config.DB.Collection("invoices").CountDocuments(context.Background(), bson.M{"payment_id": payment.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("%d invoices stored, want 1", count)
+	}
+	if claimed, err := claimInvoice(payment.ID, "invoice_id", primitive.NewObjectID()); err != nil || claimed {
+		t.Errorf("claimed an invoiced payment again: %v, %v", claimed, err)
+	}
+}
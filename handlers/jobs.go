@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"utara_backend/scheduler"
+	"utara_backend/utils"
+)
+
+// RegisterJobs registers all background jobs with the scheduler
+func RegisterJobs() {
+	scheduler.Register(scheduler.Job{
+		Name:        "food_pass_cleanup",
+		Description: "Expires unused food passes dated before today",
+		Cron:        "5 2 * * *",
+		Enabled:     true,
+		Run: func(ctx context.Context) (string, error) {
+			expired, err := ExecuteFoodPassCleanup(ctx)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("expired %d passes", expired), nil
+		},
+	})
+	scheduler.Register(scheduler.Job{
+		Name:        "checkin_reminders",
+		Description: "Reminds guests arriving tomorrow of their room and dining hall",
+		Cron:        "0 18 * * *",
+		Enabled:     true,
+		Run: func(ctx context.Context) (string, error) {
+			sent, err := ExecuteCheckInReminders(ctx)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("reminded %d arriving guests", sent), nil
+		},
+	})
+	scheduler.Register(scheduler.Job{
+		Name:        "checkout_reminders",
+		Description: "Reminds checked in guests leaving today to return their keys",
+		Cron:        "0 7 * * *",
+		Enabled:     true,
+		Run: func(ctx context.Context) (string, error) {
+			sent, err := ExecuteCheckOutReminders(ctx)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("reminded %d departing guests", sent), nil
+		},
+	})
+	scheduler.Register(scheduler.Job{
+		Name:        "overdue_checkouts",
+		Description: "Sends staff the rooms past their checkout date that are not checked out",
+		Cron:        "0 12 * * *",
+		Enabled:     true,
+		Run: func(ctx context.Context) (string, error) {
+			overdue, err := ExecuteOverdueCheckouts(ctx)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d overdue checkouts", overdue), nil
+		},
+	})
+	scheduler.Register(scheduler.Job{
+		Name:        "night_audit",
+		Description: "Closes yesterday: reports inconsistencies, fixes safe ones if NIGHT_AUDIT_AUTOCORRECT is set, and snapshots occupancy and revenue",
+		Cron:        "30 0 * * *",
+		Enabled:     true,
+		Run: func(ctx context.Context) (string, error) {
+			yesterday := utils.StartOfDayIST(time.Now()).AddDate(0, 0, -1)
+			audit, err := ExecuteNightAudit(ctx, yesterday, nightAuditAutoCorrect())
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("audited %s: %d findings, %d corrected", audit.Date, len(audit.Findings), audit.Corrected), nil
+		},
+	})
+}
+
+// GetJobs lists all background jobs with their config, next run and last run
+func GetJobs(c *gin.Context) {
+	jobs, err := scheduler.Status(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching jobs: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":  jobs,
+		"count": len(jobs),
+	})
+}
+
+// GetJobRuns returns the run history of a job
+func GetJobRuns(c *gin.Context) {
+	limit := int64(50)
+	if limitStr := c.Query("limit"); limitStr != "" {
+		val, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || val <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit value"})
+			return
+		}
+		limit = val
+	}
+
+	runs, err := scheduler.Runs(c.Request.Context(), c.Param("name"), limit)
+	if err != nil {
+		if errors.Is(err, scheduler.ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching job runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"runs":  runs,
+		"count": len(runs),
+	})
+}
+
+// TriggerJob runs a job now in the background
+func TriggerJob(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	run, err := scheduler.Trigger(c.Param("name"), fmt.Sprintf("%v", userID))
+	if err != nil {
+		if errors.Is(err, scheduler.ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			return
+		}
+		if errors.Is(err, scheduler.ErrJobLocked) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Job is already running"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error triggering job: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Job triggered",
+		"run":     run,
+	})
+}
+
+// PauseJob pauses scheduled runs of a job on all instances
+func PauseJob(c *gin.Context) {
+	setJobPaused(c, true)
+}
+
+// ResumeJob resumes scheduled runs of a paused job
+func ResumeJob(c *gin.Context) {
+	setJobPaused(c, false)
+}
+
+func setJobPaused(c *gin.Context, paused bool) {
+	userID, _ := c.Get("user_id")
+
+	err := scheduler.SetPaused(c.Request.Context(), c.Param("name"), paused, fmt.Sprintf("%v", userID))
+	if err != nil {
+		if errors.Is(err, scheduler.ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating job"})
+		return
+	}
+
+	message := "Job resumed"
+	if paused {
+		message = "Job paused"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"paused":  paused,
+	})
+}
@@ -0,0 +1,506 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+// blockingMaintenanceStatuses are the ticket states that keep a room out of order
+var blockingMaintenanceStatuses = []models.MaintenanceStatus{models.MaintenanceOpen, models.MaintenanceInProgress}
+
+// outOfOrderFilter matches open tickets whose out-of-order range overlaps
+// [from, to). A ticket without an end date blocks until it is resolved.
+func outOfOrderFilter(from, to time.Time) bson.M {
+	return bson.M{
+		"status":            bson.M{"$in": blockingMaintenanceStatuses},
+		"out_of_order_from": bson.M{"$lt": to},
+		"$or": []bson.M{
+			{"out_of_order_until": bson.M{"$gt": from}},
+			{"out_of_order_until": bson.M{"$exists": false}},
+		},
+	}
+}
+
+// roomOutOfOrder returns the ticket blocking a room for a stay, if any
+func roomOutOfOrder(roomID primitive.ObjectID, checkIn, checkOut time.Time) (*models.MaintenanceTicket, error) {
+	filter := outOfOrderFilter(checkIn, checkOut)
+	filter["room_id"] = roomID
+
+	var ticket models.MaintenanceTicket
+	err := config.DB.Collection("maintenance_tickets").FindOne(context.Background(), filter).Decode(&ticket)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// outOfOrderRoomIDs returns the rooms that are out of order right now
+func outOfOrderRoomIDs() ([]primitive.ObjectID, error) {
+	now := time.Now()
+	values, err := config.DB.Collection("maintenance_tickets").Distinct(context.Background(), "room_id", outOfOrderFilter(now, now.Add(time.Second)))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// outOfOrderError builds the response for an assignment blocked by maintenance
+func outOfOrderError(ticket *models.MaintenanceTicket) gin.H {
+	response := gin.H{
+		"error":     fmt.Sprintf("Room %s is out of order for maintenance: %s", ticket.RoomNumber, ticket.Issue),
+		"ticket_id": ticket.ID,
+	}
+	if ticket.OutOfOrderUntil != nil {
+		response["out_of_order_until"] = ticket.OutOfOrderUntil
+	}
+	return response
+}
+
+func maintenancePhotos(urls []string, by primitive.ObjectID) []models.MaintenancePhoto {
+	photos := make([]models.MaintenancePhoto, 0, len(urls))
+	for _, url := range urls {
+		if url == "" {
+			continue
+		}
+		photos = append(photos, models.MaintenancePhoto{URL: url, UploadedBy: by, UploadedAt: time.Now()})
+	}
+	return photos
+}
+
+// CreateMaintenanceTicket reports an issue with a room, optionally taking it
+// out of order for a date range
+func CreateMaintenanceTicket(c *gin.Context) {
+	var req models.CreateMaintenanceTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !req.Category.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid maintenance category"})
+		return
+	}
+	if req.OutOfOrderUntil != nil && req.OutOfOrderFrom == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "out_of_order_from is required with out_of_order_until"})
+		return
+	}
+	if req.OutOfOrderFrom != nil && req.OutOfOrderUntil != nil && !req.OutOfOrderUntil.After(*req.OutOfOrderFrom) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "out_of_order_until must be after out_of_order_from"})
+		return
+	}
+
+	var room models.Room
+	if err := config.DB.Collection("rooms").FindOne(context.Background(), bson.M{"_id": req.RoomID}).Decode(&room); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching room"})
+		return
+	}
+
+	reporterIDVal, _ := c.Get("user_id")
+	reporterObjID, _ := primitive.ObjectIDFromHex(fmt.Sprintf("%v", reporterIDVal))
+	now := time.Now()
+
+	ticket := models.MaintenanceTicket{
+		RoomID:          room.ID,
+		RoomNumber:      room.RoomNumber,
+		Building:        room.Building,
+		Floor:           room.Floor,
+		Issue:           req.Issue,
+		Description:     req.Description,
+		Category:        req.Category,
+		Photos:          maintenancePhotos(req.PhotoURLs, reporterObjID),
+		Status:          models.MaintenanceOpen,
+		ReportedBy:      reporterObjID,
+		AssignedTo:      req.AssignedTo,
+		OutOfOrderFrom:  req.OutOfOrderFrom,
+		OutOfOrderUntil: req.OutOfOrderUntil,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if req.AssignedTo != nil {
+		ticket.Status = models.MaintenanceInProgress
+	}
+
+	result, err := config.DB.Collection("maintenance_tickets").InsertOne(context.Background(), ticket)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating maintenance ticket"})
+		return
+	}
+	ticket.ID = result.InsertedID.(primitive.ObjectID)
+
+	response := gin.H{
+		"message": "Maintenance ticket created successfully",
+		"ticket":  ticket,
+	}
+
+	// Let staff know about stays that now clash with the out-of-order range
+	if ticket.OutOfOrderFrom != nil {
+		clashFilter := bson.M{
+			"room_id":        room.ID,
+			"checked_out":    false,
+			"no_show":        bson.M{"$ne": true},
+			"check_in_date":  bson.M{"$lt": farFuture(ticket.OutOfOrderUntil)},
+			"check_out_date": bson.M{"$gt": *ticket.OutOfOrderFrom},
+		}
+		cursor, err := config.DB.Collection("room_assignments").Find(context.Background(), clashFilter)
+		if err == nil {
+			var clashes []models.RoomAssignment
+			if cursor.All(context.Background(), &clashes) == nil && len(clashes) > 0 {
+				response["conflicting_assignments"] = clashes
+			}
+		}
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// farFuture returns t, or a date far enough ahead to stand in for an open end
+func farFuture(t *time.Time) time.Time {
+	if t != nil {
+		return *t
+	}
+	return time.Now().AddDate(100, 0, 0)
+}
+
+// maintenanceTicketFilter builds the ticket filter from the list query
+// params. out_of_order=true narrows the other filters to tickets blocking
+// their room now.
+func maintenanceTicketFilter(query url.Values, now time.Time) (bson.M, error) {
+	filter := bson.M{}
+	if roomID := query.Get("room_id"); roomID != "" {
+		roomObjID, err := primitive.ObjectIDFromHex(roomID)
+		if err != nil {
+			return nil, errors.New("Invalid room ID")
+		}
+		filter["room_id"] = roomObjID
+	}
+	if building := query.Get("building"); building != "" {
+		filter["building"] = building
+	}
+	if status := query.Get("status"); status != "" {
+		filter["status"] = status
+	}
+	if category := query.Get("category"); category != "" {
+		filter["category"] = category
+	}
+	if query.Get("out_of_order") == "true" {
+		filter = bson.M{"$and": []bson.M{filter, outOfOrderFilter(now, now.Add(time.Second))}}
+	}
+	return filter, nil
+}
+
+// GetMaintenanceTickets lists tickets filtered by room, building, status or category
+func GetMaintenanceTickets(c *gin.Context) {
+	filter, err := maintenanceTicketFilter(c.Request.URL.Query(), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cursor, err := config.DB.Collection("maintenance_tickets").Find(
+		context.Background(),
+		filter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching maintenance tickets"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	tickets := []models.MaintenanceTicket{}
+	if err := cursor.All(context.Background(), &tickets); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding maintenance tickets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tickets)
+}
+
+// GetMaintenanceTicket returns a single ticket
+func GetMaintenanceTicket(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+
+	var ticket models.MaintenanceTicket
+	if err := config.DB.Collection("maintenance_tickets").FindOne(context.Background(), bson.M{"_id": id}).Decode(&ticket); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Maintenance ticket not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching maintenance ticket"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+// UpdateMaintenanceTicket changes a ticket's status, assignee, photos or
+// out-of-order range. Resolving or closing a ticket puts the room back in service.
+func UpdateMaintenanceTicket(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+
+	var req models.UpdateMaintenanceTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var ticket models.MaintenanceTicket
+	if err := config.DB.Collection("maintenance_tickets").FindOne(context.Background(), bson.M{"_id": id}).Decode(&ticket); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Maintenance ticket not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching maintenance ticket"})
+		return
+	}
+	if ticket.Status == models.MaintenanceClosed {
+		c.JSON(http.StatusConflict, gin.H{"error": "Closed tickets cannot be updated"})
+		return
+	}
+
+	staffIDVal, _ := c.Get("user_id")
+	staffObjID, _ := primitive.ObjectIDFromHex(fmt.Sprintf("%v", staffIDVal))
+	now := time.Now()
+
+	set := bson.M{"updated_at": now}
+	unset := bson.M{}
+	update := bson.M{}
+
+	if req.Category != nil {
+		if !req.Category.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid maintenance category"})
+			return
+		}
+		set["category"] = *req.Category
+	}
+	if req.Description != nil {
+		set["description"] = *req.Description
+	}
+	if req.AssignedTo != nil {
+		set["assigned_to"] = *req.AssignedTo
+		if ticket.Status == models.MaintenanceOpen && req.Status == nil {
+			set["status"] = models.MaintenanceInProgress
+		}
+	}
+	if req.ResolutionNotes != nil {
+		set["resolution_notes"] = *req.ResolutionNotes
+	}
+
+	if req.ClearOutOfOrder {
+		unset["out_of_order_from"] = ""
+		unset["out_of_order_until"] = ""
+	} else if req.OutOfOrderFrom != nil || req.OutOfOrderUntil != nil {
+		from, until := ticket.OutOfOrderFrom, ticket.OutOfOrderUntil
+		if req.OutOfOrderFrom != nil {
+			from = req.OutOfOrderFrom
+		}
+		if req.OutOfOrderUntil != nil {
+			until = req.OutOfOrderUntil
+		}
+		if from == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "out_of_order_from is required with out_of_order_until"})
+			return
+		}
+		if until != nil && !until.After(*from) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "out_of_order_until must be after out_of_order_from"})
+			return
+		}
+		set["out_of_order_from"] = *from
+		if until != nil {
+			set["out_of_order_until"] = *until
+		}
+	}
+
+	if req.Status != nil {
+		switch *req.Status {
+		case models.MaintenanceOpen, models.MaintenanceInProgress:
+			set["status"] = *req.Status
+			unset["resolved_at"] = ""
+			unset["resolved_by"] = ""
+		case models.MaintenanceResolved, models.MaintenanceClosed:
+			set["status"] = *req.Status
+			if ticket.ResolvedAt == nil {
+				set["resolved_at"] = now
+				set["resolved_by"] = staffObjID
+			}
+			if *req.Status == models.MaintenanceClosed {
+				set["closed_at"] = now
+			}
+			// Trim the out-of-order range to when the room actually came back
+			if ticket.OutOfOrderFrom != nil && !req.ClearOutOfOrder {
+				if ticket.OutOfOrderFrom.After(now) {
+					unset["out_of_order_from"] = ""
+					unset["out_of_order_until"] = ""
+				} else if ticket.OutOfOrderUntil == nil || ticket.OutOfOrderUntil.After(now) {
+					set["out_of_order_until"] = now
+				}
+			}
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+			return
+		}
+	}
+
+	update["$set"] = set
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if photos := maintenancePhotos(req.AddPhotoURLs, staffObjID); len(photos) > 0 {
+		update["$push"] = bson.M{"photos": bson.M{"$each": photos}}
+	}
+
+	err = config.DB.Collection("maintenance_tickets").FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": id},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&ticket)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating maintenance ticket"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+// GetRecurringMaintenanceIssues reports rooms that keep needing the same
+// repair, and ticket counts per building and category
+func GetRecurringMaintenanceIssues(c *gin.Context) {
+	since := utils.StartOfDayIST(time.Now().AddDate(0, 0, -90))
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", sinceStr, utils.IST)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		since = parsed
+	}
+
+	minCount := 2
+	if minStr := c.Query("min_count"); minStr != "" {
+		parsed, err := strconv.Atoi(minStr)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid min_count"})
+			return
+		}
+		minCount = parsed
+	}
+
+	match := bson.M{"created_at": bson.M{"$gte": since}}
+	if building := c.Query("building"); building != "" {
+		match["building"] = building
+	}
+
+	roomPipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		// Oldest first so $last picks the latest issue of each room
+		{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         bson.M{"room_id": "$room_id", "category": "$category"},
+			"room_number": bson.M{"$first": "$room_number"},
+			"building":    bson.M{"$first": "$building"},
+			"floor":       bson.M{"$first": "$floor"},
+			"count":       bson.M{"$sum": 1},
+			"last_issue":  bson.M{"$last": "$issue"},
+			"last_at":     bson.M{"$max": "$created_at"},
+			"open":        bson.M{"$sum": bson.M{"$cond": []interface{}{bson.M{"$in": []interface{}{"$status", blockingMaintenanceStatuses}}, 1, 0}}},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gte": minCount}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "last_at", Value: -1}}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":         0,
+			"room_id":     "$_id.room_id",
+			"category":    "$_id.category",
+			"room_number": 1,
+			"building":    1,
+			"floor":       1,
+			"count":       1,
+			"open":        1,
+			"last_issue":  1,
+			"last_at":     1,
+		}}},
+	}
+
+	buildingPipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"building": "$building", "category": "$category"},
+			"count": bson.M{"$sum": 1},
+			"rooms": bson.M{"$addToSet": "$room_id"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":      0,
+			"building": "$_id.building",
+			"category": "$_id.category",
+			"count":    1,
+			"rooms":    bson.M{"$size": "$rooms"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "building", Value: 1}, {Key: "count", Value: -1}}}},
+	}
+
+	rooms := []bson.M{}
+	cursor, err := config.DB.Collection("maintenance_tickets").Aggregate(context.Background(), roomPipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error aggregating maintenance tickets"})
+		return
+	}
+	if err := cursor.All(context.Background(), &rooms); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding maintenance report"})
+		return
+	}
+
+	buildings := []bson.M{}
+	cursor, err = config.DB.Collection("maintenance_tickets").Aggregate(context.Background(), buildingPipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error aggregating maintenance tickets"})
+		return
+	}
+	if err := cursor.All(context.Background(), &buildings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding maintenance report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"since":           since.Format("2006-01-02"),
+		"min_count":       minCount,
+		"recurring_rooms": rooms,
+		"by_building":     buildings,
+	})
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestMaintenanceTicketFilter(t *testing.T) {
+	now := time.Now()
+
+	filter, err := maintenanceTicketFilter(url.Values{"status": {"RESOLVED"}, "building": {"A"}, "out_of_order": {"true"}}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := bson.M{"$and": []bson.M{
+		{"status": "RESOLVED", "building": "A"},
+		outOfOrderFilter(now, now.Add(time.Second)),
+	}}
+	if !reflect.DeepEqual(filter, want) {
+		t.Errorf("status with out_of_order = %v, want both conditions %v", filter, want)
+	}
+
+	filter, err = maintenanceTicketFilter(url.Values{"status": {"RESOLVED"}}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(filter, bson.M{"status": "RESOLVED"}) {
+		t.Errorf("status alone = %v", filter)
+	}
+
+	if _, err := maintenanceTicketFilter(url.Values{"room_id": {"101"}}, now); err == nil {
+		t.Error("an invalid room ID was accepted")
+	}
+}
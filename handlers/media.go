@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/storage"
+	"utara_backend/utils"
+)
+
+const maxImagesPerUpload = 10
+
+var errInvalidUpload = errors.New("invalid upload")
+
+// maxUploadBytes is the per-file limit, MEDIA_MAX_UPLOAD_MB (default 5 MB)
+func maxUploadBytes() int64 {
+	if mb, err := strconv.Atoi(os.Getenv("MEDIA_MAX_UPLOAD_MB")); err == nil && mb > 0 {
+		return int64(mb) << 20
+	}
+	return 5 << 20
+}
+
+// readUpload reads an uploaded file, enforcing the size limit
+func readUpload(fh *multipart.FileHeader) ([]byte, error) {
+	limit := maxUploadBytes()
+	if fh.Size > limit {
+		return nil, fmt.Errorf("%w: %s is larger than %d MB", errInvalidUpload, fh.Filename, limit>>20)
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: %s is larger than %d MB", errInvalidUpload, fh.Filename, limit>>20)
+	}
+	return data, nil
+}
+
+// storeImage validates an uploaded image and stores it with a thumbnail
+// under prefix
+func storeImage(ctx context.Context, fh *multipart.FileHeader, prefix, description string) (models.RoomImage, error) {
+	data, err := readUpload(fh)
+	if err != nil {
+		return models.RoomImage{}, err
+	}
+
+	info, err := utils.InspectImage(data)
+	if err != nil {
+		return models.RoomImage{}, fmt.Errorf("%w: %s: %v", errInvalidUpload, fh.Filename, err)
+	}
+	thumbnail, err := utils.GenerateThumbnail(data, utils.ThumbnailSize)
+	if err != nil {
+		return models.RoomImage{}, fmt.Errorf("%w: %s: %v", errInvalidUpload, fh.Filename, err)
+	}
+
+	id := primitive.NewObjectID()
+	image := models.RoomImage{
+		ID:           id,
+		Key:          prefix + "/" + id.Hex() + info.Extension,
+		ThumbnailKey: prefix + "/" + id.Hex() + "_thumb.jpg",
+		ContentType:  info.ContentType,
+		Size:         int64(len(data)),
+		Width:        info.Width,
+		Height:       info.Height,
+		Description:  description,
+		UploadedAt:   time.Now(),
+	}
+
+	if err := storage.Files.Put(ctx, image.Key, data, info.ContentType); err != nil {
+		return models.RoomImage{}, err
+	}
+	if err := storage.Files.Put(ctx, image.ThumbnailKey, thumbnail, "image/jpeg"); err != nil {
+		storage.Files.Delete(ctx, image.Key)
+		return models.RoomImage{}, err
+	}
+
+	image.URL = storage.Files.URL(image.Key)
+	image.ThumbnailURL = storage.Files.URL(image.ThumbnailKey)
+	return image, nil
+}
+
+// storeImages stores the files of the "images" form field. Nothing is kept
+// if any file fails.
+func storeImages(c *gin.Context, prefix string) ([]models.RoomImage, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, fmt.Errorf("%w: expected a multipart form", errInvalidUpload)
+	}
+	files := form.File["images"]
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: no files in the images field", errInvalidUpload)
+	}
+	if len(files) > maxImagesPerUpload {
+		return nil, fmt.Errorf("%w: at most %d images per upload", errInvalidUpload, maxImagesPerUpload)
+	}
+
+	description := c.PostForm("description")
+	images := make([]models.RoomImage, 0, len(files))
+	for _, fh := range files {
+		image, err := storeImage(c.Request.Context(), fh, prefix, description)
+		if err != nil {
+			deleteStoredImages(images)
+			return nil, err
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+// deleteStoredImages removes uploaded images from storage. Images without a
+// key only have an external URL and are left alone.
+func deleteStoredImages(images []models.RoomImage) {
+	for _, image := range images {
+		for _, key := range []string{image.Key, image.ThumbnailKey} {
+			if key == "" {
+				continue
+			}
+			if err := storage.Files.Delete(context.Background(), key); err != nil {
+				log.Printf("media: failed to delete %s: %v", key, err)
+			}
+		}
+	}
+}
+
+func respondUploadError(c *gin.Context, err error) {
+	if errors.Is(err, errInvalidUpload) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("media: upload failed: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store image"})
+}
+
+// addImages stores uploaded images and appends them to the document's images
+func addImages(c *gin.Context, collection, prefix, notFound string) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	count, err := config.DB.Collection(collection).CountDocuments(context.Background(), bson.M{"_id": id})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+		return
+	}
+
+	images, err := storeImages(c, prefix+"/"+id.Hex())
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	_, err = config.DB.Collection(collection).UpdateOne(
+		context.Background(),
+		bson.M{"_id": id},
+		bson.M{
+			"$push": bson.M{"images": bson.M{"$each": images}},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		deleteStoredImages(images)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save images"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Images uploaded successfully",
+		"images":  images,
+	})
+}
+
+// removeImage pulls an image from the document and deletes its files
+func removeImage(c *gin.Context, collection, notFound string) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	imageID, err := primitive.ObjectIDFromHex(c.Param("image_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image ID"})
+		return
+	}
+
+	var doc struct {
+		Images []models.RoomImage `bson:"images"`
+	}
+	err = config.DB.Collection(collection).FindOne(context.Background(), bson.M{"_id": id}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	var removed []models.RoomImage
+	for _, image := range doc.Images {
+		if image.ID == imageID {
+			removed = append(removed, image)
+		}
+	}
+	if len(removed) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	}
+
+	_, err = config.DB.Collection(collection).UpdateOne(
+		context.Background(),
+		bson.M{"_id": id},
+		bson.M{
+			"$pull": bson.M{"images": bson.M{"id": imageID}},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove image"})
+		return
+	}
+	deleteStoredImages(removed)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Image deleted successfully"})
+}
+
+// UploadRoomImages adds images to a room from the multipart "images" field
+func UploadRoomImages(c *gin.Context) {
+	addImages(c, "rooms", "rooms", "Room not found")
+}
+
+// DeleteRoomImage removes an image from a room
+func DeleteRoomImage(c *gin.Context) {
+	removeImage(c, "rooms", "Room not found")
+}
+
+// UploadRoomCategoryImages adds images to a room category
+func UploadRoomCategoryImages(c *gin.Context) {
+	addImages(c, "room_category", "room-categories", "Category not found")
+}
+
+// DeleteRoomCategoryImage removes an image from a room category
+func DeleteRoomCategoryImage(c *gin.Context) {
+	removeImage(c, "room_category", "Category not found")
+}
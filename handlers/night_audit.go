@@ -0,0 +1,567 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+// nightAuditSystem marks history entries written by the night audit
+const nightAuditSystem = "night audit"
+
+// nightAuditAutoCorrect reports whether the scheduled audit fixes what is
+// safe to fix, from NIGHT_AUDIT_AUTOCORRECT (default false)
+func nightAuditAutoCorrect() bool {
+	v, _ := strconv.ParseBool(os.Getenv("NIGHT_AUDIT_AUTOCORRECT"))
+	return v
+}
+
+// stayLookup holds the rooms and guests that findings about stays describe
+type stayLookup struct {
+	rooms map[primitive.ObjectID]models.Room
+	order []models.Room // Rooms by building and number
+	users map[primitive.ObjectID]models.User
+}
+
+func newStayLookup(ctx context.Context) (*stayLookup, error) {
+	l := &stayLookup{
+		rooms: map[primitive.ObjectID]models.Room{},
+		users: map[primitive.ObjectID]models.User{},
+	}
+	cursor, err := config.DB.Collection("rooms").Find(
+		ctx,
+		bson.M{},
+		options.Find().SetSort(bson.D{{Key: "building", Value: 1}, {Key: "room_number", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching rooms: %w", err)
+	}
+	if err := cursor.All(ctx, &l.order); err != nil {
+		return nil, fmt.Errorf("error decoding rooms: %w", err)
+	}
+	for _, room := range l.order {
+		l.rooms[room.ID] = room
+	}
+	return l, nil
+}
+
+// describeStay names the guest and room of a stay for a finding
+func (l *stayLookup) describeStay(assignment models.RoomAssignment) string {
+	guest := l.users[assignment.UserID].Name
+	if guest == "" {
+		guest = assignment.UserID.Hex()
+	}
+	room, ok := l.rooms[assignment.RoomID]
+	if !ok {
+		return fmt.Sprintf("%s in deleted room %s", guest, assignment.RoomID.Hex())
+	}
+	return fmt.Sprintf("%s in room %s, %s", guest, room.RoomNumber, room.Building)
+}
+
+func (l *stayLookup) loadUsers(assignments []models.RoomAssignment) error {
+	var ids []primitive.ObjectID
+	for _, assignment := range assignments {
+		if _, ok := l.users[assignment.UserID]; !ok {
+			ids = append(ids, assignment.UserID)
+		}
+	}
+	users, err := usersByID(ids)
+	if err != nil {
+		return err
+	}
+	for id, user := range users {
+		l.users[id] = user
+	}
+	return nil
+}
+
+type nightAudit struct {
+	models.NightAudit
+	*stayLookup
+	end time.Time // End of the audited day
+}
+
+func (a *nightAudit) add(issue models.NightAuditIssue, collection string, id primitive.ObjectID, description string, corrected bool) {
+	a.Findings = append(a.Findings, models.NightAuditFinding{
+		Issue:       issue,
+		Collection:  collection,
+		RefID:       id,
+		Description: description,
+		Corrected:   corrected,
+	})
+	a.Counts[issue]++
+	if corrected {
+		a.Corrected++
+	}
+}
+
+func findAssignments(ctx context.Context, filter bson.M) ([]models.RoomAssignment, error) {
+	cursor, err := config.DB.Collection("room_assignments").Find(
+		ctx,
+		filter,
+		options.Find().SetSort(bson.D{{Key: "check_in_date", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var assignments []models.RoomAssignment
+	err = cursor.All(ctx, &assignments)
+	return assignments, err
+}
+
+// auditOverdueCheckouts reports guests due out by the end of the day who
+// haven't checked out. Only staff can tell whether they are still here, so
+// these are never corrected.
+func (a *nightAudit) auditOverdueCheckouts(ctx context.Context) error {
+	assignments, err := findAssignments(ctx, bson.M{
+		"checked_in":     true,
+		"checked_out":    false,
+		"check_out_date": bson.M{"$lt": a.end},
+	})
+	if err != nil {
+		return err
+	}
+	if err := a.loadUsers(assignments); err != nil {
+		return err
+	}
+	for _, assignment := range assignments {
+		a.add(models.AuditOverdueCheckout, "room_assignments", assignment.ID,
+			fmt.Sprintf("%s was due to check out on %s", a.describeStay(assignment), notificationDate(assignment.CheckOutDate)),
+			false)
+	}
+	return nil
+}
+
+// markRequestNoShow moves an approved request to NO_SHOW
+func markRequestNoShow(ctx context.Context, requestID primitive.ObjectID, now time.Time) (bool, error) {
+	result, err := config.DB.Collection("room_requests").UpdateOne(
+		ctx,
+		bson.M{"_id": requestID, "status": models.StatusApproved},
+		bson.M{
+			"$set": bson.M{"status": models.StatusNoShow, "updated_at": now},
+			"$push": bson.M{"history": models.RoomRequestEvent{
+				Action:  models.RequestMarkedNoShow,
+				Details: nightAuditSystem,
+				At:      now,
+			}},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// clearRequestNoShow moves a request marked NO_SHOW back to APPROVED once its
+// guest checks in after all
+func clearRequestNoShow(ctx context.Context, requestID, by primitive.ObjectID, now time.Time) error {
+	_, err := config.DB.Collection("room_requests").UpdateOne(
+		ctx,
+		bson.M{"_id": requestID, "status": models.StatusNoShow},
+		bson.M{
+			"$set": bson.M{"status": models.StatusApproved, "updated_at": now},
+			"$push": bson.M{"history": models.RoomRequestEvent{
+				Action:  models.RequestNoShowCleared,
+				Details: "Checked in after being marked a no-show",
+				By:      by,
+				At:      now,
+			}},
+		},
+	)
+	return err
+}
+
+// noShowCutoff returns the arrival time before which a guest who hasn't
+// checked in is a no-show in the audit of the day ending at end. Guests due
+// on the audited day itself get a day's grace, since a late arrival may
+// still check in after midnight.
+func noShowCutoff(end time.Time) time.Time {
+	return end.AddDate(0, 0, -1)
+}
+
+// auditNoShows reports approved stays which should have arrived before the
+// audited day but never checked in. Correcting marks them NO_SHOW, which lets
+// auditOccupiedRooms release their rooms.
+func (a *nightAudit) auditNoShows(ctx context.Context) error {
+	now := time.Now()
+	cutoff := noShowCutoff(a.end)
+	assignments, err := findAssignments(ctx, bson.M{
+		"checked_in":    false,
+		"checked_out":   false,
+		"no_show":       bson.M{"$ne": true},
+		"check_in_date": bson.M{"$lt": cutoff},
+	})
+	if err != nil {
+		return err
+	}
+	if err := a.loadUsers(assignments); err != nil {
+		return err
+	}
+	for _, assignment := range assignments {
+		corrected := false
+		if a.AutoCorrect {
+			result, err := config.DB.Collection("room_assignments").UpdateOne(
+				ctx,
+				bson.M{"_id": assignment.ID, "checked_in": false, "no_show": bson.M{"$ne": true}},
+				bson.M{"$set": bson.M{"no_show": true, "no_show_at": now}},
+			)
+			if err != nil {
+				return err
+			}
+			corrected = result.ModifiedCount > 0
+			if corrected {
+				if _, err := markRequestNoShow(ctx, assignment.RequestID, now); err != nil {
+					return err
+				}
+			}
+		}
+		a.add(models.AuditNoShow, "room_assignments", assignment.ID,
+			fmt.Sprintf("%s was due to arrive on %s but never checked in", a.describeStay(assignment), notificationDate(assignment.CheckInDate)),
+			corrected)
+	}
+
+	// Requests approved without a room never get an assignment to check in
+	cursor, err := config.DB.Collection("room_requests").Find(
+		ctx,
+		bson.M{"status": models.StatusApproved, "check_in_date": bson.M{"$lt": cutoff}},
+		options.Find().SetProjection(bson.M{"_id": 1, "name": 1, "user_id": 1, "check_in_date": 1, "public_id": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	var requests []models.RoomRequest
+	if err := cursor.All(ctx, &requests); err != nil {
+		return err
+	}
+	for _, request := range requests {
+		count, err := config.DB.Collection("room_assignments").CountDocuments(ctx, bson.M{"request_id": request.ID})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		corrected := false
+		if a.AutoCorrect {
+			if corrected, err = markRequestNoShow(ctx, request.ID, now); err != nil {
+				return err
+			}
+		}
+		a.add(models.AuditNoShow, "room_requests", request.ID,
+			fmt.Sprintf("Request %s of %s was approved for %s but never given a room or checked in", request.PublicID, request.Name, notificationDate(request.CheckInDate)),
+			corrected)
+	}
+	return nil
+}
+
+// holdingRoom reports whether any stay still holds the room: one not checked
+// out and not a no-show
+func holdingRoom(ctx context.Context, roomID primitive.ObjectID) (bool, error) {
+	count, err := config.DB.Collection("room_assignments").CountDocuments(ctx, bson.M{
+		"room_id":     roomID,
+		"checked_out": false,
+		"no_show":     bson.M{"$ne": true},
+	})
+	return count > 0, err
+}
+
+// releaseRoom clears the occupied flag of a room, reporting whether it was set
+func releaseRoom(ctx context.Context, roomID primitive.ObjectID) (bool, error) {
+	result, err := config.DB.Collection("rooms").UpdateOne(
+		ctx,
+		bson.M{"_id": roomID, "is_occupied": true},
+		bson.M{"$set": bson.M{"is_occupied": false, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// expireFoodPasses expires the passes matching filter so they can't be
+// scanned, recording why in their history and giving back the guest quota
+// slots they held
+func expireFoodPasses(ctx context.Context, filter bson.M, reason string) error {
+	// Expired guest passes no longer count against their hall's quota
+	if err := releaseGuestPasses(ctx, filter); err != nil {
+		return err
+	}
+	now := time.Now()
+	_, err := config.DB.Collection("food_passes").UpdateMany(ctx, filter, bson.M{
+		"$set": bson.M{"is_expired": true, "expired_at": now},
+		"$push": bson.M{"history": models.FoodPassEvent{
+			Action: models.FoodPassExpired,
+			Reason: reason,
+			At:     now,
+		}},
+	})
+	return err
+}
+
+// auditOccupiedRooms reports rooms flagged occupied that no stay holds.
+// Correcting releases them.
+func (a *nightAudit) auditOccupiedRooms(ctx context.Context) error {
+	for _, room := range a.order {
+		if !room.IsOccupied {
+			continue
+		}
+		held, err := holdingRoom(ctx, room.ID)
+		if err != nil {
+			return err
+		}
+		if held {
+			continue
+		}
+		corrected := false
+		if a.AutoCorrect {
+			if corrected, err = releaseRoom(ctx, room.ID); err != nil {
+				return err
+			}
+		}
+		a.add(models.AuditOccupiedWithoutStay, "rooms", room.ID,
+			fmt.Sprintf("Room %s, %s is marked occupied but no stay holds it", room.RoomNumber, room.Building),
+			corrected)
+	}
+	return nil
+}
+
+// unusedPasses matches the food passes of a stay that can still be scanned
+func unusedPasses(assignmentID primitive.ObjectID) bson.M {
+	return bson.M{"assignment_id": assignmentID, "is_used": false, "is_expired": bson.M{"$ne": true}}
+}
+
+// checkedOutWithPasses finds the stays that have checked out but still have
+// food passes that can be scanned
+func checkedOutWithPasses(ctx context.Context) ([]models.RoomAssignment, error) {
+	ids, err := config.DB.Collection("food_passes").Distinct(ctx, "assignment_id", bson.M{
+		"is_used":       false,
+		"is_expired":    bson.M{"$ne": true},
+		"assignment_id": bson.M{"$exists": true},
+	})
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	return findAssignments(ctx, bson.M{"_id": bson.M{"$in": ids}, "checked_out": true})
+}
+
+// auditPassesAfterCheckout reports unused food passes of stays that have
+// checked out. Correcting expires them so they can't be scanned.
+func (a *nightAudit) auditPassesAfterCheckout(ctx context.Context) error {
+	assignments, err := checkedOutWithPasses(ctx)
+	if err != nil {
+		return err
+	}
+	if err := a.loadUsers(assignments); err != nil {
+		return err
+	}
+
+	for _, assignment := range assignments {
+		count, err := config.DB.Collection("food_passes").CountDocuments(ctx, unusedPasses(assignment.ID))
+		if err != nil {
+			return err
+		}
+		corrected := false
+		if a.AutoCorrect {
+			if err := expireFoodPasses(ctx, unusedPasses(assignment.ID), nightAuditSystem+": stay checked out"); err != nil {
+				return err
+			}
+			corrected = true
+		}
+		a.add(models.AuditPassAfterCheckout, "room_assignments", assignment.ID,
+			fmt.Sprintf("%d unused food passes of %s after checkout", count, a.describeStay(assignment)),
+			corrected)
+	}
+	return nil
+}
+
+// snapshot records the occupancy and revenue of the audited day
+func (a *nightAudit) snapshot(day time.Time) error {
+	var occupancy models.Analytics
+	if err := occupancyAnalytics(&occupancy, day, a.end, ""); err != nil {
+		return err
+	}
+	daily := occupancy.Daily[0]
+	a.Snapshot = models.NightAuditSnapshot{
+		Rooms:      daily.Total,
+		Occupied:   daily.Occupied,
+		Occupancy:  daily.Rate,
+		Arrivals:   daily.Arrivals,
+		Departures: daily.Departures,
+		NoShows:    a.Counts[models.AuditNoShow],
+		Revenue:    []models.CollectionRow{},
+	}
+
+	paid, err := findPayments(bson.M{
+		"status":  bson.M{"$in": []models.PaymentStatus{models.PaymentStatusPaid, models.PaymentStatusRefunded}},
+		"paid_at": bson.M{"$gte": day, "$lt": a.end},
+	}, "paid_at")
+	if err != nil {
+		return err
+	}
+	refunded, err := findPayments(refundedInRange(day, a.end), "updated_at")
+	if err != nil {
+		return err
+	}
+	byType := map[models.PaymentType]int{}
+	revenue := func(t models.PaymentType) *models.CollectionRow {
+		if i, ok := byType[t]; ok {
+			return &a.Snapshot.Revenue[i]
+		}
+		byType[t] = len(a.Snapshot.Revenue)
+		a.Snapshot.Revenue = append(a.Snapshot.Revenue, models.CollectionRow{Period: a.Date, Type: t})
+		return &a.Snapshot.Revenue[byType[t]]
+	}
+	for _, p := range paid {
+		row := revenue(p.Type)
+		row.Payments++
+		row.Collected += p.Amount
+		row.Net = row.Collected - row.Refunded
+		a.Snapshot.Collected += p.Amount
+	}
+	for _, p := range refunded {
+		row := revenue(p.Type)
+		row.Refunds++
+		row.Refunded += p.RefundedAmount
+		row.Net = row.Collected - row.Refunded
+		a.Snapshot.Refunded += p.RefundedAmount
+	}
+	a.Snapshot.Net = a.Snapshot.Collected - a.Snapshot.Refunded
+	return nil
+}
+
+// ExecuteNightAudit closes the IST business day starting at day: it finds
+// the inconsistencies left at the end of the day, fixes the safe ones when
+// autoCorrect is set, and stores the audit with a snapshot of the day,
+// replacing any earlier audit of the same day
+func ExecuteNightAudit(ctx context.Context, day time.Time, autoCorrect bool) (*models.NightAudit, error) {
+	day = utils.StartOfDayIST(day)
+	lookup, err := newStayLookup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a := &nightAudit{
+		NightAudit: models.NightAudit{
+			Date:        day.Format("2006-01-02"),
+			AutoCorrect: autoCorrect,
+			Findings:    []models.NightAuditFinding{},
+			Counts:      map[models.NightAuditIssue]int{},
+			StartedAt:   time.Now(),
+		},
+		stayLookup: lookup,
+		end:        day.AddDate(0, 0, 1),
+	}
+
+	// No-shows go before rooms so rooms they held are released in the same run
+	checks := []struct {
+		name string
+		run  func(context.Context) error
+	}{
+		{"overdue checkouts", a.auditOverdueCheckouts},
+		{"no-shows", a.auditNoShows},
+		{"occupied rooms", a.auditOccupiedRooms},
+		{"food passes", a.auditPassesAfterCheckout},
+	}
+	for _, check := range checks {
+		if err := check.run(ctx); err != nil {
+			return nil, fmt.Errorf("error auditing %s: %w", check.name, err)
+		}
+	}
+	if err := a.snapshot(day); err != nil {
+		return nil, fmt.Errorf("error taking snapshot: %w", err)
+	}
+	a.CompletedAt = time.Now()
+
+	var saved models.NightAudit
+	err = config.DB.Collection("night_audits").FindOneAndReplace(
+		ctx,
+		bson.M{"date": a.Date},
+		a.NightAudit,
+		options.FindOneAndReplace().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&saved)
+	if err != nil {
+		return nil, fmt.Errorf("error saving night audit: %w", err)
+	}
+	return &saved, nil
+}
+
+// RunNightAudit audits the day in the body (YYYY-MM-DD, default yesterday),
+// correcting what is safe to correct if auto_correct is set
+func RunNightAudit(c *gin.Context) {
+	var req models.RunNightAuditRequest
+	_ = c.ShouldBindJSON(&req) // The body is optional
+	day := utils.StartOfDayIST(time.Now()).AddDate(0, 0, -1)
+	if req.Date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.Date, utils.IST)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date, use YYYY-MM-DD"})
+			return
+		}
+		day = parsed
+	}
+	if !day.Before(utils.StartOfDayIST(time.Now())) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only a day that has ended can be audited"})
+		return
+	}
+
+	audit, err := ExecuteNightAudit(context.Background(), day, req.AutoCorrect)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error running night audit: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, audit)
+}
+
+// GetNightAudits lists night audits, newest first, without their findings
+func GetNightAudits(c *gin.Context) {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "30"), 10, 64)
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit value"})
+		return
+	}
+	cursor, err := config.DB.Collection("night_audits").Find(
+		context.Background(),
+		bson.M{},
+		options.Find().
+			SetSort(bson.D{{Key: "date", Value: -1}}).
+			SetLimit(limit).
+			SetProjection(bson.M{"findings": 0}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching night audits"})
+		return
+	}
+	audits := []models.NightAudit{}
+	if err := cursor.All(context.Background(), &audits); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding night audits"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"audits": audits, "count": len(audits)})
+}
+
+// GetNightAudit returns the audit of a day (YYYY-MM-DD) with its findings
+func GetNightAudit(c *gin.Context) {
+	var audit models.NightAudit
+	err := config.DB.Collection("night_audits").FindOne(context.Background(), bson.M{"date": c.Param("date")}).Decode(&audit)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No night audit for this date"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching night audit"})
+		return
+	}
+	c.JSON(http.StatusOK, audit)
+}
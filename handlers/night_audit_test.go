@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+func TestNoShowCutoff(t *testing.T) {
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, utils.IST)
+	cutoff := noShowCutoff(day.AddDate(0, 0, 1))
+	for _, tt := range []struct {
+		arrival time.Time
+		noShow  bool
+	}{
+		{day.AddDate(0, 0, -1), true},
+		{day.Add(-time.Minute), true},
+		{day, false},
+		{day.Add(20 * time.Hour), false},
+	} {
+		if got := tt.arrival.Before(cutoff); got != tt.noShow {
+			t.Errorf("arrival %s is a no-show: %v, want %v", tt.arrival, got, tt.noShow)
+		}
+	}
+}
+
+func checkIn(t *testing.T, assignmentID primitive.ObjectID) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPut, "/room-assignments/"+assignmentID.Hex()+"/check-in", nil)
+	c.Params = gin.Params{{Key: "id", Value: assignmentID.Hex()}}
+	c.Set("user_id", primitive.NewObjectID().Hex())
+	CheckInRoom(c)
+	return w.Code
+}
+
+func TestAuditNoShowsThenCheckIn(t *testing.T) {
+	useTestDB(t)
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	day := utils.StartOfDayIST(time.Now()).AddDate(0, 0, -1)
+
+	user := models.User{ID: primitive.NewObjectID(), Name: "Guest"}
+	if _, err := config.DB.Collection("users").InsertOne(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	newStay := func(arrival time.Time) models.RoomAssignment {
+		room := models.Room{ID: primitive.NewObjectID(), IsOccupied: true}
+		if _, err := config.DB.Collection("rooms").InsertOne(ctx, room); err != nil {
+			t.Fatal(err)
+		}
+		return insertStay(t, models.StatusApproved, models.RoomAssignment{
+			RoomID:       room.ID,
+			UserID:       user.ID,
+			CheckInDate:  arrival,
+			CheckOutDate: arrival.AddDate(0, 0, 3),
+		})
+	}
+	late := newStay(day.Add(-12 * time.Hour)) // Due the day before the audited day
+	sameDay := newStay(day.Add(18 * time.Hour))
+	taken := newStay(day.Add(-12 * time.Hour))
+
+	lookup, err := newStayLookup(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &nightAudit{
+		NightAudit: models.NightAudit{AutoCorrect: true, Counts: map[models.NightAuditIssue]int{}},
+		stayLookup: lookup,
+		end:        day.AddDate(0, 0, 1),
+	}
+	if err := a.auditNoShows(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.auditOccupiedRooms(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	flagged := map[primitive.ObjectID]bool{}
+	for _, finding := range a.Findings {
+		if finding.Issue == models.AuditNoShow {
+			flagged[finding.RefID] = finding.Corrected
+		}
+	}
+	if !flagged[late.ID] || !flagged[taken.ID] {
+		t.Errorf("late arrivals were not marked no-shows: %v", flagged)
+	}
+	if _, ok := flagged[sameDay.ID]; ok {
+		t.Error("an arrival due on the audited day was flagged as a no-show")
+	}
+
+	// Someone else has taken the second late arrival's room since
+	other := models.RoomAssignment{ID: primitive.NewObjectID(), RoomID: taken.RoomID, CheckInDate: time.Now(), CheckOutDate: time.Now().AddDate(0, 0, 1)}
+	if _, err := config.DB.Collection("room_assignments").InsertOne(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	if code := checkIn(t, taken.ID); code != http.StatusConflict {
+		t.Errorf("checking in a no-show whose room is taken returned %d, want %d", code, http.StatusConflict)
+	}
+
+	if code := checkIn(t, late.ID); code == http.StatusConflict || code == http.StatusBadRequest {
+		t.Fatalf("checking in a late arrival returned %d", code)
+	}
+	var assignment models.RoomAssignment
+	if err := config.DB.Collection("room_assignments").FindOne(ctx, bson.M{"_id": late.ID}).Decode(&assignment); err != nil {
+		t.Fatal(err)
+	}
+	if !assignment.CheckedIn || assignment.NoShow || assignment.NoShowAt != nil {
+		t.Errorf("after check-in the stay has checked_in %v, no_show %v", assignment.CheckedIn, assignment.NoShow)
+	}
+	var request models.RoomRequest
+	if err := config.DB.Collection("room_requests").FindOne(ctx, bson.M{"_id": late.RequestID}).Decode(&request); err != nil {
+		t.Fatal(err)
+	}
+	if request.Status != models.StatusApproved {
+		t.Errorf("after check-in the request is %s, want %s", request.Status, models.StatusApproved)
+	}
+	var room models.Room
+	if err := config.DB.Collection("rooms").FindOne(ctx, bson.M{"_id": late.RoomID}).Decode(&room); err != nil {
+		t.Fatal(err)
+	}
+	if !room.IsOccupied {
+		t.Error("the room was not marked occupied again")
+	}
+}
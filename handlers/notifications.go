@@ -0,0 +1,472 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/notify"
+	"utara_backend/utils"
+)
+
+const notifyTimeout = 30 * time.Second
+
+// notificationDate formats a date for message text
+func notificationDate(t time.Time) string {
+	return t.In(utils.IST).Format("Mon, 02 Jan 2006")
+}
+
+func notificationRecipient(user models.User) notify.Recipient {
+	language := user.Language
+	if language == "" {
+		language = notify.DefaultLanguage
+	}
+	return notify.Recipient{Name: user.Name, Phone: user.PhoneNumber, Email: user.Email, Language: language}
+}
+
+// findNotificationTemplate picks the template for an event on a channel.
+// The recipient's language beats the default language; within a language a
+// stored template for the channel beats a stored one for every channel,
+// which beats the built-in one.
+func findNotificationTemplate(event notify.Event, channel, language string) (notify.Template, bool) {
+	languages := []string{language}
+	if language != notify.DefaultLanguage {
+		languages = append(languages, notify.DefaultLanguage)
+	}
+
+	best, bestRank := notify.Template{}, -1
+	consider := func(t notify.Template, rank int) {
+		if t.Language == language {
+			rank += 10
+		}
+		if rank > bestRank {
+			best, bestRank = t, rank
+		}
+	}
+
+	cursor, err := config.DB.Collection("notification_templates").Find(context.Background(), bson.M{
+		"event":     event,
+		"is_active": true,
+		"language":  bson.M{"$in": languages},
+		"channel":   bson.M{"$in": []interface{}{channel, "", nil}},
+	})
+	if err != nil {
+		log.Printf("notify: failed to load templates for %s: %v", event, err)
+	} else {
+		var stored []models.NotificationTemplate
+		if err := cursor.All(context.Background(), &stored); err == nil {
+			for _, t := range stored {
+				rank := 1
+				if t.Channel == channel {
+					rank = 2
+				}
+				consider(storedTemplate(t), rank)
+			}
+		}
+	}
+	for _, lang := range languages {
+		if t, ok := notify.DefaultTemplate(event, lang); ok {
+			consider(t, 0)
+		}
+	}
+	return best, bestRank >= 0
+}
+
+func storedTemplate(t models.NotificationTemplate) notify.Template {
+	return notify.Template{
+		Event:    notify.Event(t.Event),
+		Channel:  t.Channel,
+		Language: t.Language,
+		Subject:  t.Subject,
+		Body:     t.Body,
+	}
+}
+
+// queueNotification renders an event's message for a user on every enabled
+// channel that can reach them and queues it in the outbox
+func queueNotification(user models.User, event notify.Event, vars map[string]string) []models.OutboxMessage {
+	recipient := notificationRecipient(user)
+	data := map[string]string{"name": user.Name}
+	for k, v := range vars {
+		data[k] = v
+	}
+
+	var queued []models.OutboxMessage
+	for _, channel := range notify.Channels {
+		to := channel.Address(recipient)
+		if to == "" || slices.Contains(user.MutedChannels, channel.Name()) {
+			continue
+		}
+		tmpl, ok := findNotificationTemplate(event, channel.Name(), recipient.Language)
+		if !ok {
+			log.Printf("notify: no template for %s", event)
+			break
+		}
+		msg, err := tmpl.Render(data)
+		if err != nil {
+			log.Printf("notify: %s template for %s failed to render: %v", event, channel.Name(), err)
+			continue
+		}
+
+		now := time.Now()
+		queued = append(queued, models.OutboxMessage{
+			UserID:        user.ID,
+			Event:         string(event),
+			Channel:       channel.Name(),
+			To:            to,
+			Language:      tmpl.Language,
+			Subject:       msg.Subject,
+			Body:          msg.Body,
+			Status:        models.OutboxPending,
+			NextAttemptAt: now,
+			History:       []models.OutboxEvent{outboxEvent(models.OutboxPending, "")},
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+	}
+	return enqueueOutbox(queued)
+}
+
+// notifyUser queues an event's message to a user. The outbox workers deliver
+// it, so handlers don't wait on messaging gateways.
+func notifyUser(userID primitive.ObjectID, event notify.Event, vars map[string]string) {
+	var user models.User
+	if err := config.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		log.Printf("notify: %s: user %s not found: %v", event, userID.Hex(), err)
+		return
+	}
+	queueNotification(user, event, vars)
+}
+
+// stayNotificationVars describes a room assignment for stay messages
+func stayNotificationVars(assignment models.RoomAssignment) map[string]string {
+	vars := map[string]string{
+		"check_in":    notificationDate(assignment.CheckInDate),
+		"check_out":   notificationDate(assignment.CheckOutDate),
+		"dining_hall": assignment.DiningHallPreference,
+	}
+	var room models.Room
+	if err := config.DB.Collection("rooms").FindOne(context.Background(), bson.M{"_id": assignment.RoomID}).Decode(&room); err == nil {
+		vars["room_number"] = room.RoomNumber
+		vars["building"] = room.Building
+	}
+	return vars
+}
+
+func notifyRoomAssigned(assignment models.RoomAssignment) {
+	notifyUser(assignment.UserID, notify.EventRoomAssigned, stayNotificationVars(assignment))
+}
+
+func notifyRequestStatus(request models.RoomRequest, event notify.Event) {
+	notifyUser(request.UserID, event, map[string]string{
+		"request_id": request.ID.Hex(),
+		"check_in":   notificationDate(request.CheckInDate),
+		"check_out":  notificationDate(request.CheckOutDate),
+	})
+}
+
+// notifyPaymentSuccess tells the payer their payment went through. A payment
+// which already has an invoice was completed, and announced, before.
+func notifyPaymentSuccess(payment models.Payment, invoice *models.Invoice) {
+	if payment.InvoiceID != nil {
+		return
+	}
+	vars := map[string]string{
+		"amount":      formatPaise(payment.Amount),
+		"description": payment.Description,
+		"payment_id":  payment.ID.Hex(),
+	}
+	if invoice != nil {
+		vars["invoice_number"] = invoice.Number
+	}
+	notifyUser(payment.UserID, notify.EventPaymentSuccess, vars)
+}
+
+// notifyRefundProcessed tells the payer about a refund, unless a credit note
+// shows it was already announced
+func notifyRefundProcessed(payment models.Payment, amount int, reason string) {
+	if payment.CreditNoteID != nil {
+		return
+	}
+	notifyUser(payment.UserID, notify.EventRefundProcessed, map[string]string{
+		"amount":     formatPaise(amount),
+		"reason":     reason,
+		"payment_id": payment.ID.Hex(),
+	})
+}
+
+// GetNotificationEvents lists the events, their variables, the enabled
+// channels and the built-in templates
+func GetNotificationEvents(c *gin.Context) {
+	type eventInfo struct {
+		Event     notify.Event      `json:"event"`
+		Variables []string          `json:"variables"`
+		Defaults  []notify.Template `json:"defaults"`
+	}
+	events := []eventInfo{}
+	for event, vars := range notify.EventVariables {
+		info := eventInfo{Event: event, Variables: append([]string{"name"}, vars...)}
+		for _, t := range notify.DefaultTemplates() {
+			if t.Event == event {
+				info.Defaults = append(info.Defaults, t)
+			}
+		}
+		events = append(events, info)
+	}
+	slices.SortFunc(events, func(a, b eventInfo) int { return strings.Compare(string(a.Event), string(b.Event)) })
+
+	channels := []string{}
+	for _, channel := range notify.Channels {
+		channels = append(channels, channel.Name())
+	}
+	c.JSON(http.StatusOK, gin.H{"events": events, "channels": channels})
+}
+
+// bindNotificationTemplate validates a template request
+func bindNotificationTemplate(c *gin.Context) (models.NotificationTemplateRequest, bool) {
+	var req models.NotificationTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return req, false
+	}
+	req.Event = strings.ToUpper(strings.TrimSpace(req.Event))
+	req.Channel = strings.ToLower(strings.TrimSpace(req.Channel))
+	req.Language = strings.ToLower(strings.TrimSpace(req.Language))
+
+	if _, ok := notify.EventVariables[notify.Event(req.Event)]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event " + req.Event})
+		return req, false
+	}
+	switch req.Channel {
+	case "", "whatsapp", "sms", "email", "console":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "channel must be whatsapp, sms, email or console"})
+		return req, false
+	}
+	tmpl := notify.Template{Subject: req.Subject, Body: req.Body}
+	if err := tmpl.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template: " + err.Error()})
+		return req, false
+	}
+	return req, true
+}
+
+// GetNotificationTemplates lists stored templates, filtered by event and language
+func GetNotificationTemplates(c *gin.Context) {
+	filter := bson.M{}
+	if event := c.Query("event"); event != "" {
+		filter["event"] = strings.ToUpper(event)
+	}
+	if language := c.Query("language"); language != "" {
+		filter["language"] = strings.ToLower(language)
+	}
+
+	cursor, err := config.DB.Collection("notification_templates").Find(
+		context.Background(),
+		filter,
+		options.Find().SetSort(bson.D{{Key: "event", Value: 1}, {Key: "language", Value: 1}, {Key: "channel", Value: 1}}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching templates"})
+		return
+	}
+	templates := []models.NotificationTemplate{}
+	if err := cursor.All(context.Background(), &templates); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding templates"})
+		return
+	}
+	c.JSON(http.StatusOK, templates)
+}
+
+// CreateNotificationTemplate stores a template for an event, language and
+// channel, of which there can be one
+func CreateNotificationTemplate(c *gin.Context) {
+	req, ok := bindNotificationTemplate(c)
+	if !ok {
+		return
+	}
+
+	count, err := config.DB.Collection("notification_templates").CountDocuments(context.Background(), bson.M{
+		"event":    req.Event,
+		"language": req.Language,
+		"channel":  req.Channel,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking templates"})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A template for this event, language and channel already exists"})
+		return
+	}
+
+	template := models.NotificationTemplate{
+		Event:     req.Event,
+		Channel:   req.Channel,
+		Language:  req.Language,
+		Subject:   req.Subject,
+		Body:      req.Body,
+		IsActive:  req.IsActive == nil || *req.IsActive,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	result, err := config.DB.Collection("notification_templates").InsertOne(context.Background(), template)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating template"})
+		return
+	}
+	template.ID = result.InsertedID.(primitive.ObjectID)
+	c.JSON(http.StatusCreated, template)
+}
+
+// UpdateNotificationTemplate replaces a stored template
+func UpdateNotificationTemplate(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+	req, ok := bindNotificationTemplate(c)
+	if !ok {
+		return
+	}
+
+	count, err := config.DB.Collection("notification_templates").CountDocuments(context.Background(), bson.M{
+		"_id":      bson.M{"$ne": id},
+		"event":    req.Event,
+		"language": req.Language,
+		"channel":  req.Channel,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking templates"})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A template for this event, language and channel already exists"})
+		return
+	}
+
+	set := bson.M{
+		"event":      req.Event,
+		"channel":    req.Channel,
+		"language":   req.Language,
+		"subject":    req.Subject,
+		"body":       req.Body,
+		"updated_at": time.Now(),
+	}
+	if req.IsActive != nil {
+		set["is_active"] = *req.IsActive
+	}
+
+	var template models.NotificationTemplate
+	err = config.DB.Collection("notification_templates").FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": id},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&template)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating template"})
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteNotificationTemplate removes a stored template, restoring the built-in one
+func DeleteNotificationTemplate(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+	result, err := config.DB.Collection("notification_templates").DeleteOne(context.Background(), bson.M{"_id": id})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting template"})
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Template deleted successfully"})
+}
+
+// SendNotification queues an event's message to a user and returns the
+// message queued for each channel
+func SendNotification(c *gin.Context) {
+	var req models.SendNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	event := notify.Event(strings.ToUpper(req.Event))
+	if _, ok := notify.EventVariables[event]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event " + req.Event})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": req.UserID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	queued := queueNotification(user, event, req.Variables)
+	if len(queued) == 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No enabled channel can reach this user"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"notifications": queued})
+}
+
+// UpdateNotificationPreferences sets the current user's message language and
+// muted channels
+func UpdateNotificationPreferences(c *gin.Context) {
+	var req models.NotificationPreferences
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, err := getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	set := bson.M{"updated_at": time.Now()}
+	if req.Language != nil {
+		set["language"] = strings.ToLower(strings.TrimSpace(*req.Language))
+	}
+	if req.MutedChannels != nil {
+		muted := []string{}
+		for _, channel := range req.MutedChannels {
+			channel = strings.ToLower(strings.TrimSpace(channel))
+			if channel != "" && !slices.Contains(muted, channel) {
+				muted = append(muted, channel)
+			}
+		}
+		set["muted_channels"] = muted
+	}
+
+	_, err = config.DB.Collection("users").UpdateOne(context.Background(), bson.M{"_id": user.ID}, bson.M{"$set": set})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification preferences updated"})
+}
@@ -0,0 +1,477 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/notify"
+)
+
+const (
+	outboxPollInterval = 5 * time.Second
+	outboxLease        = 2 * time.Minute
+	outboxBaseBackoff  = 30 * time.Second
+	outboxMaxBackoff   = 6 * time.Hour
+	outboxRateWindow   = time.Hour
+)
+
+// outboxWake nudges an idle worker when a message is queued
+var outboxWake = make(chan struct{}, 1)
+
+func envInt(name string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(name)); err == nil && v > 0 {
+		return v
+	}
+	return fallback
+}
+
+// outboxMaxAttempts is how many sends are tried before a message is dead
+// lettered, OUTBOX_MAX_ATTEMPTS (default 6)
+func outboxMaxAttempts() int { return envInt("OUTBOX_MAX_ATTEMPTS", 6) }
+
+// outboxRateLimit is how many messages a recipient gets per channel per
+// hour, OUTBOX_RATE_LIMIT (default 10). Extra messages wait, they aren't dropped.
+func outboxRateLimit() int { return envInt("OUTBOX_RATE_LIMIT", 10) }
+
+// outboxBackoff doubles the wait after each failed attempt, with jitter so
+// retries for a flaky gateway spread out
+func outboxBackoff(attempts int) time.Duration {
+	delay := outboxBaseBackoff
+	for i := 1; i < attempts && delay < outboxMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > outboxMaxBackoff {
+		delay = outboxMaxBackoff
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay/5)+1))
+}
+
+func outboxEvent(status models.OutboxStatus, errText string) models.OutboxEvent {
+	return models.OutboxEvent{Status: status, Error: errText, At: time.Now()}
+}
+
+// enqueueOutbox stores messages for delivery and wakes a worker
+func enqueueOutbox(messages []models.OutboxMessage) []models.OutboxMessage {
+	var queued []models.OutboxMessage
+	for _, msg := range messages {
+		result, err := config.DB.Collection("outbox").InsertOne(context.Background(), msg)
+		if err != nil {
+			log.Printf("outbox: failed to queue %s for user %s: %v", msg.Event, msg.UserID.Hex(), err)
+			continue
+		}
+		msg.ID = result.InsertedID.(primitive.ObjectID)
+		queued = append(queued, msg)
+	}
+	select {
+	case outboxWake <- struct{}{}:
+	default:
+	}
+	return queued
+}
+
+// StartOutbox starts OUTBOX_WORKERS (default 4) workers delivering queued
+// messages. Workers on several instances share the outbox safely.
+func StartOutbox() {
+	for i := 0; i < envInt("OUTBOX_WORKERS", 4); i++ {
+		go outboxWorker()
+	}
+}
+
+func outboxWorker() {
+	for {
+		msg, err := claimOutboxMessage()
+		if err != nil {
+			log.Printf("outbox: failed to claim message: %v", err)
+		}
+		if msg == nil {
+			select {
+			case <-outboxWake:
+			case <-time.After(outboxPollInterval):
+			}
+			continue
+		}
+		processOutboxMessage(*msg)
+	}
+}
+
+// claimOutboxMessage leases the oldest due message, or one whose worker's
+// lease ran out
+func claimOutboxMessage() (*models.OutboxMessage, error) {
+	now := time.Now()
+	var msg models.OutboxMessage
+	err := config.DB.Collection("outbox").FindOneAndUpdate(
+		context.Background(),
+		bson.M{"$or": []bson.M{
+			{"status": models.OutboxPending, "next_attempt_at": bson.M{"$lte": now}},
+			{"status": models.OutboxSending, "locked_until": bson.M{"$lt": now}},
+		}},
+		bson.M{
+			"$set": bson.M{"status": models.OutboxSending, "locked_until": now.Add(outboxLease), "updated_at": now},
+			"$inc": bson.M{"attempts": 1},
+		},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&msg)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// updateClaimed changes a message this worker still holds
+func updateClaimed(msg models.OutboxMessage, update bson.M) {
+	_, err := config.DB.Collection("outbox").UpdateOne(
+		context.Background(),
+		bson.M{"_id": msg.ID, "status": models.OutboxSending, "attempts": msg.Attempts},
+		update,
+	)
+	if err != nil {
+		log.Printf("outbox: failed to update message %s: %v", msg.ID.Hex(), err)
+	}
+}
+
+// rateLimitedUntil returns when the recipient can next be messaged on the
+// channel, or zero if they can be now
+func rateLimitedUntil(msg models.OutboxMessage) time.Time {
+	since := time.Now().Add(-outboxRateWindow)
+	cursor, err := config.DB.Collection("outbox").Find(
+		context.Background(),
+		bson.M{"channel": msg.Channel, "to": msg.To, "sent_at": bson.M{"$gte": since}},
+		options.Find().
+			SetSort(bson.D{{Key: "sent_at", Value: -1}}).
+			SetLimit(int64(outboxRateLimit())).
+			SetProjection(bson.M{"sent_at": 1}),
+	)
+	if err != nil {
+		return time.Time{}
+	}
+	var recent []models.OutboxMessage
+	if err := cursor.All(context.Background(), &recent); err != nil || len(recent) < outboxRateLimit() {
+		return time.Time{}
+	}
+	// The oldest of the last N sends has to leave the window first
+	if oldest := recent[len(recent)-1].SentAt; oldest != nil {
+		return oldest.Add(outboxRateWindow)
+	}
+	return time.Time{}
+}
+
+func processOutboxMessage(msg models.OutboxMessage) {
+	channel, ok := notify.ByName(msg.Channel)
+	if !ok {
+		failOutboxMessage(msg, "channel "+msg.Channel+" is not enabled", true)
+		return
+	}
+
+	if until := rateLimitedUntil(msg); !until.IsZero() {
+		// Waiting for the rate limit isn't a failed attempt
+		updateClaimed(msg, bson.M{
+			"$set":   bson.M{"status": models.OutboxPending, "next_attempt_at": until, "updated_at": time.Now()},
+			"$unset": bson.M{"locked_until": ""},
+			"$inc":   bson.M{"attempts": -1},
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	providerID, err := channel.Send(ctx, msg.To, notify.Message{Subject: msg.Subject, Body: msg.Body})
+	cancel()
+	if err != nil {
+		failOutboxMessage(msg, err.Error(), msg.Attempts >= outboxMaxAttempts())
+		return
+	}
+
+	now := time.Now()
+	updateClaimed(msg, bson.M{
+		"$set": bson.M{
+			"status":              models.OutboxSent,
+			"provider_message_id": providerID,
+			"sent_at":             now,
+			"updated_at":          now,
+		},
+		"$unset": bson.M{"locked_until": "", "last_error": ""},
+		"$push":  bson.M{"history": outboxEvent(models.OutboxSent, "")},
+	})
+}
+
+// failOutboxMessage schedules a retry with backoff, or dead letters the
+// message when it is out of attempts
+func failOutboxMessage(msg models.OutboxMessage, errText string, dead bool) {
+	log.Printf("outbox: %s to user %s over %s failed (attempt %d): %s", msg.Event, msg.UserID.Hex(), msg.Channel, msg.Attempts, errText)
+
+	status := models.OutboxPending
+	set := bson.M{"last_error": errText, "updated_at": time.Now()}
+	if dead {
+		status = models.OutboxDead
+	} else {
+		set["next_attempt_at"] = time.Now().Add(outboxBackoff(msg.Attempts))
+	}
+	set["status"] = status
+	updateClaimed(msg, bson.M{
+		"$set":   set,
+		"$unset": bson.M{"locked_until": ""},
+		"$push":  bson.M{"history": outboxEvent(status, errText)},
+	})
+}
+
+// outboxFilter builds the outbox filter from the list query params
+func outboxFilter(c *gin.Context) (bson.M, bool) {
+	filter := bson.M{}
+	if userID := c.Query("user_id"); userID != "" {
+		id, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return nil, false
+		}
+		filter["user_id"] = id
+	}
+	for _, param := range []string{"event", "channel", "status"} {
+		if value := c.Query(param); value != "" {
+			filter[param] = value
+		}
+	}
+	return filter, true
+}
+
+// GetNotifications lists outbox messages, newest first, filtered by user_id,
+// event, channel and status, paginated with page and limit (default 50)
+func GetNotifications(c *gin.Context) {
+	filter, ok := outboxFilter(c)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	cursor, err := config.DB.Collection("outbox").Find(
+		context.Background(),
+		filter,
+		options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}}).
+			SetSkip(int64((page-1)*limit)).
+			SetLimit(int64(limit)),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching notifications"})
+		return
+	}
+	messages := []models.OutboxMessage{}
+	if err := cursor.All(context.Background(), &messages); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding notifications"})
+		return
+	}
+	total, _ := config.DB.Collection("outbox").CountDocuments(context.Background(), filter)
+	c.JSON(http.StatusOK, gin.H{"total": total, "data": messages})
+}
+
+// GetNotification returns one outbox message with its delivery history
+func GetNotification(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+	var msg models.OutboxMessage
+	err = config.DB.Collection("outbox").FindOne(context.Background(), bson.M{"_id": id}).Decode(&msg)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching notification"})
+		return
+	}
+	c.JSON(http.StatusOK, msg)
+}
+
+// GetNotificationStats counts outbox messages by channel and status
+func GetNotificationStats(c *gin.Context) {
+	cursor, err := config.DB.Collection("outbox").Aggregate(context.Background(), []bson.M{
+		{"$group": bson.M{
+			"_id":   bson.M{"channel": "$channel", "status": "$status"},
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$project": bson.M{"_id": 0, "channel": "$_id.channel", "status": "$_id.status", "count": 1}},
+		{"$sort": bson.D{{Key: "channel", Value: 1}, {Key: "status", Value: 1}}},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error counting notifications"})
+		return
+	}
+	stats := []models.OutboxStats{}
+	if err := cursor.All(context.Background(), &stats); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding notification stats"})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// ResendNotification queues a dead or failed message again with a fresh set
+// of attempts
+func ResendNotification(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+	user, err := getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	event := outboxEvent(models.OutboxPending, "")
+	event.By = &user.ID
+	var msg models.OutboxMessage
+	err = config.DB.Collection("outbox").FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": id, "status": bson.M{"$in": []models.OutboxStatus{models.OutboxDead, models.OutboxFailed}}},
+		bson.M{
+			"$set": bson.M{
+				"status":          models.OutboxPending,
+				"attempts":        0,
+				"next_attempt_at": time.Now(),
+				"updated_at":      time.Now(),
+			},
+			"$push": bson.M{"history": event},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&msg)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only dead or failed notifications can be resent"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error resending notification"})
+		return
+	}
+
+	select {
+	case outboxWake <- struct{}{}:
+	default:
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification queued for resend", "notification": msg})
+}
+
+// deliveryStatuses maps provider status names to outbox statuses
+var deliveryStatuses = map[string]models.OutboxStatus{
+	"sent":        models.OutboxSent,
+	"delivered":   models.OutboxDelivered,
+	"read":        models.OutboxRead,
+	"seen":        models.OutboxRead,
+	"played":      models.OutboxRead,
+	"failed":      models.OutboxFailed,
+	"undelivered": models.OutboxFailed,
+	"rejected":    models.OutboxFailed,
+	"error":       models.OutboxFailed,
+}
+
+// deliveryPredecessors are the statuses a callback may move a message from,
+// so late or repeated callbacks don't move it backwards
+var deliveryPredecessors = map[models.OutboxStatus][]models.OutboxStatus{
+	models.OutboxDelivered: {models.OutboxSent},
+	models.OutboxRead:      {models.OutboxSent, models.OutboxDelivered},
+	models.OutboxFailed:    {models.OutboxSent},
+}
+
+type deliveryStatus struct {
+	ID        string `json:"id"`
+	MessageID string `json:"message_id"`
+	Status    string `json:"status"`
+	Error     any    `json:"error"`
+}
+
+// HandleNotificationCallback records delivery status reports from a
+// channel's provider. It accepts whapi's {"statuses": [...]} body or a single
+// {"id" or "message_id", "status"} object, and must be called with
+// ?token=NOTIFY_CALLBACK_SECRET.
+func HandleNotificationCallback(c *gin.Context) {
+	secret := os.Getenv("NOTIFY_CALLBACK_SECRET")
+	if secret == "" || subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(secret)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid callback token"})
+		return
+	}
+
+	var body struct {
+		Statuses []deliveryStatus `json:"statuses"`
+		deliveryStatus
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid callback payload"})
+		return
+	}
+	statuses := body.Statuses
+	if len(statuses) == 0 {
+		statuses = []deliveryStatus{body.deliveryStatus}
+	}
+
+	updated := 0
+	for _, s := range statuses {
+		id := s.ID
+		if id == "" {
+			id = s.MessageID
+		}
+		status, ok := deliveryStatuses[strings.ToLower(s.Status)]
+		if id == "" || !ok || status == models.OutboxSent {
+			continue
+		}
+
+		now := time.Now()
+		set := bson.M{"status": status, "updated_at": now}
+		errText := ""
+		if status == models.OutboxFailed {
+			if s.Error != nil {
+				detail, _ := json.Marshal(s.Error)
+				errText = string(detail)
+			} else {
+				errText = "provider reported " + s.Status
+			}
+			set["last_error"] = errText
+		} else {
+			set["delivered_at"] = now
+		}
+
+		result, err := config.DB.Collection("outbox").UpdateOne(
+			context.Background(),
+			bson.M{
+				"channel":             c.Param("channel"),
+				"provider_message_id": id,
+				"status":              bson.M{"$in": deliveryPredecessors[status]},
+			},
+			bson.M{"$set": set, "$push": bson.M{"history": outboxEvent(status, errText)}},
+		)
+		if err != nil {
+			log.Printf("outbox: failed to record %s for %s: %v", status, id, err)
+			continue
+		}
+		updated += int(result.ModifiedCount)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "received", "updated": updated})
+}
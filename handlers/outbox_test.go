@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/notify"
+)
+
+func TestOutboxBackoff(t *testing.T) {
+	previous := time.Duration(0)
+	for attempts := 1; attempts <= 20; attempts++ {
+		delay := outboxBackoff(attempts)
+		base := outboxBaseBackoff << (attempts - 1)
+		if attempts > 15 || base > outboxMaxBackoff {
+			base = outboxMaxBackoff
+		}
+		if delay < base || delay > base+base/5 {
+			t.Errorf("outboxBackoff(%d) = %s, want between %s and %s", attempts, delay, base, base+base/5)
+		}
+		if base < outboxMaxBackoff && delay <= previous/2 {
+			t.Errorf("outboxBackoff(%d) = %s did not grow from %s", attempts, delay, previous)
+		}
+		previous = delay
+	}
+}
+
+// failingChannel is a notification channel whose gateway is down
+type failingChannel struct{}
+
+func (failingChannel) Name() string                      { return "failing" }
+func (failingChannel) Address(r notify.Recipient) string { return r.Phone }
+func (failingChannel) Send(ctx context.Context, to string, msg notify.Message) (string, error) {
+	return "", errors.New("gateway unavailable")
+}
+
+func useChannels(t *testing.T, channels ...notify.Channel) {
+	t.Helper()
+	previous := notify.Channels
+	notify.Channels = channels
+	t.Cleanup(func() { notify.Channels = previous })
+}
+
+func insertOutbox(t *testing.T, msg models.OutboxMessage) models.OutboxMessage {
+	t.Helper()
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now()
+	}
+	enqueued := enqueueOutbox([]models.OutboxMessage{msg})
+	if len(enqueued) != 1 {
+		t.Fatal("message was not queued")
+	}
+	return enqueued[0]
+}
+
+func findOutbox(t *testing.T, msg models.OutboxMessage) models.OutboxMessage {
+	t.Helper()
+	var stored models.OutboxMessage
+	if err := config.DB.Collection("outbox").FindOne(context.Background(), bson.M{"_id": msg.ID}).Decode(&stored); err != nil {
+		t.Fatal(err)
+	}
+	return stored
+}
+
+func TestClaimOutboxMessage(t *testing.T) {
+	useTestDB(t)
+	now := time.Now()
+	expired := now.Add(-time.Minute)
+	held := now.Add(time.Minute)
+
+	later := insertOutbox(t, models.OutboxMessage{Channel: "console", Status: models.OutboxPending, NextAttemptAt: now.Add(time.Hour)})
+	due := insertOutbox(t, models.OutboxMessage{Channel: "console", Status: models.OutboxPending, NextAttemptAt: now.Add(-time.Minute)})
+	overdue := insertOutbox(t, models.OutboxMessage{Channel: "console", Status: models.OutboxPending, NextAttemptAt: now.Add(-time.Hour)})
+	abandoned := insertOutbox(t, models.OutboxMessage{Channel: "console", Status: models.OutboxSending, Attempts: 1, NextAttemptAt: now.Add(-time.Minute), LockedUntil: &expired})
+	leased := insertOutbox(t, models.OutboxMessage{Channel: "console", Status: models.OutboxSending, Attempts: 1, NextAttemptAt: now.Add(-2 * time.Hour), LockedUntil: &held})
+	insertOutbox(t, models.OutboxMessage{Channel: "console", Status: models.OutboxSent, NextAttemptAt: now.Add(-3 * time.Hour)})
+
+	// Oldest due first, and a lease that ran out is taken over
+	want := []struct {
+		msg      models.OutboxMessage
+		attempts int
+	}{{overdue, 1}, {due, 1}, {abandoned, 2}}
+	for _, w := range want {
+		claimed, err := claimOutboxMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claimed == nil {
+			t.Fatalf("claimed nothing, want message %s", w.msg.ID.Hex())
+		}
+		if claimed.ID != w.msg.ID {
+			t.Fatalf("claimed %s, want %s", claimed.ID.Hex(), w.msg.ID.Hex())
+		}
+		if claimed.Status != models.OutboxSending || claimed.Attempts != w.attempts {
+			t.Errorf("claimed message is %s after %d attempts, want SENDING after %d", claimed.Status, claimed.Attempts, w.attempts)
+		}
+		if claimed.LockedUntil == nil || !claimed.LockedUntil.After(now) {
+			t.Errorf("claimed message has lease %v, want one in the future", claimed.LockedUntil)
+		}
+	}
+
+	// Not yet due, still leased and sent messages are left alone
+	claimed, err := claimOutboxMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed != nil {
+		t.Errorf("claimed %s, want nothing", claimed.ID.Hex())
+	}
+	for _, msg := range []models.OutboxMessage{later, leased} {
+		if stored := findOutbox(t, msg); stored.Attempts != msg.Attempts {
+			t.Errorf("message %s has %d attempts, want %d", msg.ID.Hex(), stored.Attempts, msg.Attempts)
+		}
+	}
+}
+
+func TestOutboxRetry(t *testing.T) {
+	useTestDB(t)
+	useChannels(t, failingChannel{})
+	t.Setenv("OUTBOX_MAX_ATTEMPTS", "2")
+
+	msg := insertOutbox(t, models.OutboxMessage{Channel: "failing", To: "9999999999", Status: models.OutboxPending, NextAttemptAt: time.Now()})
+
+	// The first failure schedules a retry with backoff
+	claimed, err := claimOutboxMessage()
+	if err != nil || claimed == nil {
+		t.Fatalf("claim: %v, %v", claimed, err)
+	}
+	processOutboxMessage(*claimed)
+	stored := findOutbox(t, msg)
+	if stored.Status != models.OutboxPending || stored.LastError != "gateway unavailable" {
+		t.Fatalf("after one failure the message is %s (%q), want PENDING", stored.Status, stored.LastError)
+	}
+	if !stored.NextAttemptAt.After(time.Now().Add(outboxBaseBackoff - time.Second)) {
+		t.Errorf("retry is at %s, want at least %s from now", stored.NextAttemptAt, outboxBaseBackoff)
+	}
+	if stored.LockedUntil != nil {
+		t.Error("lease was not released")
+	}
+	if again, _ := claimOutboxMessage(); again != nil {
+		t.Fatal("message was claimed again before its retry was due")
+	}
+
+	// A worker whose lease was taken over can't overwrite the new attempt
+	_, err = config.DB.Collection("outbox").UpdateOne(context.Background(), bson.M{"_id": msg.ID}, bson.M{"$set": bson.M{"next_attempt_at": time.Now()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimed, err = claimOutboxMessage()
+	if err != nil || claimed == nil {
+		t.Fatalf("claim: %v, %v", claimed, err)
+	}
+	stale := *claimed
+	stale.Attempts--
+	failOutboxMessage(stale, "stale worker", false)
+	if stored := findOutbox(t, msg); stored.Status != models.OutboxSending || stored.LastError == "stale worker" {
+		t.Errorf("a stale worker changed the message to %s (%q)", stored.Status, stored.LastError)
+	}
+
+	// Out of attempts, it is dead lettered
+	processOutboxMessage(*claimed)
+	stored = findOutbox(t, msg)
+	if stored.Status != models.OutboxDead || stored.Attempts != 2 {
+		t.Errorf("after %d attempts the message is %s, want DEAD after 2", stored.Attempts, stored.Status)
+	}
+	if n := len(stored.History); n != 2 || stored.History[0].Status != models.OutboxPending || stored.History[1].Status != models.OutboxDead {
+		t.Errorf("history is %+v, want a retry then dead", stored.History)
+	}
+}
+
+func TestOutboxSendsAfterRetry(t *testing.T) {
+	useTestDB(t)
+	useChannels(t, notify.Console{})
+
+	msg := insertOutbox(t, models.OutboxMessage{Channel: "console", To: "guest@example.com", Status: models.OutboxPending, Attempts: 1, NextAttemptAt: time.Now(), LastError: "gateway unavailable"})
+	claimed, err := claimOutboxMessage()
+	if err != nil || claimed == nil {
+		t.Fatalf("claim: %v, %v", claimed, err)
+	}
+	processOutboxMessage(*claimed)
+
+	stored := findOutbox(t, msg)
+	if stored.Status != models.OutboxSent || stored.SentAt == nil || stored.LastError != "" {
+		t.Errorf("message is %s (%q), want SENT with the error cleared", stored.Status, stored.LastError)
+	}
+}
@@ -9,8 +9,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -48,6 +51,37 @@ func CreatePayment(c *gin.Context) {
 		return
 	}
 
+	// A quote fixes the amount, so the client can't change what is charged
+	var quoteID *primitive.ObjectID
+	if req.QuoteID != nil {
+		quote, err := loadPayableQuote(*req.QuoteID, userObjID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		quoteID = &quote.ID
+		req.Amount = quote.Total
+		req.Currency = quote.Currency
+		req.Type = models.PaymentTypeRoomBooking
+		req.Description = fmt.Sprintf("Room booking, %d nights", quote.Nights)
+		if quote.RequestID != nil {
+			requestID := quote.RequestID.Hex()
+			req.RequestID = &requestID
+		}
+		if req.Notes == nil {
+			req.Notes = map[string]string{}
+		}
+		req.Notes["quote_id"] = quote.ID.Hex()
+	} else if req.Amount <= 0 || req.Currency == "" || req.Type == "" || req.Description == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount, currency, type and description are required"})
+		return
+	}
+	req.Type = models.PaymentType(strings.ToUpper(string(req.Type)))
+	if !req.Type.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be ROOM_BOOKING, FOOD_PASS, DEPOSIT or OTHER"})
+		return
+	}
+
 	razorpayOrder, err := createRazorpayOrder(req.Amount, req.Currency, req.Description, req.Notes)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create payment order: " + err.Error()})
@@ -64,6 +98,7 @@ func CreatePayment(c *gin.Context) {
 		RazorpayOrderID: razorpayOrder.ID,
 		Description:     req.Description,
 		Notes:           req.Notes,
+		QuoteID:         quoteID,
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
 	}
@@ -192,9 +227,86 @@ func VerifyPayment(c *gin.Context) {
 		return
 	}
 
+	completePayment(payment)
+
 	c.JSON(http.StatusOK, payment)
 }
 
+// completePayment runs the bookkeeping for a payment that has just been
+// paid, online or at the desk. It is safe to call more than once.
+func completePayment(payment models.Payment) {
+	activateGuestFoodPasses(payment)
+	markQuotePaid(payment)
+	applyDepositPayment(payment)
+	invoice, err := issueInvoice(payment)
+	if err != nil {
+		log.Printf("invoice: failed to issue for payment %s: %v", payment.ID.Hex(), err)
+	}
+	notifyPaymentSuccess(payment, invoice)
+}
+
+// verifyWebhookSignature checks Razorpay's HMAC_SHA256(body, webhook secret).
+// Without a secret configured no webhook is trusted.
+func verifyWebhookSignature(body []byte, signature, secret string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expectedSignature := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(signature), []byte(expectedSignature))
+}
+
+// webhookEntity returns payload[key].entity, or nil if the payload doesn't
+// have that shape
+func webhookEntity(payload map[string]interface{}, key string) map[string]interface{} {
+	wrapper, _ := payload[key].(map[string]interface{})
+	entity, _ := wrapper["entity"].(map[string]interface{})
+	return entity
+}
+
+// completePaymentForOrder completes a payment confirmed by webhook
+func completePaymentForOrder(orderID string) {
+	var payment models.Payment
+	if err := config.DB.Collection("payments").FindOne(context.Background(), bson.M{"razorpay_order_id": orderID}).Decode(&payment); err != nil {
+		return
+	}
+	completePayment(payment)
+}
+
+// applyDepositPayment sets deposit_paid on the request's room assignment to
+// the total of its paid deposits
+func applyDepositPayment(payment models.Payment) {
+	if payment.Type != models.PaymentTypeDeposit || payment.RequestID == nil {
+		return
+	}
+
+	cursor, err := config.DB.Collection("payments").Aggregate(context.Background(), []bson.M{
+		{"$match": bson.M{
+			"request_id": *payment.RequestID,
+			"type":       models.PaymentTypeDeposit,
+			"status":     models.PaymentStatusPaid,
+		}},
+		{"$group": bson.M{"_id": nil, "total": bson.M{"$sum": "$amount"}}},
+	})
+	if err != nil {
+		log.Printf("deposit: failed to total deposits for request %s: %v", payment.RequestID.Hex(), err)
+		return
+	}
+	var totals []struct {
+		Total int `bson:"total"`
+	}
+	if err := cursor.All(context.Background(), &totals); err != nil || len(totals) == 0 {
+		return
+	}
+
+	config.DB.Collection("room_assignments").UpdateMany(
+		context.Background(),
+		bson.M{"request_id": *payment.RequestID},
+		bson.M{"$set": bson.M{"deposit_paid": totals[0].Total, "payment_id": payment.ID}},
+	)
+}
+
 // verifyRazorpaySignature verifies the payment signature
 func verifyRazorpaySignature(orderID, paymentID, signature string) bool {
 	_, keySecret := getRazorpayCredentials()
@@ -295,20 +407,27 @@ func GetPaymentByID(c *gin.Context) {
 	c.JSON(http.StatusOK, payment)
 }
 
-// GetAllPayments returns all payments (admin only)
-func GetAllPayments(c *gin.Context) {
+// paymentListFilter builds the payment filter from the list query params
+func paymentListFilter(query url.Values) bson.M {
 	filter := bson.M{}
 
 	// Filter by status
-	if status := c.Query("status"); status != "" {
+	if status := query.Get("status"); status != "" {
 		filter["status"] = status
 	}
 
 	// Filter by type
-	if paymentType := c.Query("type"); paymentType != "" {
+	if paymentType := query.Get("type"); paymentType != "" {
 		filter["type"] = paymentType
 	}
 
+	return filter
+}
+
+// GetAllPayments returns all payments (admin only)
+func GetAllPayments(c *gin.Context) {
+	filter := paymentListFilter(c.Request.URL.Query())
+
 	// Pagination
 	skip := 0
 	limit := 100
@@ -395,27 +514,35 @@ func UpdatePaymentStatus(c *gin.Context) {
 
 // HandleWebhook handles Razorpay webhook events
 func HandleWebhook(c *gin.Context) {
-	// Verify webhook signature (optional but recommended for production)
-	// You should implement webhook signature verification here
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook payload"})
+		return
+	}
+	if !verifyWebhookSignature(body, c.GetHeader("X-Razorpay-Signature"), os.Getenv("RAZORPAY_WEBHOOK_SECRET")) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid webhook signature"})
+		return
+	}
 
 	var webhookData map[string]interface{}
-	if err := c.ShouldBindJSON(&webhookData); err != nil {
+	if err := json.Unmarshal(body, &webhookData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook payload"})
 		return
 	}
 
-	// Log webhook for debugging
-	fmt.Printf("Razorpay Webhook received: %v\n", webhookData)
-
-	event := webhookData["event"].(string)
-	payload := webhookData["payload"].(map[string]interface{})
+	event, _ := webhookData["event"].(string)
+	payload, _ := webhookData["payload"].(map[string]interface{})
 
 	switch event {
 	case "payment.captured":
-		paymentEntity := payload["payment"].(map[string]interface{})["entity"].(map[string]interface{})
-		orderID := paymentEntity["order_id"].(string)
-		paymentID := paymentEntity["id"].(string)
-		
+		paymentEntity := webhookEntity(payload, "payment")
+		orderID, _ := paymentEntity["order_id"].(string)
+		paymentID, _ := paymentEntity["id"].(string)
+		if orderID == "" || paymentID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook payload"})
+			return
+		}
+
 		// Update payment record
 		filter := bson.M{"razorpay_order_id": orderID}
 		update := bson.M{
@@ -427,15 +554,17 @@ func HandleWebhook(c *gin.Context) {
 			},
 		}
 		config.DB.Collection("payments").UpdateOne(context.Background(), filter, update)
+		completePaymentForOrder(orderID)
 
 	case "payment.failed":
-		paymentEntity := payload["payment"].(map[string]interface{})["entity"].(map[string]interface{})
-		orderID := paymentEntity["order_id"].(string)
-		failureReason := ""
-		if reason, ok := paymentEntity["error_description"].(string); ok {
-			failureReason = reason
+		paymentEntity := webhookEntity(payload, "payment")
+		orderID, _ := paymentEntity["order_id"].(string)
+		if orderID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook payload"})
+			return
 		}
-		
+		failureReason, _ := paymentEntity["error_description"].(string)
+
 		filter := bson.M{"razorpay_order_id": orderID}
 		update := bson.M{
 			"$set": bson.M{
@@ -445,13 +574,19 @@ func HandleWebhook(c *gin.Context) {
 			},
 		}
 		config.DB.Collection("payments").UpdateOne(context.Background(), filter, update)
+		releaseUnpaidGuestPasses(orderID)
 
 	case "refund.processed":
-		refundEntity := payload["refund"].(map[string]interface{})["entity"].(map[string]interface{})
-		paymentID := refundEntity["payment_id"].(string)
-		refundID := refundEntity["id"].(string)
-		amount := int(refundEntity["amount"].(float64))
-		
+		refundEntity := webhookEntity(payload, "refund")
+		paymentID, _ := refundEntity["payment_id"].(string)
+		refundID, _ := refundEntity["id"].(string)
+		amountValue, ok := refundEntity["amount"].(float64)
+		if paymentID == "" || refundID == "" || !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook payload"})
+			return
+		}
+		amount := int(amountValue)
+
 		filter := bson.M{"razorpay_payment_id": paymentID}
 		update := bson.M{
 			"$set": bson.M{
@@ -460,8 +595,11 @@ func HandleWebhook(c *gin.Context) {
 				"refunded_amount": amount,
 				"updated_at":      time.Now(),
 			},
+			// Keep the time ProcessRefund recorded if it did
+			"$min": bson.M{"refunded_at": time.Now()},
 		}
 		config.DB.Collection("payments").UpdateOne(context.Background(), filter, update)
+		issueCreditNoteForRefund(paymentID, refundID, amount)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"status": "received"})
@@ -495,16 +633,26 @@ func ProcessRefund(c *gin.Context) {
 		return
 	}
 
-	if payment.RazorpayPaymentID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No Razorpay payment ID found"})
-		return
-	}
-
 	// Determine refund amount
 	refundAmount := req.Amount
 	if refundAmount == 0 {
 		refundAmount = payment.Amount // Full refund
 	}
+	if refundAmount < 0 || refundAmount > payment.Amount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Refund amount must be between 0 and the amount paid"})
+		return
+	}
+
+	// Desk payments are refunded at the desk by whoever processes the refund
+	if payment.Method.IsOffline() {
+		refundOfflinePayment(c, payment, refundAmount, req.Reason)
+		return
+	}
+
+	if payment.RazorpayPaymentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No Razorpay payment ID found"})
+		return
+	}
 
 	// Create refund in Razorpay
 	refundResult, err := createRazorpayRefund(payment.RazorpayPaymentID, refundAmount, req.Reason)
@@ -520,6 +668,7 @@ func ProcessRefund(c *gin.Context) {
 			"status":          models.PaymentStatusRefunded,
 			"refund_id":       refundResult["id"],
 			"refunded_amount": refundAmount,
+			"refunded_at":     time.Now(),
 			"updated_at":      time.Now(),
 		},
 	}
@@ -530,6 +679,13 @@ func ProcessRefund(c *gin.Context) {
 		return
 	}
 
+	refundID, _ := refundResult["id"].(string)
+	payment.Status = models.PaymentStatusRefunded
+	notifyRefundProcessed(payment, refundAmount, req.Reason)
+	if _, err := issueCreditNote(payment, refundID, refundAmount, req.Reason); err != nil {
+		log.Printf("invoice: failed to issue credit note for payment %s: %v", payment.ID.Hex(), err)
+	}
+
 	response := models.RefundResponse{
 		RefundID:   refundResult["id"].(string),
 		PaymentID:  req.PaymentID,
@@ -538,9 +694,11 @@ func ProcessRefund(c *gin.Context) {
 		ReceiptURL: "",
 	}
 
-	// Generate receipt URL if needed
-	if receiptURL, ok := refundResult["receipt_url"].(string); ok {
-		response.ReceiptURL = receiptURL
+	// Razorpay's receipt if it sent one, otherwise ours with the credit note
+	if url, ok := refundResult["receipt_url"].(string); ok {
+		response.ReceiptURL = url
+	} else {
+		response.ReceiptURL = receiptURL(payment.ID)
 	}
 
 	c.JSON(http.StatusOK, response)
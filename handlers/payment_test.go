@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func signWebhook(body, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"event":"payment.captured"}`)
+	signature := signWebhook(string(body), "secret")
+
+	if !verifyWebhookSignature(body, signature, "secret") {
+		t.Error("a correctly signed body was rejected")
+	}
+	if verifyWebhookSignature(body, signature, "other") {
+		t.Error("a body signed with another secret was accepted")
+	}
+	if verifyWebhookSignature([]byte(`{"event":"refund.processed"}`), signature, "secret") {
+		t.Error("a changed body was accepted")
+	}
+	if verifyWebhookSignature(body, "", "") {
+		t.Error("an unsigned body was accepted without a secret")
+	}
+}
+
+func TestHandleWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("RAZORPAY_WEBHOOK_SECRET", "secret")
+
+	tests := []struct {
+		name      string
+		body      string
+		signature string
+		want      int
+	}{
+		{"unsigned", `{"event":"payment.captured"}`, "", http.StatusUnauthorized},
+		{"wrong signature", `{"event":"payment.captured"}`, signWebhook(`{"event":"payment.captured"}`, "other"), http.StatusUnauthorized},
+		{"not JSON", `payment`, signWebhook(`payment`, "secret"), http.StatusBadRequest},
+		{"captured without a payload", `{"event":"payment.captured"}`, signWebhook(`{"event":"payment.captured"}`, "secret"), http.StatusBadRequest},
+		{"refund with a string amount", `{"event":"refund.processed","payload":{"refund":{"entity":{"id":"rfnd_1","payment_id":"pay_1","amount":"100"}}}}`,
+			signWebhook(`{"event":"refund.processed","payload":{"refund":{"entity":{"id":"rfnd_1","payment_id":"pay_1","amount":"100"}}}}`, "secret"), http.StatusBadRequest},
+		{"unhandled event", `{"event":"order.paid"}`, signWebhook(`{"event":"order.paid"}`, "secret"), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tt.body))
+			if tt.signature != "" {
+				c.Request.Header.Set("X-Razorpay-Signature", tt.signature)
+			}
+			HandleWebhook(c)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
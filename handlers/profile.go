@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"net/url"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
@@ -44,9 +45,21 @@ func GetProfile(c *gin.Context) {
 	})
 }
 
+// userListFilter builds the user filter from the list query params
+func userListFilter(query url.Values) bson.M {
+	filter := bson.M{}
+	if role := query.Get("role"); role != "" {
+		filter["role"] = role
+	}
+	if userType := query.Get("user_type"); userType != "" {
+		filter["user_type"] = userType
+	}
+	return filter
+}
+
 func GetAllUsers(c *gin.Context) {
 	// Find all users in database
-	cursor, err := config.DB.Collection("users").Find(context.Background(), bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	cursor, err := config.DB.Collection("users").Find(context.Background(), userListFilter(c.Request.URL.Query()), options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
 		return
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/notify"
+	"utara_backend/utils"
+)
+
+// findActiveStays returns the assignments matching filter that belong to an
+// approved request and weren't released as a no-show
+func findActiveStays(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]models.RoomAssignment, error) {
+	filter["no_show"] = bson.M{"$ne": true}
+	cursor, err := config.DB.Collection("room_assignments").Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching room assignments: %w", err)
+	}
+	var assignments []models.RoomAssignment
+	if err := cursor.All(ctx, &assignments); err != nil {
+		return nil, fmt.Errorf("error decoding room assignments: %w", err)
+	}
+	if len(assignments) == 0 {
+		return nil, nil
+	}
+
+	requestIDs := make([]primitive.ObjectID, 0, len(assignments))
+	for _, assignment := range assignments {
+		requestIDs = append(requestIDs, assignment.RequestID)
+	}
+	cursor, err = config.DB.Collection("room_requests").Find(ctx, bson.M{
+		"_id":    bson.M{"$in": requestIDs},
+		"status": models.StatusApproved,
+	}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("error fetching room requests: %w", err)
+	}
+	var requests []models.RoomRequest
+	if err := cursor.All(ctx, &requests); err != nil {
+		return nil, fmt.Errorf("error decoding room requests: %w", err)
+	}
+	approved := make(map[primitive.ObjectID]bool, len(requests))
+	for _, request := range requests {
+		approved[request.ID] = true
+	}
+
+	active := assignments[:0]
+	for _, assignment := range assignments {
+		if approved[assignment.RequestID] {
+			active = append(active, assignment)
+		}
+	}
+	return active, nil
+}
+
+// sendStayReminders sends an event's reminder for every assignment matching
+// filter. Each assignment is claimed through claimField first so a rerun of
+// the job doesn't remind a guest twice.
+func sendStayReminders(ctx context.Context, filter bson.M, claimField string, event notify.Event) (int, error) {
+	filter[claimField] = bson.M{"$exists": false}
+	assignments, err := findActiveStays(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, assignment := range assignments {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		result, err := config.DB.Collection("room_assignments").UpdateOne(
+			ctx,
+			bson.M{"_id": assignment.ID, claimField: bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{claimField: time.Now()}},
+		)
+		if err != nil {
+			log.Printf("reminders: failed to claim assignment %s: %v", assignment.ID.Hex(), err)
+			continue
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+		notifyUser(assignment.UserID, event, stayNotificationVars(assignment))
+		sent++
+	}
+	return sent, nil
+}
+
+// ExecuteCheckInReminders reminds guests arriving tomorrow (IST) who haven't
+// checked in
+func ExecuteCheckInReminders(ctx context.Context) (int, error) {
+	tomorrow := utils.StartOfDayIST(time.Now()).AddDate(0, 0, 1)
+	return sendStayReminders(ctx, bson.M{
+		"check_in_date": bson.M{"$gte": tomorrow, "$lt": tomorrow.AddDate(0, 0, 1)},
+		"checked_in":    false,
+	}, "check_in_reminder_at", notify.EventCheckInReminder)
+}
+
+// ExecuteCheckOutReminders reminds checked in guests leaving today (IST)
+func ExecuteCheckOutReminders(ctx context.Context) (int, error) {
+	today := utils.StartOfDayIST(time.Now())
+	return sendStayReminders(ctx, bson.M{
+		"check_out_date": bson.M{"$gte": today, "$lt": today.AddDate(0, 0, 1)},
+		"checked_in":     true,
+		"checked_out":    false,
+	}, "check_out_reminder_at", notify.EventCheckOutReminder)
+}
+
+// ExecuteOverdueCheckouts sends staff a list of checked in guests whose
+// checkout date (IST) is before today but who haven't checked out. It
+// returns the number of overdue rooms.
+func ExecuteOverdueCheckouts(ctx context.Context) (int, error) {
+	assignments, err := findActiveStays(
+		ctx,
+		bson.M{
+			"check_out_date": bson.M{"$lt": utils.StartOfDayIST(time.Now())},
+			"checked_in":     true,
+			"checked_out":    false,
+		},
+		options.Find().SetSort(bson.D{{Key: "check_out_date", Value: 1}}),
+	)
+	if err != nil {
+		return 0, err
+	}
+	if len(assignments) == 0 {
+		return 0, nil
+	}
+
+	lines := make([]string, 0, len(assignments))
+	for _, assignment := range assignments {
+		vars := stayNotificationVars(assignment)
+		guest := ""
+		var user models.User
+		if err := config.DB.Collection("users").FindOne(ctx, bson.M{"_id": assignment.UserID}).Decode(&user); err == nil {
+			guest = user.Name
+			if user.PhoneNumber != "" {
+				guest += " (" + user.PhoneNumber + ")"
+			}
+		}
+		lines = append(lines, fmt.Sprintf("- Room %s, %s: %s, due %s", vars["room_number"], vars["building"], guest, vars["check_out"]))
+	}
+
+	cursor, err := config.DB.Collection("users").Find(ctx, bson.M{
+		"role": bson.M{"$in": []models.UserRole{models.RoleStaff, models.RoleSuperAdmin}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error fetching staff: %w", err)
+	}
+	var staff []models.User
+	if err := cursor.All(ctx, &staff); err != nil {
+		return 0, fmt.Errorf("error decoding staff: %w", err)
+	}
+
+	vars := map[string]string{
+		"count": strconv.Itoa(len(assignments)),
+		"rooms": strings.Join(lines, "\n"),
+	}
+	for _, user := range staff {
+		queueNotification(user, notify.EventCheckOutOverdue, vars)
+	}
+	return len(assignments), nil
+}
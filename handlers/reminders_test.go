@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+func insertStay(t *testing.T, status models.RequestStatus, assignment models.RoomAssignment) models.RoomAssignment {
+	t.Helper()
+	ctx := context.Background()
+	request := models.RoomRequest{ID: primitive.NewObjectID(), Status: status}
+	if _, err := config.DB.Collection("room_requests").InsertOne(ctx, request); err != nil {
+		t.Fatal(err)
+	}
+	assignment.ID = primitive.NewObjectID()
+	assignment.RequestID = request.ID
+	if _, err := config.DB.Collection("room_assignments").InsertOne(ctx, assignment); err != nil {
+		t.Fatal(err)
+	}
+	return assignment
+}
+
+func TestOverdueCheckouts(t *testing.T) {
+	useTestDB(t)
+	today := utils.StartOfDayIST(time.Now())
+	stay := func(checkOut time.Time) models.RoomAssignment {
+		return models.RoomAssignment{CheckInDate: checkOut.AddDate(0, 0, -2), CheckOutDate: checkOut, CheckedIn: true}
+	}
+
+	overdue := insertStay(t, models.StatusApproved, stay(today.AddDate(0, 0, -1).Add(11*time.Hour)))
+	insertStay(t, models.StatusApproved, stay(today))                   // Leaving today
+	insertStay(t, models.StatusRejected, stay(today.AddDate(0, 0, -1))) // Request rejected after assignment
+	noShow := stay(today.AddDate(0, 0, -1))
+	noShow.NoShow = true
+	insertStay(t, models.StatusNoShow, noShow)
+
+	stays, err := findActiveStays(context.Background(), bson.M{
+		"check_out_date": bson.M{"$lt": today},
+		"checked_in":     true,
+		"checked_out":    false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stays) != 1 || stays[0].ID != overdue.ID {
+		t.Errorf("found %d overdue stays, want only %s", len(stays), overdue.ID.Hex())
+	}
+
+	count, err := ExecuteOverdueCheckouts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("ExecuteOverdueCheckouts() = %d, want 1", count)
+	}
+}
@@ -2,9 +2,10 @@ package handlers
 
 import (
 	"context"
-	"encoding/csv"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -35,10 +36,19 @@ func CreateRoom(c *gin.Context) {
 		return
 	}
 
+	roomType, err := activeRoomType(string(req.Type))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Beds) == 0 {
+		req.Beds = roomType.DefaultBeds
+	}
+
 	room := models.Room{
 		RoomNumber:      req.RoomNumber,
 		Floor:           req.Floor,
-		Type:            req.Type,
+		Type:            roomType.Code,
 		Beds:            req.Beds,
 		HasGeyser:       req.HasGeyser,
 		HasAC:           req.HasAC,
@@ -63,206 +73,51 @@ func CreateRoom(c *gin.Context) {
 	c.JSON(http.StatusCreated, room)
 }
 
-// CreateMultipleRooms creates multiple new rooms
-func CreateMultipleRooms(c *gin.Context) {
-	fileHeader, err := c.FormFile("file")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File upload failed"})
-		return
-	}
-
-	if !strings.HasSuffix(fileHeader.Filename, ".csv") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Only CSV files are allowed"})
-		return
-	}
-
-	file, err := fileHeader.Open()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
-		return
-	}
-	defer file.Close()
-
-	reader := csv.NewReader(file)
-	headers, err := reader.Read()
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read CSV headers"})
-		return
-	}
-
-	var rooms []interface{}
-	var skippedRows []string
-	for {
-		row, err := reader.Read()
-		if err != nil {
-			break
-		}
-
-		// Map CSV headers to row values
-		data := map[string]string{}
-		for i, header := range headers {
-			data[header] = row[i]
-		}
-
-		// Normalize the room type string from CSV
-		roomTypeStr := strings.ToLower(strings.TrimSpace(data["type"]))
-
-		var roomType models.RoomType
-		switch roomTypeStr {
-		case "shreehariplus":
-			roomType = models.ShreeHariPlus
-		case "shreehari":
-			roomType = models.ShreeHari
-		case "sarjuplus":
-			roomType = models.SarjuPlus
-		case "sarju":
-			roomType = models.Sarju
-		case "neelkanthplus":
-			roomType = models.NeelkanthPlus
-		case "neelkanth":
-			roomType = models.Neelkanth
-		default:
-			skippedRows = append(skippedRows, "Invalid room type for room_number: "+data["room_number"])
-			continue // Skip this row
-		}
-
-		// Parse and validate beds from comma-separated values
-		bedsStr := strings.Split(data["beds"], ",")
-		var beds []models.Bed
-		validBeds := true
-		for _, b := range bedsStr {
-			trimmedBedType := models.BedType(strings.TrimSpace(b))
-			if trimmedBedType == "" {
-				continue
-			}
-			switch trimmedBedType {
-			case models.Single, models.Double, models.ExtraBed:
-				beds = append(beds, models.Bed{Type: trimmedBedType})
-			default:
-				validBeds = false
-				break // Invalid bed type found
-			}
-		}
-
-		if !validBeds {
-			skippedRows = append(skippedRows, "Invalid bed type for room_number: "+data["room_number"])
-			continue // Skip this row
-		}
-
-		// Parse primitive values
-		floor, _ := strconv.Atoi(data["floor"])
-		sofaSetQty, _ := strconv.Atoi(data["sofa_set_quantity"])
-		hasAC := strings.ToLower(data["has_ac"]) == "true"
-		hasGeyser := strings.ToLower(data["has_geyser"]) == "true"
-		hasSofaSet := strings.ToLower(data["has_sofa_set"]) == "true"
-		isVisible := strings.ToLower(data["is_visible"]) == "true"
-
-		// Parse images from comma-separated values
-		imagesStr := strings.Split(data["images"], ",")
-		descStr := strings.Split(data["images_description"], ",")
-
-		var images []models.RoomImage
-		for i, imgURL := range imagesStr {
-			trimmedURL := strings.TrimSpace(imgURL)
-			if trimmedURL == "" {
-				continue
-			}
-			description := ""
-			if i < len(descStr) {
-				description = strings.TrimSpace(descStr[i])
-			}
-
-			images = append(images, models.RoomImage{
-				URL:         trimmedURL,
-				Description: description,
-				UploadedAt:  time.Now(),
-			})
-		}
-
-		room := models.Room{
-			RoomNumber:      data["room_number"],
-			Floor:           floor,
-			Type:            roomType,
-			Beds:            beds,
-			HasGeyser:       hasGeyser,
-			HasAC:           hasAC,
-			HasSofaSet:      hasSofaSet,
-			SofaSetQuantity: sofaSetQty,
-			ExtraAmenities:  data["extra_amenities"],
-			IsVisible:       isVisible,
-			IsOccupied:      false,
-			Building:        data["building"],
-			CreatedAt:       time.Now(),
-			UpdatedAt:       time.Now(),
-		}
-
-		// Check if room already exists
-		var existing models.Room
-		err = config.DB.Collection("rooms").FindOne(context.Background(), bson.M{
-			"room_number": room.RoomNumber,
-			"building":    room.Building,
-		}).Decode(&existing)
-
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			rooms = append(rooms, room)
-		} else {
-			skippedRows = append(skippedRows, "Room already exists: "+data["room_number"])
-		}
-	}
-
-	if len(rooms) > 0 {
-		_, err = config.DB.Collection("rooms").InsertMany(context.Background(), rooms)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to insert rooms"})
-			return
-		}
-	}
-
-	response := gin.H{
-		"message":         "Room import process finished",
-		"imported_rooms":  len(rooms),
-		"skipped_rows":    len(skippedRows),
-		"skipped_details": skippedRows,
-	}
-
-	if len(rooms) == 0 && len(skippedRows) > 0 {
-		c.JSON(http.StatusConflict, response)
-		return
-	}
-
-	c.JSON(http.StatusOK, response)
-}
-
-// GetRooms returns all rooms with optional filters
-func GetRooms(c *gin.Context) {
+// roomListFilter builds the room filter from the list query params. A nil
+// user isn't limited to the rooms guests can see.
+func roomListFilter(query url.Values, user *models.User, outOfOrder []primitive.ObjectID) (bson.M, error) {
 	filter := bson.M{}
 
-	// Apply filters if provided
-	if floor := c.Query("floor"); floor != "" {
+	if floor := query.Get("floor"); floor != "" {
 		// floor will be int, so convert string to int
 		floorInt, err := strconv.Atoi(floor)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid floor value"})
-			return
+			return nil, errors.New("Invalid floor value")
 		}
 		filter["floor"] = floorInt
 	}
-	if roomType := c.Query("type"); roomType != "" {
+	if roomType := query.Get("type"); roomType != "" {
 		filter["type"] = roomType
 	}
-	if building := c.Query("building"); building != "" {
+	if building := query.Get("building"); building != "" {
 		filter["building"] = building
 	}
-	if isVisible := c.Query("is_visible"); isVisible != "" {
+	if isVisible := query.Get("is_visible"); isVisible != "" {
 		filter["is_visible"] = isVisible == "true"
 	}
-	if isOccupied := c.Query("is_occupied"); isOccupied != "" {
+	if isOccupied := query.Get("is_occupied"); isOccupied != "" {
 		filter["is_occupied"] = isOccupied == "true"
 	}
-	if needsCleaning := c.Query("needs_cleaning"); needsCleaning != "" {
+	if needsCleaning := query.Get("needs_cleaning"); needsCleaning != "" {
 		filter["needs_cleaning"] = needsCleaning == "true"
 	}
 
+	// Anyone but staff can only see visible rooms that are in service
+	isStaff := user == nil || user.Role == models.RoleSuperAdmin || user.Role == models.RoleStaff
+	if !isStaff {
+		filter["is_visible"] = true
+	}
+	if !isStaff || query.Get("out_of_order") == "false" {
+		filter["_id"] = bson.M{"$nin": outOfOrder}
+	} else if query.Get("out_of_order") == "true" {
+		filter["_id"] = bson.M{"$in": outOfOrder}
+	}
+
+	return filter, nil
+}
+
+// GetRooms returns all rooms with optional filters
+func GetRooms(c *gin.Context) {
 	// Pagination parameters
 	limitStr := c.Query("limit")
 	offsetStr := c.Query("offset")
@@ -286,9 +141,16 @@ func GetRooms(c *gin.Context) {
 		return
 	}
 
-	// Regular users can only see visible rooms
-	if user.Role == models.RoleUser {
-		filter["is_visible"] = true
+	outOfOrder, err := outOfOrderRoomIDs()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking room maintenance"})
+		return
+	}
+
+	filter, err := roomListFilter(c.Request.URL.Query(), &user, outOfOrder)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	findOptions := options.Find()
@@ -312,6 +174,14 @@ func GetRooms(c *gin.Context) {
 		return
 	}
 
+	blocked := make(map[primitive.ObjectID]bool, len(outOfOrder))
+	for _, id := range outOfOrder {
+		blocked[id] = true
+	}
+	for i := range rooms {
+		rooms[i].OutOfOrder = blocked[rooms[i].ID]
+	}
+
 	if isPaginated {
 		total, _ := config.DB.Collection("rooms").CountDocuments(context.Background(), filter)
 		c.JSON(http.StatusOK, gin.H{
@@ -343,7 +213,8 @@ func GetRoom(c *gin.Context) {
 
 	// Build filter based on role
 	filter := bson.M{"_id": id}
-	if user.Role == models.RoleUser {
+	isStaff := user.Role == models.RoleSuperAdmin || user.Role == models.RoleStaff
+	if !isStaff {
 		filter["is_visible"] = true
 	}
 
@@ -358,6 +229,11 @@ func GetRoom(c *gin.Context) {
 		return
 	}
 
+	now := time.Now()
+	if ticket, err := roomOutOfOrder(room.ID, now, now.Add(time.Second)); err == nil && ticket != nil {
+		room.OutOfOrder = true
+	}
+
 	c.JSON(http.StatusOK, room)
 }
 
@@ -399,7 +275,12 @@ func UpdateRoom(c *gin.Context) {
 		update["$set"].(bson.M)["floor"] = *req.Floor
 	}
 	if req.Type != nil {
-		update["$set"].(bson.M)["type"] = *req.Type
+		roomType, err := activeRoomType(string(*req.Type))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		update["$set"].(bson.M)["type"] = roomType.Code
 	}
 	if req.Beds != nil {
 		update["$set"].(bson.M)["beds"] = req.Beds
@@ -463,11 +344,28 @@ func GetRoomStats(c *gin.Context) {
 		return
 	}
 
+	// Out-of-order rooms that aren't already counted as occupied or dirty
+	outOfOrder, err := outOfOrderRoomIDs()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching room stats"})
+		return
+	}
+	outOfOrderRooms, err := config.DB.Collection("rooms").CountDocuments(context.Background(), bson.M{
+		"_id":            bson.M{"$in": outOfOrder},
+		"is_occupied":    false,
+		"needs_cleaning": false,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching room stats"})
+		return
+	}
+
 	stats := gin.H{
-		"total_rooms":     totalRooms,
-		"occupied_rooms":  occupiedRooms,
-		"cleaning_rooms":  cleaningRooms,
-		"available_rooms": totalRooms - occupiedRooms - cleaningRooms,
+		"total_rooms":        totalRooms,
+		"occupied_rooms":     occupiedRooms,
+		"cleaning_rooms":     cleaningRooms,
+		"out_of_order_rooms": len(outOfOrder),
+		"available_rooms":    totalRooms - occupiedRooms - cleaningRooms - outOfOrderRooms,
 	}
 
 	c.JSON(http.StatusOK, stats)
@@ -481,20 +379,32 @@ func DeleteRoom(c *gin.Context) {
 		return
 	}
 
-	result, err := config.DB.Collection("rooms").DeleteOne(
-		context.Background(),
-		bson.M{"_id": id},
-	)
-
+	// Deleting a room a stay still holds would strand the stay
+	held, err := holdingRoom(context.Background(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting room"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking room stays"})
 		return
 	}
+	if held {
+		c.JSON(http.StatusConflict, gin.H{"error": "Room has stays that have not checked out"})
+		return
+	}
+
+	var room models.Room
+	err = config.DB.Collection("rooms").FindOneAndDelete(
+		context.Background(),
+		bson.M{"_id": id},
+	).Decode(&room)
 
-	if result.DeletedCount == 0 {
+	if err == mongo.ErrNoDocuments {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting room"})
+		return
+	}
+	deleteStoredImages(room.Images)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Room deleted successfully"})
 }
@@ -532,16 +442,38 @@ func ToggleRoomCleaning(c *gin.Context) {
 		return
 	}
 
+	// Keep the housekeeping queue in step with the flag
+	staffIDVal, _ := c.Get("user_id")
+	staffObjID, _ := primitive.ObjectIDFromHex(fmt.Sprintf("%v", staffIDVal))
+	if newStatus {
+		_, _, err = createCleaningTask(room, nil, models.CleaningSourceManual, staffObjID)
+	} else {
+		err = closeCleaningTasks(room.ID, staffObjID)
+	}
+	if err != nil {
+		fmt.Printf("Error syncing cleaning tasks for room %s: %v\n", room.RoomNumber, err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":        "Room cleaning status updated",
 		"needs_cleaning": newStatus,
 	})
 }
 
+// CreateRoomCategory creates a category from JSON, or from a multipart form
+// with room_name, price and image files in "images"
 func CreateRoomCategory(c *gin.Context) {
 	var req models.RoomCategory
+	multipartForm := strings.HasPrefix(c.ContentType(), "multipart/")
 
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if multipartForm {
+		req.RoomName = c.PostForm("room_name")
+		req.Price = c.PostForm("price")
+		if req.RoomName == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "room_name is required"})
+			return
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -549,8 +481,18 @@ func CreateRoomCategory(c *gin.Context) {
 	req.ID = primitive.NewObjectID()
 	req.CreatedAt = time.Now()
 
+	if multipartForm {
+		images, err := storeImages(c, "room-categories/"+req.ID.Hex())
+		if err != nil {
+			respondUploadError(c, err)
+			return
+		}
+		req.Images = images
+	}
+
 	_, err := config.DB.Collection("room_category").InsertOne(context.Background(), req)
 	if err != nil {
+		deleteStoredImages(req.Images)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating category"})
 		return
 	}
@@ -593,6 +535,9 @@ func UpdateRoomCategory(c *gin.Context) {
 		return
 	}
 
+	// Images are managed through the image endpoints so stored files get cleaned up
+	delete(req, "images")
+	delete(req, "_id")
 	req["updated_at"] = time.Now()
 
 	update := bson.M{"$set": req}
@@ -623,16 +568,17 @@ func DeleteRoomCategory(c *gin.Context) {
 		return
 	}
 
-	result, err := config.DB.Collection("room_categories").DeleteOne(context.Background(), bson.M{"_id": objectID})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting room category"})
+	var category models.RoomCategory
+	err = config.DB.Collection("room_category").FindOneAndDelete(context.Background(), bson.M{"_id": objectID}).Decode(&category)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room category not found"})
 		return
 	}
-
-	if result.DeletedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Room category not found"})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting room category"})
 		return
 	}
+	deleteStoredImages(category.Images)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Room category deleted successfully"})
 }
@@ -0,0 +1,601 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+)
+
+// importRow is one data row of an import file keyed by normalised header
+type importRow struct {
+	Line int
+	Data map[string]string
+	Err  error // Set when the row itself could not be read
+}
+
+// normalizeImportHeader turns "Room Number" and "room_number" into the same key
+func normalizeImportHeader(h string) string {
+	h = strings.TrimPrefix(h, "\ufeff")
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(h)), " ", "_")
+}
+
+func mapImportRow(headers, cells []string) map[string]string {
+	data := make(map[string]string, len(headers))
+	for i, header := range headers {
+		if i < len(cells) {
+			data[header] = strings.TrimSpace(cells[i])
+		} else {
+			data[header] = ""
+		}
+	}
+	return data
+}
+
+// readCSVImport reads every row of a CSV file, keeping malformed rows as
+// errors instead of stopping at the first one
+func readCSVImport(r io.Reader) ([]string, []importRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	headerRow, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CSV headers: %w", err)
+	}
+	headers := make([]string, len(headerRow))
+	for i, h := range headerRow {
+		headers[i] = normalizeImportHeader(h)
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, importRow{Line: parseErr.StartLine, Err: parseErr.Err})
+				continue
+			}
+			return nil, nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(record) > len(headers) {
+			rows = append(rows, importRow{Line: line, Err: fmt.Errorf("row has %d fields, header has %d", len(record), len(headers))})
+			continue
+		}
+		rows = append(rows, importRow{Line: line, Data: mapImportRow(headers, record)})
+	}
+
+	return headers, rows, nil
+}
+
+// readXLSXImport reads the first sheet of a workbook. Line numbers are the
+// spreadsheet row numbers.
+func readXLSXImport(r io.Reader) ([]string, []importRow, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open XLSX file: %w", err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, nil, errors.New("workbook has no sheets")
+	}
+
+	all, err := f.GetRows(sheets[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read sheet %s: %w", sheets[0], err)
+	}
+	if len(all) == 0 {
+		return nil, nil, errors.New("sheet is empty")
+	}
+
+	headers := make([]string, len(all[0]))
+	for i, h := range all[0] {
+		headers[i] = normalizeImportHeader(h)
+	}
+
+	var rows []importRow
+	for i, cells := range all[1:] {
+		empty := true
+		for _, cell := range cells {
+			if strings.TrimSpace(cell) != "" {
+				empty = false
+				break
+			}
+		}
+		if empty {
+			continue
+		}
+		rows = append(rows, importRow{Line: i + 2, Data: mapImportRow(headers, cells)})
+	}
+
+	return headers, rows, nil
+}
+
+// parseImportBeds parses "DOUBLE:2, SINGLE" into beds, merging repeated types
+func parseImportBeds(value string) ([]models.Bed, error) {
+	var beds []models.Bed
+	index := map[models.BedType]int{}
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, qtyStr, hasQty := strings.Cut(part, ":")
+		quantity := 1
+		if hasQty {
+			q, err := strconv.Atoi(strings.TrimSpace(qtyStr))
+			if err != nil || q < 1 {
+				return nil, fmt.Errorf("invalid bed quantity %q", part)
+			}
+			quantity = q
+		}
+
+		var bedType models.BedType
+		switch normalizeTypeCode(name) {
+		case "SINGLE":
+			bedType = models.Single
+		case "DOUBLE":
+			bedType = models.Double
+		case "EXTRABED":
+			bedType = models.ExtraBed
+		default:
+			return nil, fmt.Errorf("invalid bed type %q", strings.TrimSpace(name))
+		}
+
+		if i, ok := index[bedType]; ok {
+			beds[i].Quantity += quantity
+			continue
+		}
+		index[bedType] = len(beds)
+		beds = append(beds, models.Bed{Type: bedType, Quantity: quantity})
+	}
+
+	return beds, nil
+}
+
+func parseImportBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "":
+		return false, nil
+	case "true", "yes", "y", "1":
+		return true, nil
+	case "false", "no", "n", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", value)
+}
+
+// roomImportContext holds lookups loaded once per import
+type roomImportContext struct {
+	categoriesByID   map[string]models.RoomCategory
+	categoriesByName map[string]models.RoomCategory
+	roomTypes        map[string]models.RoomTypeConfig
+}
+
+func loadRoomImportContext() (*roomImportContext, error) {
+	cursor, err := config.DB.Collection("room_category").Find(context.Background(), bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var categories []models.RoomCategory
+	if err := cursor.All(context.Background(), &categories); err != nil {
+		return nil, err
+	}
+
+	types, err := roomTypeCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	ictx := &roomImportContext{
+		categoriesByID:   map[string]models.RoomCategory{},
+		categoriesByName: map[string]models.RoomCategory{},
+		roomTypes:        indexRoomTypes(types),
+	}
+	for _, category := range categories {
+		ictx.categoriesByID[category.ID.Hex()] = category
+		ictx.categoriesByName[normalizeTypeCode(category.RoomName)] = category
+	}
+	return ictx, nil
+}
+
+// parseImportRoom validates a row and builds the room it describes. The
+// returned field set only holds columns present in the file, so upserts
+// leave other fields untouched.
+func (ictx *roomImportContext) parseImportRoom(data map[string]string) (models.Room, bson.M, []string, []string) {
+	var errs, warnings []string
+	fields := bson.M{}
+	has := func(col string) bool { _, ok := data[col]; return ok }
+
+	room := models.Room{
+		RoomNumber: data["room_number"],
+		Building:   data["building"],
+	}
+	if room.RoomNumber == "" {
+		errs = append(errs, "room_number is required")
+	}
+	if room.Building == "" {
+		warnings = append(warnings, "building is empty")
+	}
+	fields["room_number"] = room.RoomNumber
+	fields["building"] = room.Building
+
+	if floor, err := strconv.Atoi(data["floor"]); err != nil {
+		errs = append(errs, fmt.Sprintf("invalid floor %q", data["floor"]))
+	} else {
+		room.Floor = floor
+		fields["floor"] = floor
+	}
+
+	var roomType models.RoomTypeConfig
+	if has("type") {
+		if strings.TrimSpace(data["type"]) == "" {
+			errs = append(errs, "type is required")
+		} else if known, ok := ictx.roomTypes[normalizeTypeCode(data["type"])]; !ok {
+			errs = append(errs, fmt.Sprintf("unknown room type %q", data["type"]))
+		} else if !known.IsActive {
+			errs = append(errs, fmt.Sprintf("room type %s is inactive", known.Code))
+		} else {
+			roomType = known
+			room.Type = known.Code
+			fields["type"] = room.Type
+		}
+	} else {
+		errs = append(errs, "type column is missing")
+	}
+
+	// Rooms created without beds get the room type's default beds; updates
+	// only touch beds when the column has a value
+	room.Beds = roomType.DefaultBeds
+	if has("beds") && strings.TrimSpace(data["beds"]) != "" {
+		beds, err := parseImportBeds(data["beds"])
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		room.Beds = beds
+		fields["beds"] = beds
+	}
+
+	for col, target := range map[string]*bool{
+		"has_geyser":   &room.HasGeyser,
+		"has_ac":       &room.HasAC,
+		"has_sofa_set": &room.HasSofaSet,
+		"is_visible":   &room.IsVisible,
+	} {
+		if !has(col) {
+			continue
+		}
+		v, err := parseImportBool(data[col])
+		if err != nil {
+			errs = append(errs, col+": "+err.Error())
+			continue
+		}
+		*target = v
+		fields[col] = v
+	}
+
+	if has("sofa_set_quantity") && data["sofa_set_quantity"] != "" {
+		qty, err := strconv.Atoi(data["sofa_set_quantity"])
+		if err != nil || qty < 0 {
+			errs = append(errs, fmt.Sprintf("invalid sofa_set_quantity %q", data["sofa_set_quantity"]))
+		} else {
+			room.SofaSetQuantity = qty
+			fields["sofa_set_quantity"] = qty
+		}
+	}
+
+	if has("extra_amenities") {
+		room.ExtraAmenities = data["extra_amenities"]
+		fields["extra_amenities"] = room.ExtraAmenities
+	}
+
+	// Link the room category by ID or by name
+	if id := data["room_category_id"]; id != "" {
+		if _, ok := ictx.categoriesByID[id]; !ok {
+			errs = append(errs, fmt.Sprintf("room category %q not found", id))
+		} else {
+			room.RoomCategoryId = id
+			fields["room_category_id"] = id
+		}
+	} else if name := data["room_category"]; name != "" {
+		if category, ok := ictx.categoriesByName[normalizeTypeCode(name)]; !ok {
+			errs = append(errs, fmt.Sprintf("room category %q not found", name))
+		} else {
+			room.RoomCategoryId = category.ID.Hex()
+			fields["room_category_id"] = room.RoomCategoryId
+		}
+	}
+
+	if has("images") && data["images"] != "" {
+		warnings = append(warnings, "images column is ignored; upload images to the room category")
+	}
+
+	return room, fields, errs, warnings
+}
+
+// roomImportKey identifies a room the way the unique index on building and
+// room number does, so case matters
+func roomImportKey(building, roomNumber string) string {
+	return building + "|" + roomNumber
+}
+
+// failedWrites maps the writes a bulk write rejected, by their index in the
+// batch, to the error. Other errors mean the whole batch failed.
+func failedWrites(err error) (map[int]string, error) {
+	if err == nil {
+		return nil, nil
+	}
+	var bulk mongo.BulkWriteException
+	if !errors.As(err, &bulk) || bulk.WriteConcernError != nil || len(bulk.WriteErrors) == 0 {
+		return nil, err
+	}
+	failed := make(map[int]string, len(bulk.WriteErrors))
+	for _, we := range bulk.WriteErrors {
+		message := we.Message
+		if mongo.IsDuplicateKeyError(we.WriteError) {
+			message = "room already exists"
+		}
+		failed[we.Index] = message
+	}
+	return failed, nil
+}
+
+// readRoomImport reads the rows of a CSV or XLSX room import file, picking
+// the format from the file extension
+func readRoomImport(r io.Reader, ext string) ([]importRow, error) {
+	var rows []importRow
+	var err error
+	switch ext {
+	case ".xlsx":
+		_, rows, err = readXLSXImport(r)
+	case ".csv":
+		_, rows, err = readCSVImport(r)
+	default:
+		return nil, errors.New("Only CSV and XLSX files are allowed")
+	}
+	return rows, err
+}
+
+// importRooms checks the rows of an import file and writes the rooms unless
+// dryRun is set. Existing rooms, matched on building and room number, are
+// updated if upsert is set and skipped otherwise.
+func importRooms(rows []importRow, dryRun, upsert bool) (models.RoomImportReport, error) {
+	report := models.RoomImportReport{
+		DryRun:    dryRun,
+		Upsert:    upsert,
+		TotalRows: len(rows),
+		Rows:      make([]models.RoomImportRow, 0, len(rows)),
+	}
+
+	ictx, err := loadRoomImportContext()
+	if err != nil {
+		return report, fmt.Errorf("Error fetching room categories and types: %w", err)
+	}
+
+	type parsedRoom struct {
+		reportIndex int
+		room        models.Room
+		fields      bson.M
+	}
+	var parsed []parsedRoom
+	seen := map[string]int{}
+	var roomNumbers []string
+
+	for _, row := range rows {
+		result := models.RoomImportRow{Line: row.Line}
+		if row.Err != nil {
+			result.Action = models.RoomImportError
+			result.Errors = []string{row.Err.Error()}
+			report.Rows = append(report.Rows, result)
+			continue
+		}
+
+		room, fields, errs, warnings := ictx.parseImportRoom(row.Data)
+		result.RoomNumber = room.RoomNumber
+		result.Building = room.Building
+		result.Warnings = warnings
+
+		key := roomImportKey(room.Building, room.RoomNumber)
+		if firstLine, dup := seen[key]; dup && room.RoomNumber != "" {
+			errs = append(errs, fmt.Sprintf("duplicate of line %d", firstLine))
+		}
+		if len(errs) > 0 {
+			result.Action = models.RoomImportError
+			result.Errors = errs
+			report.Rows = append(report.Rows, result)
+			continue
+		}
+
+		seen[key] = row.Line
+		roomNumbers = append(roomNumbers, room.RoomNumber)
+		parsed = append(parsed, parsedRoom{reportIndex: len(report.Rows), room: room, fields: fields})
+		report.Rows = append(report.Rows, result)
+	}
+
+	// Look up all existing rooms in one query
+	existing := map[string]models.Room{}
+	if len(roomNumbers) > 0 {
+		cursor, err := config.DB.Collection("rooms").Find(
+			context.Background(),
+			bson.M{"room_number": bson.M{"$in": roomNumbers}},
+			options.Find().SetProjection(bson.M{"room_number": 1, "building": 1}),
+		)
+		if err != nil {
+			return report, fmt.Errorf("Error checking existing rooms: %w", err)
+		}
+		var rooms []models.Room
+		if err := cursor.All(context.Background(), &rooms); err != nil {
+			return report, fmt.Errorf("Error checking existing rooms: %w", err)
+		}
+		for _, room := range rooms {
+			existing[roomImportKey(room.Building, room.RoomNumber)] = room
+		}
+	}
+
+	now := time.Now()
+	var inserts []interface{}
+	var updates []mongo.WriteModel
+	var insertRows, updateRows []int // Report row of each insert and update
+	for _, p := range parsed {
+		result := &report.Rows[p.reportIndex]
+		current, exists := existing[roomImportKey(p.room.Building, p.room.RoomNumber)]
+
+		switch {
+		case exists && !report.Upsert:
+			result.Action = models.RoomImportSkip
+			result.Warnings = append(result.Warnings, "room already exists")
+			report.Skipped++
+		case exists:
+			result.Action = models.RoomImportUpdate
+			p.fields["updated_at"] = now
+			updates = append(updates, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": current.ID}).
+				SetUpdate(bson.M{"$set": p.fields}))
+			updateRows = append(updateRows, p.reportIndex)
+			report.Updated++
+		default:
+			result.Action = models.RoomImportCreate
+			p.room.CreatedAt = now
+			p.room.UpdatedAt = now
+			inserts = append(inserts, p.room)
+			insertRows = append(insertRows, p.reportIndex)
+			report.Created++
+		}
+	}
+
+	// Writes are unordered so one rejected room doesn't stop the rest, and
+	// each rejected room becomes an error on its row
+	if !report.DryRun {
+		if len(inserts) > 0 {
+			_, err := config.DB.Collection("rooms").InsertMany(context.Background(), inserts, options.InsertMany().SetOrdered(false))
+			failed, err := failedWrites(err)
+			if err != nil {
+				return report, fmt.Errorf("Failed to insert rooms: %w", err)
+			}
+			for i, message := range failed {
+				markImportRowFailed(&report.Rows[insertRows[i]], message)
+				report.Created--
+			}
+		}
+		if len(updates) > 0 {
+			_, err := config.DB.Collection("rooms").BulkWrite(context.Background(), updates, options.BulkWrite().SetOrdered(false))
+			failed, err := failedWrites(err)
+			if err != nil {
+				return report, fmt.Errorf("Failed to update rooms: %w", err)
+			}
+			for i, message := range failed {
+				markImportRowFailed(&report.Rows[updateRows[i]], message)
+				report.Updated--
+			}
+		}
+	}
+	for _, row := range report.Rows {
+		if row.Action == models.RoomImportError {
+			report.Failed++
+		}
+	}
+	return report, nil
+}
+
+func markImportRowFailed(row *models.RoomImportRow, message string) {
+	row.Action = models.RoomImportError
+	row.Errors = append(row.Errors, message)
+}
+
+// ImportRoomsFile imports rooms from a CSV or XLSX file on disk, as
+// CreateMultipleRooms does for an upload
+func ImportRoomsFile(path string, dryRun, upsert bool) (models.RoomImportReport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return models.RoomImportReport{}, err
+	}
+	defer file.Close()
+
+	rows, err := readRoomImport(file, strings.ToLower(filepath.Ext(path)))
+	if err != nil {
+		return models.RoomImportReport{}, err
+	}
+	return importRooms(rows, dryRun, upsert)
+}
+
+// CreateMultipleRooms imports rooms from a CSV or XLSX file. With
+// ?dry_run=true nothing is written and the report shows what would happen;
+// with ?upsert=true existing rooms (matched on building and room number) are
+// updated instead of skipped.
+func CreateMultipleRooms(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File upload failed"})
+		return
+	}
+
+	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
+	if ext != ".csv" && ext != ".xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only CSV and XLSX files are allowed"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
+		return
+	}
+	defer file.Close()
+
+	rows, err := readRoomImport(file, ext)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := importRooms(rows, c.Query("dry_run") == "true", c.Query("upsert") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	message := "Room import process finished"
+	if report.DryRun {
+		message = "Dry run finished, no rooms were written"
+	}
+
+	status := http.StatusOK
+	if !report.DryRun && report.Created+report.Updated == 0 && report.TotalRows > 0 {
+		status = http.StatusConflict
+	}
+
+	c.JSON(status, gin.H{
+		"message":        message,
+		"imported_rooms": report.Created + report.Updated,
+		"skipped_rows":   report.Skipped + report.Failed,
+		"report":         report,
+	})
+}
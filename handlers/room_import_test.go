@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"utara_backend/models"
+)
+
+func TestRoomImportKeyMatchesIndex(t *testing.T) {
+	if roomImportKey("Annex", "101A") == roomImportKey("annex", "101a") {
+		t.Error("rooms differing only in case share a key, but the unique index tells them apart")
+	}
+}
+
+func TestFailedWrites(t *testing.T) {
+	failed, err := failedWrites(mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+		{WriteError: mongo.WriteError{Index: 1, Code: 11000, Message: "E11000 duplicate key error"}},
+		{WriteError: mongo.WriteError{Index: 3, Code: 121, Message: "Document failed validation"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]string{1: "room already exists", 3: "Document failed validation"}
+	if len(failed) != len(want) || failed[1] != want[1] || failed[3] != want[3] {
+		t.Errorf("failedWrites = %v, want %v", failed, want)
+	}
+
+	if failed, err := failedWrites(nil); failed != nil || err != nil {
+		t.Errorf("failedWrites(nil) = %v, %v", failed, err)
+	}
+
+	down := errors.New("server selection timeout")
+	if _, err := failedWrites(down); err != down {
+		t.Errorf("a failed batch returned %v, want %v", err, down)
+	}
+	concern := mongo.BulkWriteException{WriteConcernError: &mongo.WriteConcernError{Message: "waiting for replication timed out"}}
+	if _, err := failedWrites(concern); err == nil {
+		t.Error("a write concern error was treated as row errors")
+	}
+}
+
+func TestImportDemoCSV(t *testing.T) {
+	file, err := os.Open("../demo.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	rows, err := readRoomImport(file, ".csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) == 0 {
+		t.Fatal("demo.csv has no rows")
+	}
+
+	ictx := &roomImportContext{
+		categoriesByID:   map[string]models.RoomCategory{},
+		categoriesByName: map[string]models.RoomCategory{},
+		roomTypes:        indexRoomTypes(models.DefaultRoomTypes()),
+	}
+	for _, row := range rows {
+		if row.Err != nil {
+			t.Errorf("line %d: %v", row.Line, row.Err)
+			continue
+		}
+		room, _, errs, _ := ictx.parseImportRoom(row.Data)
+		if len(errs) > 0 {
+			t.Errorf("line %d: %v", row.Line, errs)
+		}
+		if room.Type == "" || len(room.Beds) == 0 {
+			t.Errorf("line %d: room %s has type %q and beds %v", row.Line, room.RoomNumber, room.Type, room.Beds)
+		}
+	}
+}
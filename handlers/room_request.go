@@ -4,10 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -17,7 +18,9 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"utara_backend/config"
+	"utara_backend/events"
 	"utara_backend/models"
+	"utara_backend/notify"
 	"utara_backend/utils"
 )
 
@@ -28,7 +31,7 @@ func CreateRoomRequest(c *gin.Context) {
 	var place, purpose, formName, specialRequests, reference string
 	var checkInDate, checkOutDate time.Time
 	var numberOfPeople models.PeopleCount
-	var chitthiURL string
+	var chitthiFile *multipart.FileHeader
 
 	if contentType == "application/json" {
 		// Legacy JSON support
@@ -83,27 +86,9 @@ func CreateRoomRequest(c *gin.Context) {
 			}
 		}
 
-		// Handle chitthi image upload
-		file, err := c.FormFile("chitthi")
-		if err == nil && file != nil {
-			// Create uploads directory if it doesn't exist
-			uploadDir := "./uploads/chitthi"
-			if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating upload directory"})
-				return
-			}
-
-			// Generate unique filename
-			ext := filepath.Ext(file.Filename)
-			filename := fmt.Sprintf("%d_%s%s", time.Now().UnixNano(), primitive.NewObjectID().Hex(), ext)
-			savePath := filepath.Join(uploadDir, filename)
-
-			if err := c.SaveUploadedFile(file, savePath); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error saving chitthi image"})
-				return
-			}
-
-			chitthiURL = "/uploads/chitthi/" + filename
+		// Optional chitthi, stored once the request ID is known
+		if file, err := c.FormFile("chitthi"); err == nil {
+			chitthiFile = file
 		}
 	}
 
@@ -120,6 +105,7 @@ func CreateRoomRequest(c *gin.Context) {
 	numberOfPeople.Total = numberOfPeople.Male + numberOfPeople.Female + numberOfPeople.Children
 
 	roomRequest := models.RoomRequest{
+		ID:              primitive.NewObjectID(),
 		UserID:          userObjID,
 		Name:            user.Name,
 		Place:           place,
@@ -134,17 +120,33 @@ func CreateRoomRequest(c *gin.Context) {
 		UpdatedAt:       time.Now(),
 		Reference:       reference,
 		PublicID:        utils.GeneratePublicRoomRequestID(),
-		ChitthiURL:      chitthiURL,
 	}
 
-	result, err := config.DB.Collection("room_requests").InsertOne(context.Background(), roomRequest)
+	if chitthiFile != nil {
+		chitthi, err := storeChitthi(c.Request.Context(), chitthiFile, roomRequest.ID, userObjID)
+		if err != nil {
+			respondUploadError(c, err)
+			return
+		}
+		roomRequest.Chitthi = chitthi
+		roomRequest.ChitthiURL = chitthiPath(roomRequest.ID)
+		roomRequest.History = []models.RoomRequestEvent{{
+			Action:  models.RequestChitthiUploaded,
+			Details: chitthi.FileName,
+			By:      userObjID,
+			At:      chitthi.UploadedAt,
+		}}
+	}
+
+	_, err = config.DB.Collection("room_requests").InsertOne(context.Background(), roomRequest)
 	if err != nil {
+		if roomRequest.Chitthi != nil {
+			deleteChitthi(roomRequest)
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating room request"})
 		return
 	}
 
-	roomRequest.ID = result.InsertedID.(primitive.ObjectID)
-
 	// Update user's booking stats
 	now := time.Now()
 	_, err = config.DB.Collection("users").UpdateOne(
@@ -160,13 +162,49 @@ func CreateRoomRequest(c *gin.Context) {
 		fmt.Printf("Error updating user booking stats: %v\n", err)
 	}
 
+	notifyRequestStatus(roomRequest, notify.EventRequestReceived)
+	publishRequestEvent(events.RequestCreated, roomRequest, "")
+
 	c.JSON(http.StatusCreated, roomRequest)
 }
 
-// GetRoomRequests returns all room requests with optional filters and room details
-func GetRoomRequests(c *gin.Context) {
+// roomRequestListFilter builds the room request filter from the list query
+// params. A nil user isn't limited to their own requests.
+func roomRequestListFilter(query url.Values, user *models.User) bson.M {
 	filter := bson.M{}
 
+	// Only staff see everyone's requests, others see their own
+	isStaff := user == nil || user.Role == models.RoleSuperAdmin || user.Role == models.RoleStaff
+	if !isStaff {
+		filter["user_id"] = user.ID
+		return filter
+	}
+
+	// Apply filters if provided (for SUPER_ADMIN and STAFF)
+	if status := query.Get("status"); status != "" {
+		filter["status"] = status
+	}
+	if filterUserID := query.Get("user_id"); filterUserID != "" {
+		userObjID, err := primitive.ObjectIDFromHex(filterUserID)
+		if err == nil {
+			filter["user_id"] = userObjID
+		}
+	}
+	if checkoutToday := query.Get("checkout_today"); checkoutToday == "true" {
+		now := time.Now()
+		startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		endOfDay := startOfDay.Add(24 * time.Hour)
+		filter["check_out_date"] = bson.M{
+			"$gte": startOfDay,
+			"$lt":  endOfDay,
+		}
+	}
+
+	return filter
+}
+
+// GetRoomRequests returns all room requests with optional filters and room details
+func GetRoomRequests(c *gin.Context) {
 	// Get user role from context
 	userID, _ := c.Get("user_id")
 	userObjID, _ := primitive.ObjectIDFromHex(userID.(string))
@@ -177,30 +215,7 @@ func GetRoomRequests(c *gin.Context) {
 		return
 	}
 
-	// Regular users can only see their own requests
-	if user.Role == models.RoleUser {
-		filter["user_id"] = userObjID
-	} else {
-		// Apply filters if provided (for SUPER_ADMIN and STAFF)
-		if status := c.Query("status"); status != "" {
-			filter["status"] = status
-		}
-		if filterUserID := c.Query("user_id"); filterUserID != "" {
-			userObjID, err := primitive.ObjectIDFromHex(filterUserID)
-			if err == nil {
-				filter["user_id"] = userObjID
-			}
-		}
-		if checkoutToday := c.Query("checkout_today"); checkoutToday == "true" {
-			now := time.Now()
-			startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
-			endOfDay := startOfDay.Add(24 * time.Hour)
-			filter["check_out_date"] = bson.M{
-				"$gte": startOfDay,
-				"$lt":  endOfDay,
-			}
-		}
-	}
+	filter := roomRequestListFilter(c.Request.URL.Query(), &user)
 
 	// Pagination parameters
 	limitStr := c.Query("limit")
@@ -328,6 +343,28 @@ func ProcessRoomRequest(c *gin.Context) {
 		return
 	}
 
+	// Don't approve into a room that is out of order for the stay
+	if req.Status == models.StatusApproved && req.RoomID != nil {
+		var existing models.RoomRequest
+		if err := config.DB.Collection("room_requests").FindOne(context.Background(), bson.M{"_id": id}).Decode(&existing); err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Room request not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching room request"})
+			return
+		}
+		ticket, err := roomOutOfOrder(*req.RoomID, existing.CheckInDate, existing.CheckOutDate)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking room maintenance"})
+			return
+		}
+		if ticket != nil {
+			c.JSON(http.StatusConflict, outOfOrderError(ticket))
+			return
+		}
+	}
+
 	staffID, _ := c.Get("user_id")
 	staffObjID, _ := primitive.ObjectIDFromHex(staffID.(string))
 
@@ -339,6 +376,11 @@ func ProcessRoomRequest(c *gin.Context) {
 			"updated_at":   time.Now(),
 		},
 	}
+	if reason := strings.TrimSpace(req.RejectionReason); req.Status == models.StatusRejected && reason != "" {
+		update["$set"].(bson.M)["rejection_reason"] = reason
+	} else {
+		update["$unset"] = bson.M{"rejection_reason": ""}
+	}
 
 	var roomRequest models.RoomRequest
 	err = config.DB.Collection("room_requests").FindOneAndUpdate(
@@ -391,6 +433,27 @@ func ProcessRoomRequest(c *gin.Context) {
 		}
 	}
 
+	building := ""
+	if req.RoomID != nil {
+		_, building = roomBuilding(*req.RoomID)
+	}
+	publishRequestEvent(events.RequestStatusChanged, roomRequest, building)
+
+	// The room assigned message also tells the guest they were approved
+	switch {
+	case req.Status == models.StatusApproved && req.RoomID != nil:
+		notifyRoomAssigned(models.RoomAssignment{
+			RoomID:       *req.RoomID,
+			UserID:       roomRequest.UserID,
+			CheckInDate:  roomRequest.CheckInDate,
+			CheckOutDate: roomRequest.CheckOutDate,
+		})
+	case req.Status == models.StatusApproved:
+		notifyRequestStatus(roomRequest, notify.EventRequestApproved)
+	case req.Status == models.StatusRejected:
+		notifyRequestStatus(roomRequest, notify.EventRequestRejected)
+	}
+
 	// If request is rejected and there's a payment, mark it for refund
 	if req.Status == models.StatusRejected {
 		// Find any payment associated with this request
@@ -472,6 +535,16 @@ func AssignRoom(c *gin.Context) {
 		return
 	}
 
+	ticket, err := roomOutOfOrder(roomObjID, req.CheckInDate, req.CheckOutDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking room maintenance"})
+		return
+	}
+	if ticket != nil {
+		c.JSON(http.StatusConflict, outOfOrderError(ticket))
+		return
+	}
+
 	// ✅ Use provided guest names or default
 	guestNames := req.GuestNames
 	if len(guestNames) == 0 {
@@ -509,6 +582,8 @@ func AssignRoom(c *gin.Context) {
 	}
 
 	assignment.ID = result.InsertedID.(primitive.ObjectID)
+	notifyRoomAssigned(assignment)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message":    "Room assigned successfully",
 		"assignment": assignment,
@@ -535,6 +610,20 @@ func CheckInRoom(c *gin.Context) {
 		return
 	}
 
+	// A late arrival the night audit marked a no-show can still check in,
+	// unless their room has gone to someone else since
+	if assignment.NoShow {
+		held, err := holdingRoom(context.Background(), assignment.RoomID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking room availability"})
+			return
+		}
+		if held {
+			c.JSON(http.StatusConflict, gin.H{"error": "Stay was marked a no-show and its room is now taken, assign another room"})
+			return
+		}
+	}
+
 	now := time.Now()
 
 	// ✅ Mark as checked in
@@ -543,13 +632,38 @@ func CheckInRoom(c *gin.Context) {
 			"checked_in":    true,
 			"checked_in_at": &now,
 		},
+		"$unset": bson.M{"no_show": "", "no_show_at": ""},
 	}
 
-	_, err = config.DB.Collection("room_assignments").UpdateByID(context.Background(), id, update)
+	result, err := config.DB.Collection("room_assignments").UpdateOne(context.Background(), bson.M{"_id": id, "checked_in": false}, update)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update check-in status"})
 		return
 	}
+	if result.ModifiedCount == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Room already checked in"})
+		return
+	}
+
+	// ✅ Use staff ID from token/context
+	staffIDVal, _ := c.Get("user_id")
+	staffObjID, _ := primitive.ObjectIDFromHex(fmt.Sprintf("%v", staffIDVal))
+
+	if assignment.NoShow {
+		if err := clearRequestNoShow(context.Background(), assignment.RequestID, staffObjID, now); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore the room request"})
+			return
+		}
+		_, err = config.DB.Collection("rooms").UpdateOne(
+			context.Background(),
+			bson.M{"_id": assignment.RoomID},
+			bson.M{"$set": bson.M{"is_occupied": true, "updated_at": now}},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating room status"})
+			return
+		}
+	}
 
 	// ✅ Re-fetch updated assignment so it shows correct checked_in = true
 	err = config.DB.Collection("room_assignments").FindOne(context.Background(), bson.M{"_id": id}).Decode(&assignment)
@@ -558,19 +672,18 @@ func CheckInRoom(c *gin.Context) {
 		return
 	}
 
+	publishStayEvent(events.CheckedIn, assignment)
+
 	// ✅ Prepare food pass request using updated assignment
 	req := models.GenerateFoodPassRequest{
-		UserID:      assignment.UserID,
-		MemberNames: assignment.GuestNames,
-		DiningHall:  assignment.DiningHallPreference,
-		StartDate:   assignment.CheckInDate,
-		EndDate:     assignment.CheckOutDate,
+		UserID:       assignment.UserID,
+		AssignmentID: &assignment.ID,
+		MemberNames:  assignment.GuestNames,
+		DiningHall:   assignment.DiningHallPreference,
+		StartDate:    assignment.CheckInDate,
+		EndDate:      assignment.CheckOutDate,
 	}
 
-	// ✅ Use staff ID from token/context
-	staffIDVal, _ := c.Get("user_id")
-	staffObjID, _ := primitive.ObjectIDFromHex(fmt.Sprintf("%v", staffIDVal))
-
 	// ✅ Generate food passes using existing function
 	totalPasses, err := ExecuteFoodPassGeneration(req, staffObjID)
 	if err != nil {
@@ -633,6 +746,18 @@ func CheckOutRoom(c *gin.Context) {
 		return
 	}
 
+	publishStayEvent(events.CheckedOut, assignment)
+
+	// Queue the room for housekeeping
+	var room models.Room
+	if err := config.DB.Collection("rooms").FindOne(context.Background(), bson.M{"_id": assignment.RoomID}).Decode(&room); err == nil {
+		staffIDVal, _ := c.Get("user_id")
+		staffObjID, _ := primitive.ObjectIDFromHex(fmt.Sprintf("%v", staffIDVal))
+		if _, _, err := createCleaningTask(room, &assignment.ID, models.CleaningSourceCheckout, staffObjID); err != nil {
+			fmt.Printf("Error creating cleaning task for room %s: %v\n", room.RoomNumber, err)
+		}
+	}
+
 	// Delete all unused & not expired food passes for this user
 	_, err = config.DB.Collection("food_passes").DeleteMany(
 		context.Background(),
@@ -11,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // UpdateRoomRequest updates a room request (only number of people) for pending requests
@@ -77,16 +78,17 @@ func DeleteRoomRequest(c *gin.Context) {
 		"status":  models.StatusPending,
 	}
 
-	result, err := config.DB.Collection("room_requests").DeleteOne(context.Background(), filter)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting room request"})
+	var request models.RoomRequest
+	err = config.DB.Collection("room_requests").FindOneAndDelete(context.Background(), filter).Decode(&request)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room request not found or cannot be deleted (must be pending)"})
 		return
 	}
-
-	if result.DeletedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Room request not found or cannot be deleted (must be pending)"})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting room request"})
 		return
 	}
+	deleteChitthi(request)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Room request deleted successfully"})
 }
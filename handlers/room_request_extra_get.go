@@ -33,8 +33,9 @@ func GetRoomRequestByID(c *gin.Context) {
 	}
 
 	filter := bson.M{"_id": id}
-	// If regular user, ensure they own the request
-	if user.Role == models.RoleUser {
+	// Anyone but staff must own the request
+	isStaff := user.Role == models.RoleSuperAdmin || user.Role == models.RoleStaff
+	if !isStaff {
 		filter["user_id"] = userObjID
 	}
 
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"time"
 
@@ -14,28 +15,33 @@ import (
 	"utara_backend/models"
 )
 
+// The /room-type-costs endpoints predate room types and are kept for older
+// clients. They read and write the deposit fields of the room_types collection.
+
+func roomTypeCostView(t models.RoomTypeConfig) models.RoomTypeCost {
+	return models.RoomTypeCost{
+		ID:            t.ID,
+		RoomType:      t.Code,
+		DepositAmount: t.DepositAmount,
+		Currency:      t.Currency,
+		Description:   t.DisplayName,
+		IsActive:      t.IsActive,
+		CreatedAt:     t.CreatedAt,
+		UpdatedAt:     t.UpdatedAt,
+	}
+}
+
 // GetRoomTypeCosts returns all room type deposit costs
 func GetRoomTypeCosts(c *gin.Context) {
-	cursor, err := config.DB.Collection("room_type_costs").Find(
-		context.Background(),
-		bson.M{},
-		options.Find().SetSort(bson.D{{Key: "room_type", Value: 1}}),
-	)
+	types, err := roomTypeCatalog()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch room type costs"})
 		return
 	}
-	defer cursor.Close(context.Background())
-
-	var costs []models.RoomTypeCost
-	if err := cursor.All(context.Background(), &costs); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode room type costs"})
-		return
-	}
 
-	// If no costs are set, return defaults
-	if len(costs) == 0 {
-		costs = models.GetDefaultRoomTypeCosts()
+	costs := make([]models.RoomTypeCost, len(types))
+	for i, t := range types {
+		costs[i] = roomTypeCostView(t)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -46,28 +52,17 @@ func GetRoomTypeCosts(c *gin.Context) {
 
 // GetRoomTypeCost returns cost for a specific room type
 func GetRoomTypeCost(c *gin.Context) {
-	roomType := models.RoomType(c.Param("type"))
-
-	var cost models.RoomTypeCost
-	err := config.DB.Collection("room_type_costs").FindOne(
-		context.Background(),
-		bson.M{"room_type": roomType},
-	).Decode(&cost)
-
-	if err != nil {
-		// Return default if not found
-		defaults := models.GetDefaultRoomTypeCosts()
-		for _, d := range defaults {
-			if d.RoomType == roomType {
-				c.JSON(http.StatusOK, d)
-				return
-			}
-		}
+	roomType, err := resolveRoomType(c.Param("type"))
+	if errors.Is(err, errRoomTypeNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Room type not found"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch room type cost"})
+		return
+	}
 
-	c.JSON(http.StatusOK, cost)
+	c.JSON(http.StatusOK, roomTypeCostView(roomType))
 }
 
 // UpdateRoomTypeCost updates the deposit amount for a room type
@@ -101,76 +96,49 @@ func UpdateRoomTypeCost(c *gin.Context) {
 		update["$set"].(bson.M)["currency"] = req.Currency
 	}
 	if req.Description != "" {
-		update["$set"].(bson.M)["description"] = req.Description
+		update["$set"].(bson.M)["display_name"] = req.Description
 	}
 	if req.IsActive != nil {
 		update["$set"].(bson.M)["is_active"] = *req.IsActive
 	}
 
-	var cost models.RoomTypeCost
-	err = config.DB.Collection("room_type_costs").FindOneAndUpdate(
+	var roomType models.RoomTypeConfig
+	err = config.DB.Collection("room_types").FindOneAndUpdate(
 		context.Background(),
 		bson.M{"_id": id},
 		update,
 		options.FindOneAndUpdate().SetReturnDocument(options.After),
-	).Decode(&cost)
+	).Decode(&roomType)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update room type cost"})
 		return
 	}
 
-	c.JSON(http.StatusOK, cost)
+	c.JSON(http.StatusOK, roomTypeCostView(roomType))
 }
 
-// InitializeRoomTypeCosts creates default room type costs if none exist
+// InitializeRoomTypeCosts seeds the default room types if none exist
 func InitializeRoomTypeCosts(c *gin.Context) {
-	// Check if any costs exist
-	count, err := config.DB.Collection("room_type_costs").CountDocuments(context.Background(), bson.M{})
+	seeded, err := seedRoomTypes()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing costs"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initialize room type costs"})
 		return
 	}
 
-	if count > 0 {
+	if seeded == 0 {
 		c.JSON(http.StatusOK, gin.H{"message": "Room type costs already initialized"})
 		return
 	}
 
-	// Insert defaults
-	defaults := models.GetDefaultRoomTypeCosts()
-	docs := make([]interface{}, len(defaults))
-	for i, d := range defaults {
-		docs[i] = d
-	}
-
-	_, err = config.DB.Collection("room_type_costs").InsertMany(context.Background(), docs)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initialize room type costs"})
-		return
-	}
-
 	c.JSON(http.StatusCreated, gin.H{"message": "Room type costs initialized successfully"})
 }
 
 // GetDepositAmountForRoomType returns the deposit amount for a room type
 func GetDepositAmountForRoomType(roomType models.RoomType) int {
-	var cost models.RoomTypeCost
-	err := config.DB.Collection("room_type_costs").FindOne(
-		context.Background(),
-		bson.M{"room_type": roomType, "is_active": true},
-	).Decode(&cost)
-
-	if err != nil {
-		// Return default amount
-		defaults := models.GetDefaultRoomTypeCosts()
-		for _, d := range defaults {
-			if d.RoomType == roomType {
-				return d.DepositAmount
-			}
-		}
-		return 30000 // Default fallback: ₹300
+	t, err := resolveRoomType(string(roomType))
+	if err != nil || !t.IsActive {
+		return defaultRoomTypeDeposit
 	}
-
-	return cost.DepositAmount
+	return t.DepositAmount
 }
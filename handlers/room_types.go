@@ -0,0 +1,500 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+)
+
+// defaultRoomTypeDeposit is charged when no room type matches: ₹300
+const defaultRoomTypeDeposit = 30000
+
+var errRoomTypeNotFound = errors.New("room type not found")
+
+// normalizeTypeCode folds the spellings of a type ("Shri Hari+", "shri-hari plus")
+// to a single key by upper-casing, spelling out "+" and dropping separators
+func normalizeTypeCode(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	return strings.NewReplacer("+", "PLUS", " ", "", "-", "", "_", "").Replace(s)
+}
+
+// loadRoomTypes returns the stored room types ordered for display
+func loadRoomTypes(filter bson.M) ([]models.RoomTypeConfig, error) {
+	cursor, err := config.DB.Collection("room_types").Find(
+		context.Background(),
+		filter,
+		options.Find().SetSort(bson.D{{Key: "sort_order", Value: 1}, {Key: "code", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var types []models.RoomTypeConfig
+	if err := cursor.All(context.Background(), &types); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+// roomTypeCatalog returns the stored room types, or the defaults while the
+// collection hasn't been seeded yet
+func roomTypeCatalog() ([]models.RoomTypeConfig, error) {
+	types, err := loadRoomTypes(bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	if len(types) == 0 {
+		types = models.DefaultRoomTypes()
+	}
+	return types, nil
+}
+
+// indexRoomTypes maps every spelling of each type to the type. Codes win over
+// aliases, display names and user types when two types share a spelling.
+func indexRoomTypes(types []models.RoomTypeConfig) map[string]models.RoomTypeConfig {
+	index := map[string]models.RoomTypeConfig{}
+	for _, t := range types {
+		index[normalizeTypeCode(string(t.Code))] = t
+	}
+	for _, t := range types {
+		spellings := append([]string{t.DisplayName, t.UserType}, t.Aliases...)
+		for _, s := range spellings {
+			key := normalizeTypeCode(s)
+			if key == "" {
+				continue
+			}
+			if _, taken := index[key]; !taken {
+				index[key] = t
+			}
+		}
+	}
+	return index
+}
+
+// resolveRoomType finds the room type matching a code, alias, display name
+// or linked user type
+func resolveRoomType(value string) (models.RoomTypeConfig, error) {
+	key := normalizeTypeCode(value)
+	if key == "" {
+		return models.RoomTypeConfig{}, errRoomTypeNotFound
+	}
+	types, err := roomTypeCatalog()
+	if err != nil {
+		return models.RoomTypeConfig{}, err
+	}
+	t, ok := indexRoomTypes(types)[key]
+	if !ok {
+		return models.RoomTypeConfig{}, errRoomTypeNotFound
+	}
+	return t, nil
+}
+
+// activeRoomType resolves a room type given for a room, rejecting unknown
+// and deactivated types
+func activeRoomType(value string) (models.RoomTypeConfig, error) {
+	roomType, err := resolveRoomType(value)
+	if errors.Is(err, errRoomTypeNotFound) {
+		return roomType, fmt.Errorf("unknown room type %q", value)
+	}
+	if err != nil {
+		return roomType, errors.New("failed to look up room type")
+	}
+	if !roomType.IsActive {
+		return roomType, fmt.Errorf("room type %s is inactive", roomType.Code)
+	}
+	return roomType, nil
+}
+
+// checkRoomTypeSpellings makes sure the code and aliases of a type don't
+// already name a different type
+func checkRoomTypeSpellings(id primitive.ObjectID, code models.RoomType, aliases []string) error {
+	types, err := loadRoomTypes(bson.M{"_id": bson.M{"$ne": id}})
+	if err != nil {
+		return err
+	}
+	index := indexRoomTypes(types)
+	for _, s := range append([]string{string(code)}, aliases...) {
+		if other, ok := index[normalizeTypeCode(s)]; ok {
+			return fmt.Errorf("%q already refers to room type %s", s, other.Code)
+		}
+	}
+	return nil
+}
+
+// GetRoomTypes lists room types, optionally filtered with ?active=true|false
+func GetRoomTypes(c *gin.Context) {
+	filter := bson.M{}
+	if active := c.Query("active"); active != "" {
+		filter["is_active"] = active == "true"
+	}
+
+	types, err := loadRoomTypes(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch room types"})
+		return
+	}
+	if types == nil {
+		types = []models.RoomTypeConfig{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"room_types": types,
+		"count":      len(types),
+	})
+}
+
+// GetRoomType returns a room type by code or any of its other spellings
+func GetRoomType(c *gin.Context) {
+	roomType, err := resolveRoomType(c.Param("code"))
+	if errors.Is(err, errRoomTypeNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room type not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch room type"})
+		return
+	}
+
+	c.JSON(http.StatusOK, roomType)
+}
+
+// CreateRoomType adds a room type
+func CreateRoomType(c *gin.Context) {
+	var req models.CreateRoomTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	code := models.RoomType(normalizeTypeCode(req.Code))
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+	if req.DepositAmount < 0 || req.DefaultCapacity < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "deposit_amount and default_capacity can't be negative"})
+		return
+	}
+	if err := checkRoomTypeSpellings(primitive.NilObjectID, code, req.Aliases); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	roomType := models.RoomTypeConfig{
+		Code:            code,
+		DisplayName:     strings.TrimSpace(req.DisplayName),
+		Aliases:         req.Aliases,
+		IsPlus:          req.IsPlus,
+		DefaultCapacity: req.DefaultCapacity,
+		DefaultBeds:     req.DefaultBeds,
+		DepositAmount:   req.DepositAmount,
+		Currency:        req.Currency,
+		UserType:        strings.TrimSpace(req.UserType),
+		SortOrder:       req.SortOrder,
+		IsActive:        req.IsActive == nil || *req.IsActive,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+	if roomType.Currency == "" {
+		roomType.Currency = "INR"
+	}
+
+	result, err := config.DB.Collection("room_types").InsertOne(context.Background(), roomType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create room type"})
+		return
+	}
+
+	roomType.ID = result.InsertedID.(primitive.ObjectID)
+	c.JSON(http.StatusCreated, roomType)
+}
+
+// findRoomTypeByCode loads the room type with exactly the code in the path.
+// Unlike GET, changes don't go through aliases so they can't hit the wrong type.
+func findRoomTypeByCode(c *gin.Context) (models.RoomTypeConfig, bool) {
+	var roomType models.RoomTypeConfig
+	err := config.DB.Collection("room_types").FindOne(context.Background(), bson.M{"code": normalizeTypeCode(c.Param("code"))}).Decode(&roomType)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room type not found"})
+			return roomType, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch room type"})
+		return roomType, false
+	}
+	return roomType, true
+}
+
+// UpdateRoomType changes a room type. The code stays fixed since rooms store it.
+func UpdateRoomType(c *gin.Context) {
+	var req models.UpdateRoomTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	current, ok := findRoomTypeByCode(c)
+	if !ok {
+		return
+	}
+	id := current.ID
+
+	update := bson.M{
+		"$set": bson.M{
+			"updated_at": time.Now(),
+		},
+	}
+
+	if req.Aliases != nil {
+		if err := checkRoomTypeSpellings(id, current.Code, req.Aliases); err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		update["$set"].(bson.M)["aliases"] = req.Aliases
+	}
+	if req.DisplayName != nil {
+		update["$set"].(bson.M)["display_name"] = strings.TrimSpace(*req.DisplayName)
+	}
+	if req.IsPlus != nil {
+		update["$set"].(bson.M)["is_plus"] = *req.IsPlus
+	}
+	if req.DefaultCapacity != nil {
+		if *req.DefaultCapacity < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "default_capacity can't be negative"})
+			return
+		}
+		update["$set"].(bson.M)["default_capacity"] = *req.DefaultCapacity
+	}
+	if req.DefaultBeds != nil {
+		update["$set"].(bson.M)["default_beds"] = req.DefaultBeds
+	}
+	if req.DepositAmount != nil {
+		if *req.DepositAmount < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "deposit_amount can't be negative"})
+			return
+		}
+		update["$set"].(bson.M)["deposit_amount"] = *req.DepositAmount
+	}
+	if req.Currency != nil {
+		update["$set"].(bson.M)["currency"] = *req.Currency
+	}
+	if req.UserType != nil {
+		update["$set"].(bson.M)["user_type"] = strings.TrimSpace(*req.UserType)
+	}
+	if req.SortOrder != nil {
+		update["$set"].(bson.M)["sort_order"] = *req.SortOrder
+	}
+	if req.IsActive != nil {
+		update["$set"].(bson.M)["is_active"] = *req.IsActive
+	}
+
+	var roomType models.RoomTypeConfig
+	err := config.DB.Collection("room_types").FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": id},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&roomType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update room type"})
+		return
+	}
+
+	c.JSON(http.StatusOK, roomType)
+}
+
+// DeleteRoomType removes a room type no room uses. Deactivate it instead
+// to keep existing rooms.
+func DeleteRoomType(c *gin.Context) {
+	roomType, ok := findRoomTypeByCode(c)
+	if !ok {
+		return
+	}
+
+	inUse, err := config.DB.Collection("rooms").CountDocuments(context.Background(), bson.M{"type": roomType.Code})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check rooms"})
+		return
+	}
+	if inUse > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": fmt.Sprintf("%d rooms use this room type; deactivate it instead", inUse),
+			"rooms": inUse,
+		})
+		return
+	}
+
+	if _, err := config.DB.Collection("room_types").DeleteOne(context.Background(), bson.M{"_id": roomType.ID}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete room type"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Room type deleted successfully"})
+}
+
+// seedRoomTypes inserts the default room types into an empty collection,
+// keeping deposits already set through room_type_costs
+func seedRoomTypes() (int, error) {
+	count, err := config.DB.Collection("room_types").CountDocuments(context.Background(), bson.M{})
+	if err != nil || count > 0 {
+		return 0, err
+	}
+
+	legacy := map[models.RoomType]models.RoomTypeCost{}
+	cursor, err := config.DB.Collection("room_type_costs").Find(context.Background(), bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	var costs []models.RoomTypeCost
+	if err := cursor.All(context.Background(), &costs); err != nil {
+		return 0, err
+	}
+	for _, cost := range costs {
+		legacy[models.RoomType(normalizeTypeCode(string(cost.RoomType)))] = cost
+	}
+
+	defaults := models.DefaultRoomTypes()
+	docs := make([]interface{}, len(defaults))
+	for i, d := range defaults {
+		if cost, ok := legacy[d.Code]; ok {
+			d.DepositAmount = cost.DepositAmount
+			d.IsActive = cost.IsActive
+			if cost.Currency != "" {
+				d.Currency = cost.Currency
+			}
+		}
+		docs[i] = d
+	}
+
+	if _, err := config.DB.Collection("room_types").InsertMany(context.Background(), docs); err != nil {
+		return 0, err
+	}
+	return len(docs), nil
+}
+
+// MigrateRoomTypes seeds room_types and rewrites the free-form room and user
+// type strings stored before room types were configurable: rooms get the
+// canonical code and users the linked user type spelling. Room types are
+// created for room values no type recognises. Safe to run more than once.
+func MigrateRoomTypes() (models.RoomTypeMigrationReport, error) {
+	report := models.RoomTypeMigrationReport{TypesCreated: []string{}, UnknownUserTypes: []string{}}
+
+	seeded, err := seedRoomTypes()
+	if err != nil {
+		return report, fmt.Errorf("seeding room types: %w", err)
+	}
+	report.TypesSeeded = seeded
+
+	types, err := loadRoomTypes(bson.M{})
+	if err != nil {
+		return report, err
+	}
+	index := indexRoomTypes(types)
+
+	roomValues, err := config.DB.Collection("rooms").Distinct(context.Background(), "type", bson.M{})
+	if err != nil {
+		return report, fmt.Errorf("reading room types: %w", err)
+	}
+	for _, v := range roomValues {
+		value, ok := v.(string)
+		if !ok || strings.TrimSpace(value) == "" {
+			continue
+		}
+
+		roomType, known := index[normalizeTypeCode(value)]
+		if !known {
+			roomType = models.RoomTypeConfig{
+				Code:          models.RoomType(normalizeTypeCode(value)),
+				DisplayName:   strings.TrimSpace(value),
+				DepositAmount: defaultRoomTypeDeposit,
+				Currency:      "INR",
+				SortOrder:     len(types) + len(report.TypesCreated) + 1,
+				IsActive:      true,
+				CreatedAt:     time.Now(),
+				UpdatedAt:     time.Now(),
+			}
+			if _, err := config.DB.Collection("room_types").InsertOne(context.Background(), roomType); err != nil {
+				return report, fmt.Errorf("creating room type %s: %w", roomType.Code, err)
+			}
+			index[string(roomType.Code)] = roomType
+			report.TypesCreated = append(report.TypesCreated, string(roomType.Code))
+		}
+
+		if value == string(roomType.Code) {
+			continue
+		}
+		result, err := config.DB.Collection("rooms").UpdateMany(
+			context.Background(),
+			bson.M{"type": value},
+			bson.M{"$set": bson.M{"type": roomType.Code, "updated_at": time.Now()}},
+		)
+		if err != nil {
+			return report, fmt.Errorf("updating rooms of type %q: %w", value, err)
+		}
+		report.RoomsUpdated += result.ModifiedCount
+	}
+
+	userValues, err := config.DB.Collection("users").Distinct(context.Background(), "user_type", bson.M{})
+	if err != nil {
+		return report, fmt.Errorf("reading user types: %w", err)
+	}
+	for _, v := range userValues {
+		value, ok := v.(string)
+		if !ok || value == "" {
+			continue
+		}
+
+		roomType, known := index[normalizeTypeCode(value)]
+		if !known || roomType.UserType == "" {
+			err := config.DB.Collection("user_type_configs").FindOne(context.Background(), bson.M{"user_type": value}).Err()
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				report.UnknownUserTypes = append(report.UnknownUserTypes, value)
+			} else if err != nil {
+				return report, err
+			}
+			continue
+		}
+		if value == roomType.UserType {
+			continue
+		}
+
+		result, err := config.DB.Collection("users").UpdateMany(
+			context.Background(),
+			bson.M{"user_type": value},
+			bson.M{"$set": bson.M{"user_type": roomType.UserType, "updated_at": time.Now()}},
+		)
+		if err != nil {
+			return report, fmt.Errorf("updating users of type %q: %w", value, err)
+		}
+		report.UsersUpdated += result.ModifiedCount
+	}
+
+	return report, nil
+}
+
+// MigrateRoomTypesHandler runs MigrateRoomTypes and returns its report
+func MigrateRoomTypesHandler(c *gin.Context) {
+	report, err := MigrateRoomTypes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Room type migration failed: " + err.Error(), "report": report})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Room type migration finished",
+		"report":  report,
+	})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"utara_backend/config"
+	"utara_backend/models"
+)
+
+// diningHallColors are given to seeded food pass categories in turn
+var diningHallColors = []string{"#E53935", "#1E88E5", "#43A047", "#FB8C00", "#8E24AA", "#00897B"}
+
+// seedFoodPassCategories adds a category for each dining hall into an empty
+// food_pass_categories collection. Without halls the buildings with rooms
+// are used.
+func seedFoodPassCategories(halls []string) (int, error) {
+	count, err := config.DB.Collection("food_pass_categories").CountDocuments(context.Background(), bson.M{})
+	if err != nil || count > 0 {
+		return 0, err
+	}
+
+	if len(halls) == 0 {
+		buildings, err := config.DB.Collection("rooms").Distinct(context.Background(), "building", bson.M{"building": bson.M{"$ne": ""}})
+		if err != nil {
+			return 0, err
+		}
+		for _, b := range buildings {
+			halls = append(halls, fmt.Sprintf("%v", b))
+		}
+		sort.Strings(halls)
+	}
+
+	var docs []interface{}
+	now := time.Now()
+	for _, hall := range halls {
+		hall = strings.TrimSpace(hall)
+		if hall == "" {
+			continue
+		}
+		docs = append(docs, models.FoodPassCategory{
+			ID:           primitive.NewObjectID(),
+			BuildingName: hall,
+			ColorCode:    diningHallColors[len(docs)%len(diningHallColors)],
+			CreatedAt:    now,
+		})
+	}
+	if len(docs) == 0 {
+		return 0, nil
+	}
+	if _, err := config.DB.Collection("food_pass_categories").InsertMany(context.Background(), docs); err != nil {
+		return 0, err
+	}
+	return len(docs), nil
+}
+
+// SeedDefaults fills the empty room_types and food_pass_categories
+// collections. Room types carry the deposits once kept in room_type_costs.
+// Collections that already have documents are left alone.
+func SeedDefaults(diningHalls []string) (roomTypes, categories int, err error) {
+	if roomTypes, err = seedRoomTypes(); err != nil {
+		return 0, 0, fmt.Errorf("error seeding room types: %w", err)
+	}
+	if categories, err = seedFoodPassCategories(diningHalls); err != nil {
+		return roomTypes, 0, fmt.Errorf("error seeding food pass categories: %w", err)
+	}
+	return roomTypes, categories, nil
+}
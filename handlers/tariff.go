@@ -0,0 +1,760 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+const (
+	quoteValidity  = 24 * time.Hour
+	maxQuoteNights = 90
+)
+
+var errNoTariff = errors.New("no tariff is set for this room type or category")
+
+// tariffScope validates the room type or category a tariff or override
+// applies to, returning the canonical room type code
+func tariffScope(roomType models.RoomType, categoryID string) (models.RoomType, error) {
+	if categoryID != "" {
+		objID, err := primitive.ObjectIDFromHex(categoryID)
+		if err != nil {
+			return "", errors.New("invalid room_category_id")
+		}
+		count, err := config.DB.Collection("room_category").CountDocuments(context.Background(), bson.M{"_id": objID})
+		if err != nil || count == 0 {
+			return "", errors.New("room category not found")
+		}
+		return "", nil
+	}
+	if roomType == "" {
+		return "", nil
+	}
+	t, err := activeRoomType(string(roomType))
+	if err != nil {
+		return "", err
+	}
+	return t.Code, nil
+}
+
+// findTariff returns the active tariff for a room category, falling back to
+// the room type's tariff
+func findTariff(roomType models.RoomType, categoryID string) (models.Tariff, error) {
+	var tariff models.Tariff
+	if categoryID != "" {
+		err := config.DB.Collection("tariffs").FindOne(context.Background(), bson.M{"room_category_id": categoryID, "is_active": true}).Decode(&tariff)
+		if err == nil {
+			return tariff, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			return tariff, err
+		}
+	}
+	if roomType != "" {
+		err := config.DB.Collection("tariffs").FindOne(context.Background(), bson.M{
+			"room_type":        roomType,
+			"room_category_id": bson.M{"$exists": false},
+			"is_active":        true,
+		}).Decode(&tariff)
+		if err == nil {
+			return tariff, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			return tariff, err
+		}
+	}
+	return tariff, errNoTariff
+}
+
+// overrideRank orders overrides that cover the same night: festivals beat
+// seasons, then category overrides beat room type overrides beat global ones
+func overrideRank(o models.TariffOverride) int {
+	rank := 0
+	if o.Kind == models.OverrideFestival {
+		rank += 10
+	}
+	switch {
+	case o.RoomCategoryID != "":
+		rank += 2
+	case o.RoomType != "":
+		rank++
+	}
+	return rank
+}
+
+// loadTariffOverrides returns active overrides for the stay that apply to the
+// room type or category
+func loadTariffOverrides(roomType models.RoomType, categoryID string, firstNight, lastNight time.Time) ([]models.TariffOverride, error) {
+	scope := []bson.M{
+		{"room_type": bson.M{"$exists": false}, "room_category_id": bson.M{"$exists": false}},
+	}
+	if roomType != "" {
+		scope = append(scope, bson.M{"room_type": roomType, "room_category_id": bson.M{"$exists": false}})
+	}
+	if categoryID != "" {
+		scope = append(scope, bson.M{"room_category_id": categoryID})
+	}
+
+	cursor, err := config.DB.Collection("tariff_overrides").Find(context.Background(), bson.M{
+		"is_active":  true,
+		"start_date": bson.M{"$lte": lastNight},
+		"end_date":   bson.M{"$gte": firstNight},
+		"$or":        scope,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var overrides []models.TariffOverride
+	err = cursor.All(context.Background(), &overrides)
+	return overrides, err
+}
+
+// nightlyRate applies the best matching override to the base rate
+func nightlyRate(base int, night time.Time, overrides []models.TariffOverride) (int, *models.TariffOverride) {
+	var best *models.TariffOverride
+	for i := range overrides {
+		o := &overrides[i]
+		if night.Before(o.StartDate) || night.After(o.EndDate) {
+			continue
+		}
+		if best == nil || overrideRank(*o) > overrideRank(*best) ||
+			(overrideRank(*o) == overrideRank(*best) && o.StartDate.After(best.StartDate)) {
+			best = o
+		}
+	}
+	if best == nil {
+		return base, nil
+	}
+	if best.NightlyRate > 0 {
+		return best.NightlyRate, best
+	}
+	return base * (100 + best.AdjustmentPercent) / 100, best
+}
+
+// quoteNights returns the first and last night of a stay in IST
+func quoteNights(checkIn, checkOut time.Time) (time.Time, time.Time, error) {
+	firstNight := utils.StartOfDayIST(checkIn)
+	lastNight := utils.StartOfDayIST(checkOut).AddDate(0, 0, -1)
+	if lastNight.Before(firstNight) {
+		return firstNight, lastNight, errors.New("check_out_date must be after check_in_date")
+	}
+	return firstNight, lastNight, nil
+}
+
+// buildQuote loads the tariff, overrides and user type terms for a stay and
+// prices it
+func buildQuote(quote *models.Quote, userType string) error {
+	tariff, err := findTariff(quote.RoomType, quote.RoomCategoryID)
+	if err != nil {
+		return err
+	}
+	firstNight, lastNight, err := quoteNights(quote.CheckInDate, quote.CheckOutDate)
+	if err != nil {
+		return err
+	}
+	overrides, err := loadTariffOverrides(quote.RoomType, quote.RoomCategoryID, firstNight, lastNight)
+	if err != nil {
+		return err
+	}
+	var terms userTypeTerms
+	if userType != "" {
+		if terms, err = loadUserTypeTerms(userType); err != nil {
+			return err
+		}
+	}
+	return priceQuote(quote, tariff, overrides, userType, terms)
+}
+
+// priceQuote prices a stay: a line per night at the tariff or override rate,
+// extra guest charges above the base occupancy, then the user type discount
+func priceQuote(quote *models.Quote, tariff models.Tariff, overrides []models.TariffOverride, userType string, terms userTypeTerms) error {
+	firstNight, lastNight, err := quoteNights(quote.CheckInDate, quote.CheckOutDate)
+	if err != nil {
+		return err
+	}
+
+	quote.TariffID = tariff.ID
+	quote.Currency = tariff.Currency
+	quote.Lines = nil
+	quote.Nights = 0
+	subtotal := 0
+
+	for night := firstNight; !night.After(lastNight); night = night.AddDate(0, 0, 1) {
+		quote.Nights++
+		if quote.Nights > maxQuoteNights {
+			return fmt.Errorf("stays longer than %d nights can't be quoted", maxQuoteNights)
+		}
+		rate, override := nightlyRate(tariff.NightlyRate, night, overrides)
+		description := "Room charge, night of " + night.Format("02 Jan 2006")
+		if override != nil {
+			description += " (" + override.Name + ")"
+		}
+		date := night
+		quote.Lines = append(quote.Lines, models.QuoteLine{
+			Kind:        models.QuoteLineNight,
+			Description: description,
+			Date:        &date,
+			Quantity:    1,
+			UnitAmount:  rate,
+			Amount:      rate,
+		})
+		subtotal += rate
+	}
+
+	// Adults fill the base occupancy first, then children
+	adults := quote.NumberOfPeople.Male + quote.NumberOfPeople.Female
+	children := quote.NumberOfPeople.Children
+	extraAdults := max(0, adults-tariff.BaseOccupancy)
+	extraChildren := max(0, children-max(0, tariff.BaseOccupancy-adults))
+
+	if extraAdults > 0 && tariff.ExtraAdultRate > 0 {
+		line := models.QuoteLine{
+			Kind:        models.QuoteLineExtraAdult,
+			Description: fmt.Sprintf("Extra adult x %d for %d nights", extraAdults, quote.Nights),
+			Quantity:    extraAdults * quote.Nights,
+			UnitAmount:  tariff.ExtraAdultRate,
+		}
+		line.Amount = line.Quantity * line.UnitAmount
+		quote.Lines = append(quote.Lines, line)
+		subtotal += line.Amount
+	}
+	if extraChildren > 0 && tariff.ExtraChildRate > 0 {
+		line := models.QuoteLine{
+			Kind:        models.QuoteLineExtraChild,
+			Description: fmt.Sprintf("Extra child x %d for %d nights", extraChildren, quote.Nights),
+			Quantity:    extraChildren * quote.Nights,
+			UnitAmount:  tariff.ExtraChildRate,
+		}
+		line.Amount = line.Quantity * line.UnitAmount
+		quote.Lines = append(quote.Lines, line)
+		subtotal += line.Amount
+	}
+
+	quote.Subtotal = subtotal
+	quote.UserType = userType
+	quote.Discount = 0
+	quote.DiscountPercent = 0
+	quote.IsFOC = false
+
+	if terms.isFOC {
+		quote.IsFOC = true
+		quote.DiscountPercent = 100
+	} else if terms.config != nil {
+		quote.DiscountPercent = min(max(terms.config.DiscountPercent, 0), 100)
+	}
+	if quote.DiscountPercent > 0 && subtotal > 0 {
+		quote.Discount = subtotal * quote.DiscountPercent / 100
+		description := fmt.Sprintf("%s discount (%d%%)", userType, quote.DiscountPercent)
+		if quote.IsFOC {
+			description = "Free of cost (" + userType + ")"
+		}
+		quote.Lines = append(quote.Lines, models.QuoteLine{
+			Kind:        models.QuoteLineDiscount,
+			Description: description,
+			Quantity:    1,
+			UnitAmount:  -quote.Discount,
+			Amount:      -quote.Discount,
+		})
+	}
+
+	quote.Total = subtotal - quote.Discount
+	return nil
+}
+
+// CreateQuote returns an itemized price for a stay. The quote is stored and
+// can be paid through CreatePayment with its ID.
+func CreateQuote(c *gin.Context) {
+	var req models.QuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+	isStaff := user.Role == models.RoleSuperAdmin || user.Role == models.RoleStaff
+
+	quote := models.Quote{
+		ID:             primitive.NewObjectID(),
+		UserID:         user.ID,
+		RoomCategoryID: req.RoomCategoryID,
+		CheckInDate:    req.CheckInDate,
+		CheckOutDate:   req.CheckOutDate,
+		NumberOfPeople: req.NumberOfPeople,
+		Status:         models.QuoteOpen,
+		CreatedAt:      time.Now(),
+		ExpiresAt:      time.Now().Add(quoteValidity),
+	}
+	if req.UserID != nil && *req.UserID != user.ID {
+		if !isStaff {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only staff can quote for another user"})
+			return
+		}
+		quote.UserID = *req.UserID
+	}
+
+	// Dates, guests and the assigned room come from the room request if given
+	if req.RequestID != nil {
+		var request models.RoomRequest
+		err := config.DB.Collection("room_requests").FindOne(context.Background(), bson.M{"_id": *req.RequestID}).Decode(&request)
+		if err != nil || (!isStaff && request.UserID != user.ID) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room request not found"})
+			return
+		}
+		quote.RequestID = &request.ID
+		quote.UserID = request.UserID
+		quote.CheckInDate = request.CheckInDate
+		quote.CheckOutDate = request.CheckOutDate
+		quote.NumberOfPeople = request.NumberOfPeople
+
+		// Guests always pay for the room they were given. Staff may quote
+		// another room, and get the assigned one by naming none.
+		if !isStaff || (req.RoomType == "" && req.RoomCategoryID == "") {
+			var assignment models.RoomAssignment
+			err := config.DB.Collection("room_assignments").FindOne(
+				context.Background(),
+				bson.M{"request_id": request.ID},
+				options.FindOne().SetSort(bson.D{{Key: "assigned_at", Value: -1}}),
+			).Decode(&assignment)
+			if err != nil && err != mongo.ErrNoDocuments {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching room assignment"})
+				return
+			}
+			if err == nil {
+				var room models.Room
+				if err := config.DB.Collection("rooms").FindOne(context.Background(), bson.M{"_id": assignment.RoomID}).Decode(&room); err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching assigned room"})
+					return
+				}
+				req.RoomType = string(room.Type)
+				quote.RoomCategoryID = room.RoomCategoryId
+			}
+		}
+	}
+
+	if req.RoomType == "" && quote.RoomCategoryID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "room_type or room_category_id is required"})
+		return
+	}
+	if req.RoomType != "" {
+		roomType, err := resolveRoomType(req.RoomType)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown room type %q", req.RoomType)})
+			return
+		}
+		quote.RoomType = roomType.Code
+	}
+	if quote.CheckInDate.IsZero() || quote.CheckOutDate.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "check_in_date and check_out_date are required"})
+		return
+	}
+	quote.NumberOfPeople.Total = quote.NumberOfPeople.Male + quote.NumberOfPeople.Female + quote.NumberOfPeople.Children
+
+	var quotedUser models.User
+	if err := config.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": quote.UserID}).Decode(&quotedUser); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := buildQuote(&quote, quotedUser.UserType); err != nil {
+		if errors.Is(err, errNoTariff) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := config.DB.Collection("quotes").InsertOne(context.Background(), quote); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save quote"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, quote)
+}
+
+// GetQuote returns a stored quote to its user or staff
+func GetQuote(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quote ID"})
+		return
+	}
+	user, err := getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	var quote models.Quote
+	err = config.DB.Collection("quotes").FindOne(context.Background(), bson.M{"_id": id}).Decode(&quote)
+	if err != nil || (quote.UserID != user.ID && user.Role != models.RoleSuperAdmin && user.Role != models.RoleStaff) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quote not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// loadPayableQuote returns a quote the user can pay: theirs, unexpired,
+// unpaid and with something to charge
+func loadPayableQuote(quoteID string, userID primitive.ObjectID) (models.Quote, error) {
+	var quote models.Quote
+	id, err := primitive.ObjectIDFromHex(quoteID)
+	if err != nil {
+		return quote, errors.New("invalid quote_id")
+	}
+	err = config.DB.Collection("quotes").FindOne(context.Background(), bson.M{"_id": id, "user_id": userID}).Decode(&quote)
+	if err != nil {
+		return quote, errors.New("quote not found")
+	}
+	switch {
+	case quote.Status == models.QuotePaid:
+		return quote, errors.New("quote is already paid")
+	case time.Now().After(quote.ExpiresAt):
+		return quote, errors.New("quote has expired, request a new one")
+	case quote.Total <= 0:
+		return quote, errors.New("quote is free of cost, no payment is needed")
+	}
+	return quote, nil
+}
+
+// markQuotePaid closes the quote charged by a verified payment
+func markQuotePaid(payment models.Payment) {
+	if payment.QuoteID == nil {
+		return
+	}
+	config.DB.Collection("quotes").UpdateOne(
+		context.Background(),
+		bson.M{"_id": *payment.QuoteID},
+		bson.M{"$set": bson.M{"status": models.QuotePaid, "payment_id": payment.ID}},
+	)
+}
+
+// GetTariffs lists tariffs
+func GetTariffs(c *gin.Context) {
+	filter := bson.M{}
+	if active := c.Query("active"); active != "" {
+		filter["is_active"] = active == "true"
+	}
+	cursor, err := config.DB.Collection("tariffs").Find(
+		context.Background(),
+		filter,
+		options.Find().SetSort(bson.D{{Key: "room_type", Value: 1}, {Key: "name", Value: 1}}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tariffs"})
+		return
+	}
+	tariffs := []models.Tariff{}
+	if err := cursor.All(context.Background(), &tariffs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode tariffs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tariffs": tariffs, "count": len(tariffs)})
+}
+
+// validateTariff checks a tariff request and returns the tariff it describes
+func validateTariff(req models.TariffRequest) (models.Tariff, error) {
+	if (req.RoomType == "") == (req.RoomCategoryID == "") {
+		return models.Tariff{}, errors.New("set exactly one of room_type or room_category_id")
+	}
+	if req.NightlyRate <= 0 || req.BaseOccupancy < 0 || req.ExtraAdultRate < 0 || req.ExtraChildRate < 0 {
+		return models.Tariff{}, errors.New("nightly_rate must be positive and other amounts can't be negative")
+	}
+	roomType, err := tariffScope(req.RoomType, req.RoomCategoryID)
+	if err != nil {
+		return models.Tariff{}, err
+	}
+
+	tariff := models.Tariff{
+		Name:           req.Name,
+		RoomType:       roomType,
+		RoomCategoryID: req.RoomCategoryID,
+		NightlyRate:    req.NightlyRate,
+		BaseOccupancy:  req.BaseOccupancy,
+		ExtraAdultRate: req.ExtraAdultRate,
+		ExtraChildRate: req.ExtraChildRate,
+		Currency:       req.Currency,
+		IsActive:       req.IsActive == nil || *req.IsActive,
+	}
+	if tariff.Currency == "" {
+		tariff.Currency = "INR"
+	}
+	return tariff, nil
+}
+
+// activeTariffExists reports whether another active tariff covers the same scope
+func activeTariffExists(tariff models.Tariff, exclude primitive.ObjectID) (bool, error) {
+	filter := bson.M{"is_active": true, "_id": bson.M{"$ne": exclude}}
+	if tariff.RoomCategoryID != "" {
+		filter["room_category_id"] = tariff.RoomCategoryID
+	} else {
+		filter["room_type"] = tariff.RoomType
+		filter["room_category_id"] = bson.M{"$exists": false}
+	}
+	count, err := config.DB.Collection("tariffs").CountDocuments(context.Background(), filter)
+	return count > 0, err
+}
+
+// CreateTariff adds a nightly rate for a room type or category
+func CreateTariff(c *gin.Context) {
+	var req models.TariffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tariff, err := validateTariff(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if tariff.IsActive {
+		if exists, err := activeTariffExists(tariff, primitive.NilObjectID); err != nil || exists {
+			c.JSON(http.StatusConflict, gin.H{"error": "An active tariff already exists for this room type or category"})
+			return
+		}
+	}
+
+	tariff.CreatedAt = time.Now()
+	tariff.UpdatedAt = time.Now()
+	result, err := config.DB.Collection("tariffs").InsertOne(context.Background(), tariff)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tariff"})
+		return
+	}
+	tariff.ID = result.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusCreated, tariff)
+}
+
+// UpdateTariff replaces a tariff
+func UpdateTariff(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var req models.TariffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tariff, err := validateTariff(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if tariff.IsActive {
+		if exists, err := activeTariffExists(tariff, id); err != nil || exists {
+			c.JSON(http.StatusConflict, gin.H{"error": "An active tariff already exists for this room type or category"})
+			return
+		}
+	}
+
+	var current models.Tariff
+	if err := config.DB.Collection("tariffs").FindOne(context.Background(), bson.M{"_id": id}).Decode(&current); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tariff not found"})
+		return
+	}
+	tariff.ID = id
+	tariff.CreatedAt = current.CreatedAt
+	tariff.UpdatedAt = time.Now()
+
+	if _, err := config.DB.Collection("tariffs").ReplaceOne(context.Background(), bson.M{"_id": id}, tariff); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tariff"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tariff)
+}
+
+// DeleteTariff removes a tariff
+func DeleteTariff(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	result, err := config.DB.Collection("tariffs").DeleteOne(context.Background(), bson.M{"_id": id})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tariff"})
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tariff not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tariff deleted successfully"})
+}
+
+// GetTariffOverrides lists seasonal and festival overrides, optionally only
+// those covering ?from= to ?to= (YYYY-MM-DD)
+func GetTariffOverrides(c *gin.Context) {
+	filter := bson.M{}
+	if from := c.Query("from"); from != "" {
+		t, err := time.ParseInLocation("2006-01-02", from, utils.IST)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, use YYYY-MM-DD"})
+			return
+		}
+		filter["end_date"] = bson.M{"$gte": t}
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.ParseInLocation("2006-01-02", to, utils.IST)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, use YYYY-MM-DD"})
+			return
+		}
+		filter["start_date"] = bson.M{"$lte": t}
+	}
+
+	cursor, err := config.DB.Collection("tariff_overrides").Find(
+		context.Background(),
+		filter,
+		options.Find().SetSort(bson.D{{Key: "start_date", Value: 1}}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tariff overrides"})
+		return
+	}
+	overrides := []models.TariffOverride{}
+	if err := cursor.All(context.Background(), &overrides); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode tariff overrides"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"overrides": overrides, "count": len(overrides)})
+}
+
+// validateTariffOverride checks an override request and returns the override
+// it describes, with its dates as IST nights
+func validateTariffOverride(req models.TariffOverrideRequest) (models.TariffOverride, error) {
+	if req.Kind != models.OverrideSeason && req.Kind != models.OverrideFestival {
+		return models.TariffOverride{}, errors.New("kind must be SEASON or FESTIVAL")
+	}
+	start := utils.StartOfDayIST(req.StartDate)
+	end := utils.StartOfDayIST(req.EndDate)
+	if end.Before(start) {
+		return models.TariffOverride{}, errors.New("end_date can't be before start_date")
+	}
+	if (req.NightlyRate > 0) == (req.AdjustmentPercent != 0) {
+		return models.TariffOverride{}, errors.New("set either nightly_rate or adjustment_percent")
+	}
+	if req.NightlyRate < 0 || req.AdjustmentPercent <= -100 {
+		return models.TariffOverride{}, errors.New("override can't make the rate zero or negative")
+	}
+	roomType, err := tariffScope(req.RoomType, req.RoomCategoryID)
+	if err != nil {
+		return models.TariffOverride{}, err
+	}
+
+	return models.TariffOverride{
+		Name:              req.Name,
+		Kind:              req.Kind,
+		StartDate:         start,
+		EndDate:           end,
+		RoomType:          roomType,
+		RoomCategoryID:    req.RoomCategoryID,
+		NightlyRate:       req.NightlyRate,
+		AdjustmentPercent: req.AdjustmentPercent,
+		IsActive:          req.IsActive == nil || *req.IsActive,
+	}, nil
+}
+
+// CreateTariffOverride adds a seasonal or festival rate
+func CreateTariffOverride(c *gin.Context) {
+	var req models.TariffOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	override, err := validateTariffOverride(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	override.CreatedAt = time.Now()
+	override.UpdatedAt = time.Now()
+	result, err := config.DB.Collection("tariff_overrides").InsertOne(context.Background(), override)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tariff override"})
+		return
+	}
+	override.ID = result.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusCreated, override)
+}
+
+// UpdateTariffOverride replaces a seasonal or festival rate
+func UpdateTariffOverride(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var req models.TariffOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	override, err := validateTariffOverride(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var current models.TariffOverride
+	if err := config.DB.Collection("tariff_overrides").FindOne(context.Background(), bson.M{"_id": id}).Decode(&current); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tariff override not found"})
+		return
+	}
+	override.ID = id
+	override.CreatedAt = current.CreatedAt
+	override.UpdatedAt = time.Now()
+
+	if _, err := config.DB.Collection("tariff_overrides").ReplaceOne(context.Background(), bson.M{"_id": id}, override); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tariff override"})
+		return
+	}
+
+	c.JSON(http.StatusOK, override)
+}
+
+// DeleteTariffOverride removes a seasonal or festival rate
+func DeleteTariffOverride(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	result, err := config.DB.Collection("tariff_overrides").DeleteOne(context.Background(), bson.M{"_id": id})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tariff override"})
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tariff override not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tariff override deleted successfully"})
+}
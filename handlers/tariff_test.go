@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+func TestPriceQuote(t *testing.T) {
+	day := func(d, hour int) time.Time { return time.Date(2026, 3, d, hour, 0, 0, 0, utils.IST) }
+	tariff := models.Tariff{NightlyRate: 1000, BaseOccupancy: 2, ExtraAdultRate: 300, ExtraChildRate: 100}
+	couple := models.PeopleCount{Male: 1, Female: 1}
+
+	tests := []struct {
+		name      string
+		checkIn   time.Time
+		checkOut  time.Time
+		people    models.PeopleCount
+		overrides []models.TariffOverride
+		terms     userTypeTerms
+		nights    int
+		rates     []int
+		subtotal  int
+		discount  int
+		total     int
+		wantErr   bool
+	}{
+		{
+			name:     "nights counted by IST date",
+			checkIn:  day(10, 14),
+			checkOut: day(13, 10),
+			people:   couple,
+			nights:   3,
+			rates:    []int{1000, 1000, 1000},
+			subtotal: 3000,
+			total:    3000,
+		},
+		{
+			name:     "check-in just after IST midnight is that day's night",
+			checkIn:  time.Date(2026, 3, 9, 20, 0, 0, 0, time.UTC),
+			checkOut: day(11, 10),
+			people:   couple,
+			nights:   1,
+			rates:    []int{1000},
+			subtotal: 1000,
+			total:    1000,
+		},
+		{
+			name:     "same day check-out",
+			checkIn:  day(10, 9),
+			checkOut: day(10, 18),
+			people:   couple,
+			wantErr:  true,
+		},
+		{
+			name:     "too many nights",
+			checkIn:  day(1, 14),
+			checkOut: day(1, 10).AddDate(0, 0, maxQuoteNights+1),
+			people:   couple,
+			wantErr:  true,
+		},
+		{
+			name:     "festival beats season",
+			checkIn:  day(10, 14),
+			checkOut: day(13, 10),
+			people:   couple,
+			overrides: []models.TariffOverride{
+				{Name: "Spring", Kind: models.OverrideSeason, StartDate: day(10, 0), EndDate: day(11, 0), AdjustmentPercent: 20},
+				{Name: "Holi", Kind: models.OverrideFestival, StartDate: day(11, 0), EndDate: day(11, 0), NightlyRate: 2500},
+			},
+			nights:   3,
+			rates:    []int{1200, 2500, 1000},
+			subtotal: 4700,
+			total:    4700,
+		},
+		{
+			name:     "category beats room type",
+			checkIn:  day(10, 14),
+			checkOut: day(11, 10),
+			people:   couple,
+			overrides: []models.TariffOverride{
+				{Name: "Category", Kind: models.OverrideSeason, StartDate: day(10, 0), EndDate: day(10, 0), RoomCategoryID: "c1", NightlyRate: 1800},
+				{Name: "Type", Kind: models.OverrideSeason, StartDate: day(10, 0), EndDate: day(10, 0), RoomType: "SARJU", NightlyRate: 1500},
+			},
+			nights:   1,
+			rates:    []int{1800},
+			subtotal: 1800,
+			total:    1800,
+		},
+		{
+			name:     "later start wins a tie",
+			checkIn:  day(10, 14),
+			checkOut: day(11, 10),
+			people:   couple,
+			overrides: []models.TariffOverride{
+				{Name: "Late", Kind: models.OverrideSeason, StartDate: day(5, 0), EndDate: day(20, 0), AdjustmentPercent: -10},
+				{Name: "Early", Kind: models.OverrideSeason, StartDate: day(1, 0), EndDate: day(20, 0), AdjustmentPercent: 50},
+			},
+			nights:   1,
+			rates:    []int{900},
+			subtotal: 900,
+			total:    900,
+		},
+		{
+			name:     "extra adults and children",
+			checkIn:  day(10, 14),
+			checkOut: day(12, 10),
+			people:   models.PeopleCount{Male: 2, Female: 1, Children: 2},
+			nights:   2,
+			rates:    []int{1000, 1000},
+			subtotal: 2000 + 1*2*300 + 2*2*100,
+			total:    3000,
+		},
+		{
+			name:     "children fill the remaining base occupancy",
+			checkIn:  day(10, 14),
+			checkOut: day(12, 10),
+			people:   models.PeopleCount{Female: 1, Children: 2},
+			nights:   2,
+			rates:    []int{1000, 1000},
+			subtotal: 2000 + 1*2*100,
+			total:    2200,
+		},
+		{
+			name:     "user type discount",
+			checkIn:  day(10, 14),
+			checkOut: day(12, 10),
+			people:   couple,
+			terms:    userTypeTerms{config: &models.UserTypeConfig{DiscountPercent: 10}},
+			nights:   2,
+			rates:    []int{1000, 1000},
+			subtotal: 2000,
+			discount: 200,
+			total:    1800,
+		},
+		{
+			name:     "discount capped at 100 percent",
+			checkIn:  day(10, 14),
+			checkOut: day(11, 10),
+			people:   couple,
+			terms:    userTypeTerms{config: &models.UserTypeConfig{DiscountPercent: 150}},
+			nights:   1,
+			rates:    []int{1000},
+			subtotal: 1000,
+			discount: 1000,
+			total:    0,
+		},
+		{
+			name:     "free of cost",
+			checkIn:  day(10, 14),
+			checkOut: day(12, 10),
+			people:   models.PeopleCount{Male: 3},
+			terms:    userTypeTerms{config: &models.UserTypeConfig{DiscountPercent: 10}, isFOC: true},
+			nights:   2,
+			rates:    []int{1000, 1000},
+			subtotal: 2600,
+			discount: 2600,
+			total:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote := &models.Quote{CheckInDate: tt.checkIn, CheckOutDate: tt.checkOut, NumberOfPeople: tt.people}
+			err := priceQuote(quote, tariff, tt.overrides, "SADHAK", tt.terms)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if quote.Nights != tt.nights {
+				t.Errorf("nights = %d, want %d", quote.Nights, tt.nights)
+			}
+			var rates []int
+			for _, line := range quote.Lines {
+				if line.Kind == models.QuoteLineNight {
+					rates = append(rates, line.Amount)
+				}
+			}
+			if len(rates) != len(tt.rates) {
+				t.Fatalf("night rates = %v, want %v", rates, tt.rates)
+			}
+			for i := range rates {
+				if rates[i] != tt.rates[i] {
+					t.Errorf("night rates = %v, want %v", rates, tt.rates)
+					break
+				}
+			}
+			if quote.Subtotal != tt.subtotal || quote.Discount != tt.discount || quote.Total != tt.total {
+				t.Errorf("subtotal, discount, total = %d, %d, %d, want %d, %d, %d",
+					quote.Subtotal, quote.Discount, quote.Total, tt.subtotal, tt.discount, tt.total)
+			}
+			if quote.IsFOC != tt.terms.isFOC {
+				t.Errorf("IsFOC = %v", quote.IsFOC)
+			}
+		})
+	}
+}
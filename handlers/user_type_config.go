@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"utara_backend/config"
@@ -61,16 +63,21 @@ func GetUserTypeConfig(c *gin.Context) {
 // CreateUserTypeConfig creates a new user type configuration
 func CreateUserTypeConfig(c *gin.Context) {
 	var req struct {
-		UserType      string `json:"user_type" binding:"required"`
-		DepositAmount int    `json:"deposit_amount"`
-		IsFOC         bool   `json:"is_foc"`
-		Description   string `json:"description"`
+		UserType        string `json:"user_type" binding:"required"`
+		DepositAmount   int    `json:"deposit_amount"`
+		IsFOC           bool   `json:"is_foc"`
+		DiscountPercent int    `json:"discount_percent"`
+		Description     string `json:"description"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.DiscountPercent < 0 || req.DiscountPercent > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "discount_percent must be between 0 and 100"})
+		return
+	}
 
 	// Check if user type already exists
 	var existing models.UserTypeConfig
@@ -85,12 +92,13 @@ func CreateUserTypeConfig(c *gin.Context) {
 	}
 
 	newConfig := models.UserTypeConfig{
-		UserType:      req.UserType,
-		DepositAmount: req.DepositAmount,
-		IsFOC:         req.IsFOC,
-		Description:   req.Description,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+		UserType:        req.UserType,
+		DepositAmount:   req.DepositAmount,
+		IsFOC:           req.IsFOC,
+		DiscountPercent: req.DiscountPercent,
+		Description:     req.Description,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
 	}
 
 	result, err := config.DB.Collection("user_type_configs").InsertOne(context.Background(), newConfig)
@@ -112,9 +120,10 @@ func UpdateUserTypeConfig(c *gin.Context) {
 	}
 
 	var req struct {
-		DepositAmount *int    `json:"deposit_amount,omitempty"`
-		IsFOC         *bool   `json:"is_foc,omitempty"`
-		Description   *string `json:"description,omitempty"`
+		DepositAmount   *int    `json:"deposit_amount,omitempty"`
+		IsFOC           *bool   `json:"is_foc,omitempty"`
+		DiscountPercent *int    `json:"discount_percent,omitempty"`
+		Description     *string `json:"description,omitempty"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -134,6 +143,13 @@ func UpdateUserTypeConfig(c *gin.Context) {
 	if req.IsFOC != nil {
 		update["$set"].(bson.M)["is_foc"] = *req.IsFOC
 	}
+	if req.DiscountPercent != nil {
+		if *req.DiscountPercent < 0 || *req.DiscountPercent > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "discount_percent must be between 0 and 100"})
+			return
+		}
+		update["$set"].(bson.M)["discount_percent"] = *req.DiscountPercent
+	}
 	if req.Description != nil {
 		update["$set"].(bson.M)["description"] = *req.Description
 	}
@@ -176,29 +192,75 @@ func DeleteUserTypeConfig(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User type config deleted successfully"})
 }
 
-// GetDepositForUser returns the deposit amount required for a user
-func GetDepositForUser(userType string) (amount int, isFOC bool) {
+// userTypeTerms are what a user type pays, from its user type config and
+// the room type it is linked to
+type userTypeTerms struct {
+	config   *models.UserTypeConfig
+	roomType *models.RoomTypeConfig
+	isFOC    bool
+}
+
+// loadUserTypeTerms finds the terms of a user type. Quotes and deposits both
+// decide free of cost here: the config's is_foc when the user type has a
+// config, else the plus tier of its room type, else "plus" in its name.
+func loadUserTypeTerms(userType string) (userTypeTerms, error) {
+	var terms userTypeTerms
 	var userTypeConfig models.UserTypeConfig
 	err := config.DB.Collection("user_type_configs").FindOne(
 		context.Background(),
 		bson.M{"user_type": userType},
 	).Decode(&userTypeConfig)
+	if err == nil {
+		terms.config = &userTypeConfig
+		terms.isFOC = userTypeConfig.IsFOC
+		return terms, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return terms, err
+	}
 
+	roomType, err := resolveRoomType(userType)
 	if err == nil {
-		if userTypeConfig.IsFOC {
-			return 0, true
-		}
-		return userTypeConfig.DepositAmount, false
+		terms.roomType = &roomType
+		terms.isFOC = roomType.IsPlus
+		return terms, nil
 	}
+	if !errors.Is(err, errRoomTypeNotFound) {
+		return terms, err
+	}
+
+	terms.isFOC = strings.Contains(strings.ToLower(userType), "plus")
+	return terms, nil
+}
 
-	// Default fallback: Plus type customers get free of cost
-	if strings.Contains(strings.ToLower(userType), "plus") {
+// GetDepositForUser returns the deposit amount required for a user
+func GetDepositForUser(userType string) (amount int, isFOC bool) {
+	terms, err := loadUserTypeTerms(userType)
+	switch {
+	case err != nil:
+		return defaultRoomTypeDeposit, false
+	case terms.isFOC:
 		return 0, true
+	case terms.config != nil:
+		return terms.config.DepositAmount, false
+	case terms.roomType != nil:
+		return terms.roomType.DepositAmount, false
 	}
+	return defaultRoomTypeDeposit, false
+}
 
-	// Otherwise, fallback to the deposit amount defined for the equivalent Room Type
-	amount = GetDepositAmountForRoomType(models.RoomType(userType))
-	return amount, false
+// canonicalUserType checks a user type against the room types' linked user
+// types and the user type configs, returning the stored spelling
+func canonicalUserType(userType string) (string, bool) {
+	if roomType, err := resolveRoomType(userType); err == nil && roomType.UserType != "" && roomType.IsActive {
+		return roomType.UserType, true
+	}
+
+	err := config.DB.Collection("user_type_configs").FindOne(
+		context.Background(),
+		bson.M{"user_type": userType},
+	).Err()
+	return userType, err == nil
 }
 
 // CheckUserRequiresDeposit checks if a user needs to pay deposit based on their user type
@@ -1,35 +1,101 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
 
 	"utara_backend/config"
+	"utara_backend/events"
 	"utara_backend/handlers"
+	"utara_backend/middleware"
+	"utara_backend/migrations"
+	"utara_backend/notify"
 	"utara_backend/routes"
+	"utara_backend/scheduler"
+	"utara_backend/storage"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
 func main() {
-	handlers.StartAutomaticCleanup()
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found")
 	}
 
+	// The first argument picks the subcommand, serve when there is none
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" || name == "-h" || name == "--help" {
+		usage(os.Stdout)
+		return
+	}
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
 	// Connect to MongoDB
 	config.ConnectDB()
 
+	os.Exit(cmd.run(args))
+}
+
+// startServices configures the storage, notification and event backends
+// shared by the server and the jobs
+func startServices() {
+	// Configure file storage for uploads
+	if err := storage.Init(); err != nil {
+		log.Fatal("Failed to configure storage:", err)
+	}
+
+	// Configure notification channels
+	if err := notify.Init(); err != nil {
+		log.Fatal("Failed to configure notifications:", err)
+	}
+
+	// Configure the event bus behind the live event stream
+	if err := events.Init(); err != nil {
+		log.Fatal("Failed to configure events:", err)
+	}
+}
+
+// serve runs the HTTP server with the background workers and jobs
+func serve(args []string) int {
+	// Migrations are applied with the migrate command, not on start
+	if pending, err := migrations.Pending(context.Background()); err != nil {
+		log.Println("Error checking migrations:", err)
+	} else if pending > 0 {
+		log.Printf("%d database migrations are pending, run migrate to apply them", pending)
+	}
+
+	startServices()
+	handlers.StartOutbox()
+
+	// Start background jobs
+	handlers.RegisterJobs()
+	if err := scheduler.Start(); err != nil {
+		log.Fatal("Failed to start scheduler:", err)
+	}
+
 	// Initialize Gin
 	r := gin.Default()
 
 	// ✅ Serve static files from ./static folder (place login.html & index.html here)
 	r.Static("/static", "./static")
 
-	// ✅ Serve uploaded files (chitthi images, etc.)
-	r.Static("/uploads", "./uploads")
+	// ✅ Serve uploaded media. Migration 8 moves old chitthi files out of
+	// uploads/chitthi; until then they are only served through
+	// GET /room-requests/:id/chitthi.
+	uploads := r.Group("/uploads", middleware.DenyPathPrefix("/uploads/chitthi/"))
+	uploads.Static("/", "./uploads")
 
 	// ✅ Map clean routes for your pages
 	r.GET("/", func(c *gin.Context) {
@@ -54,4 +120,5 @@ func main() {
 	if err := r.Run(":" + port); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
+	return 0
 }
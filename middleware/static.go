@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DenyPathPrefix answers 404 for requests under prefix, hiding files that
+// sit inside a statically served directory. The path is cleaned first, the
+// same way the file server does, so "//", "." and ".." can't get around it.
+func DenyPathPrefix(prefix string) gin.HandlerFunc {
+	prefix = strings.TrimSuffix(path.Clean(prefix), "/")
+	return func(c *gin.Context) {
+		p := path.Clean("/" + c.Request.URL.Path)
+		if p == prefix || strings.HasPrefix(p, prefix+"/") {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		c.Next()
+	}
+}
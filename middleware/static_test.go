@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveUploads serves a temporary uploads directory the way main.go does,
+// with one public file and one chitthi
+func serveUploads(t *testing.T) *gin.Engine {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "chitthi"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "room.jpg"), []byte("room"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "chitthi", "x.jpg"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	uploads := r.Group("/uploads", DenyPathPrefix("/uploads/chitthi/"))
+	uploads.Static("/", dir)
+	return r
+}
+
+func TestDenyPathPrefix(t *testing.T) {
+	r := serveUploads(t)
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/uploads/room.jpg", http.StatusOK},
+		{"/uploads/chitthi/x.jpg", http.StatusNotFound},
+		{"/uploads/chitthi", http.StatusNotFound},
+		{"/uploads//chitthi/x.jpg", http.StatusNotFound},
+		{"/uploads/./chitthi/x.jpg", http.StatusNotFound},
+		{"/uploads/a/../chitthi/x.jpg", http.StatusNotFound},
+		{"/uploads/chitthi/../chitthi/x.jpg", http.StatusNotFound},
+		{"/uploads/%63hitthi/x.jpg", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("GET %s = %d, want %d", tt.path, w.Code, tt.want)
+		}
+		if tt.want == http.StatusNotFound && w.Body.String() == "secret" {
+			t.Errorf("GET %s served the chitthi", tt.path)
+		}
+	}
+}
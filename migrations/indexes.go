@@ -0,0 +1,120 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+)
+
+// collectionIndex is an index to create on a collection
+type collectionIndex struct {
+	collection string
+	model      mongo.IndexModel
+}
+
+func index(collection string, keys bson.D) collectionIndex {
+	return collectionIndex{collection, mongo.IndexModel{Keys: keys}}
+}
+
+func uniqueIndex(collection string, keys bson.D) collectionIndex {
+	return collectionIndex{collection, mongo.IndexModel{Keys: keys, Options: options.Index().SetUnique(true)}}
+}
+
+// uniqueWhenSet is a unique index that ignores documents where field is
+// missing or empty, for optional fields such as a Razorpay order ID
+func uniqueWhenSet(collection string, keys bson.D, field string) collectionIndex {
+	return collectionIndex{collection, mongo.IndexModel{
+		Keys: keys,
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{field: bson.M{"$type": "string", "$gt": ""}}),
+	}}
+}
+
+// queryIndexes back the lookups and listings the handlers make most often
+var queryIndexes = []collectionIndex{
+	index("room_requests", bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}),
+	index("room_requests", bson.D{{Key: "status", Value: 1}, {Key: "check_in_date", Value: 1}}),
+	index("room_assignments", bson.D{{Key: "request_id", Value: 1}}),
+	index("room_assignments", bson.D{{Key: "room_id", Value: 1}, {Key: "checked_out", Value: 1}}),
+	index("room_assignments", bson.D{{Key: "user_id", Value: 1}, {Key: "check_in_date", Value: -1}}),
+	index("food_passes", bson.D{{Key: "user_id", Value: 1}, {Key: "date", Value: 1}}),
+	index("food_passes", bson.D{{Key: "assignment_id", Value: 1}}),
+	index("food_passes", bson.D{{Key: "date", Value: 1}}),
+	index("payments", bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}),
+	index("payments", bson.D{{Key: "request_id", Value: 1}}),
+	index("outbox", bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}),
+	index("job_runs", bson.D{{Key: "job_name", Value: 1}, {Key: "started_at", Value: -1}}),
+}
+
+// uniqueIndexes enforce what the handlers check before inserting, so two
+// requests racing each other can't both get through
+var uniqueIndexes = []collectionIndex{
+	uniqueWhenSet("users", bson.D{{Key: "email", Value: 1}}, "email"),
+	uniqueIndex("rooms", bson.D{{Key: "building", Value: 1}, {Key: "room_number", Value: 1}}),
+	uniqueWhenSet("room_requests", bson.D{{Key: "public_id", Value: 1}}, "public_id"),
+	uniqueWhenSet("payments", bson.D{{Key: "razorpay_order_id", Value: 1}}, "razorpay_order_id"),
+	uniqueIndex("invoices", bson.D{{Key: "payment_id", Value: 1}, {Key: "kind", Value: 1}}),
+	uniqueIndex("cash_desk_closings", bson.D{{Key: "staff_id", Value: 1}, {Key: "date", Value: 1}}),
+	uniqueIndex("notification_templates", bson.D{{Key: "event", Value: 1}, {Key: "language", Value: 1}, {Key: "channel", Value: 1}}),
+	uniqueIndex("night_audits", bson.D{{Key: "date", Value: 1}}),
+	uniqueIndex("room_types", bson.D{{Key: "code", Value: 1}}),
+}
+
+// openTaskIndex allows one open cleaning task per room. The partial $in
+// filter needs MongoDB 6.0.
+var openTaskIndex = collectionIndex{"housekeeping_tasks", mongo.IndexModel{
+	Keys: bson.D{{Key: "room_id", Value: 1}},
+	Options: options.Index().
+		SetUnique(true).
+		SetPartialFilterExpression(bson.M{"status": bson.M{"$in": []models.CleaningTaskStatus{models.CleaningQueued, models.CleaningInProgress}}}),
+}}
+
+// phoneNumberIndex allows one user per phone number, which OTP login looks
+// users up by. It replaces the plain phone_number_1 index, so it needs a name
+// of its own.
+var phoneNumberIndex = collectionIndex{"users", mongo.IndexModel{
+	Keys: bson.D{{Key: "phone_number", Value: 1}},
+	Options: options.Index().
+		SetName("phone_number_unique").
+		SetUnique(true).
+		SetPartialFilterExpression(bson.M{"phone_number": bson.M{"$type": "string", "$gt": ""}}),
+}}
+
+// signupOTPExpiry removes signup OTPs once they expire
+var signupOTPExpiry = collectionIndex{"signup_otps", mongo.IndexModel{
+	Keys:    bson.D{{Key: "otp_expiry", Value: 1}},
+	Options: options.Index().SetExpireAfterSeconds(0),
+}}
+
+// createIndexes creates the indexes in order. Creating an index that
+// already exists with the same options does nothing. A unique index fails
+// while the collection holds duplicates, which have to be resolved first.
+func createIndexes(ctx context.Context, indexes ...collectionIndex) error {
+	for _, idx := range indexes {
+		if _, err := config.DB.Collection(idx.collection).Indexes().CreateOne(ctx, idx.model); err != nil {
+			return fmt.Errorf("error creating index %v on %s: %w", idx.model.Keys, idx.collection, err)
+		}
+	}
+	return nil
+}
+
+// dropIndex drops an index by name, doing nothing if it doesn't exist
+func dropIndex(ctx context.Context, collection, name string) error {
+	_, err := config.DB.Collection(collection).Indexes().DropOne(ctx, name)
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && (cmdErr.Name == "IndexNotFound" || cmdErr.Name == "NamespaceNotFound") {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error dropping index %s on %s: %w", name, collection, err)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/handlers"
+	"utara_backend/models"
+	"utara_backend/utils"
+)
+
+// Migration is one numbered step of the database schema. Steps are applied
+// in order and recorded in schema_migrations once they succeed. They must be
+// safe to run again, since a step that fails part way, or runs on two
+// instances at once, runs again.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context) error
+}
+
+// all lists every migration by version. Append new steps; never renumber or
+// change a step that may have been applied.
+var all = []Migration{
+	{1, "seed room types and normalise room and user type spellings", func(ctx context.Context) error {
+		_, err := handlers.MigrateRoomTypes()
+		return err
+	}},
+	{2, "create query indexes", func(ctx context.Context) error {
+		return createIndexes(ctx, queryIndexes...)
+	}},
+	{3, "expire signup OTPs", func(ctx context.Context) error {
+		return createIndexes(ctx, signupOTPExpiry)
+	}},
+	{4, "backfill room request public IDs", backfillPublicIDs},
+	{5, "create unique indexes", func(ctx context.Context) error {
+		return createIndexes(ctx, uniqueIndexes...)
+	}},
+	{6, "link guest pass payments to stays instead of room requests", linkGuestPassPayments},
+	{7, "count guest pass quotas", handlers.BackfillGuestPassQuotas},
+	{8, "move chitthi files out of the public uploads directory", handlers.MoveLegacyChitthis},
+	{9, "create unique indexes for open cleaning tasks and phone numbers", func(ctx context.Context) error {
+		if err := createIndexes(ctx, openTaskIndex); err != nil {
+			return err
+		}
+		return uniquePhoneNumbers(ctx)
+	}},
+}
+
+func collection() *mongo.Collection {
+	return config.DB.Collection("schema_migrations")
+}
+
+func applied(ctx context.Context) (map[int]models.SchemaMigration, error) {
+	cursor, err := collection().Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var records []models.SchemaMigration
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	done := make(map[int]models.SchemaMigration, len(records))
+	for _, r := range records {
+		done[r.Version] = r
+	}
+	return done, nil
+}
+
+// Status lists every migration with when it was applied
+func Status(ctx context.Context) ([]models.MigrationStatus, error) {
+	done, err := applied(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading applied migrations: %w", err)
+	}
+	statuses := make([]models.MigrationStatus, len(all))
+	for i, m := range all {
+		statuses[i] = models.MigrationStatus{Version: m.Version, Name: m.Name}
+		if r, ok := done[m.Version]; ok {
+			appliedAt := r.AppliedAt
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+	return statuses, nil
+}
+
+// Pending counts the migrations not applied yet
+func Pending(ctx context.Context) (int, error) {
+	done, err := applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, m := range all {
+		if _, ok := done[m.Version]; !ok {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// Up applies the pending migrations in order, stopping at the first that
+// fails. It returns the migrations it applied.
+func Up(ctx context.Context) ([]models.SchemaMigration, error) {
+	done, err := applied(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading applied migrations: %w", err)
+	}
+
+	ran := []models.SchemaMigration{}
+	for _, m := range all {
+		if _, ok := done[m.Version]; ok {
+			continue
+		}
+		started := time.Now()
+		if err := m.Up(ctx); err != nil {
+			return ran, fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		record := models.SchemaMigration{
+			Version:    m.Version,
+			Name:       m.Name,
+			AppliedAt:  time.Now(),
+			DurationMs: time.Since(started).Milliseconds(),
+		}
+		// Another instance may have recorded it while this one ran
+		if _, err := collection().ReplaceOne(ctx, bson.M{"_id": m.Version}, record, options.Replace().SetUpsert(true)); err != nil {
+			return ran, fmt.Errorf("error recording migration %d: %w", m.Version, err)
+		}
+		ran = append(ran, record)
+	}
+	return ran, nil
+}
+
+// backfillPublicIDs gives requests made before public IDs existed one dated
+// from when they were created
+func backfillPublicIDs(ctx context.Context) error {
+	requests := config.DB.Collection("room_requests")
+	missing := bson.M{"public_id": bson.M{"$in": bson.A{nil, ""}}} // nil also matches a missing field
+	cursor, err := requests.Find(ctx, missing, options.Find().SetProjection(bson.M{"_id": 1, "created_at": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var request models.RoomRequest
+		if err := cursor.Decode(&request); err != nil {
+			return err
+		}
+		created := request.CreatedAt
+		if created.IsZero() {
+			created = request.ID.Timestamp()
+		}
+		filter := bson.M{"_id": request.ID, "public_id": missing["public_id"]}
+		update := bson.M{"$set": bson.M{"public_id": utils.PublicRoomRequestIDFor(created)}}
+		if _, err := requests.UpdateOne(ctx, filter, update); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// linkGuestPassPayments moves guest pass payments off the room request of the
+// host's stay, where they were mistaken for payments of the booking, and onto
+// the stay itself
+func linkGuestPassPayments(ctx context.Context) error {
+	passes := config.DB.Collection("food_passes")
+	ids, err := passes.Distinct(ctx, "payment_id", bson.M{"is_guest_pass": true, "payment_id": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+
+	payments := config.DB.Collection("payments")
+	for _, id := range ids {
+		var pass models.FoodPass
+		err := passes.FindOne(ctx, bson.M{"payment_id": id}, options.FindOne().SetProjection(bson.M{"assignment_id": 1})).Decode(&pass)
+		if err != nil {
+			return err
+		}
+		update := bson.M{"$unset": bson.M{"request_id": ""}}
+		if pass.AssignmentID != nil {
+			update["$set"] = bson.M{"assignment_id": pass.AssignmentID}
+		}
+		if _, err := payments.UpdateOne(ctx, bson.M{"_id": id, "type": models.PaymentTypeFoodPass}, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uniquePhoneNumbers replaces the plain phone number index with a unique one.
+// Users sharing a number are listed so they can be resolved by hand first.
+func uniquePhoneNumbers(ctx context.Context) error {
+	cursor, err := config.DB.Collection("users").Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"phone_number": bson.M{"$type": "string", "$gt": ""}}},
+		{"$group": bson.M{"_id": "$phone_number", "count": bson.M{"$sum": 1}}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+		{"$sort": bson.M{"_id": 1}},
+	})
+	if err != nil {
+		return err
+	}
+	var duplicates []struct {
+		PhoneNumber string `bson:"_id"`
+		Count       int    `bson:"count"`
+	}
+	if err := cursor.All(ctx, &duplicates); err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		shared := make([]string, len(duplicates))
+		for i, d := range duplicates {
+			shared[i] = fmt.Sprintf("%s (%d users)", d.PhoneNumber, d.Count)
+		}
+		return fmt.Errorf("users share phone numbers, give each a number of their own before migrating: %s", strings.Join(shared, ", "))
+	}
+
+	if err := dropIndex(ctx, "users", "phone_number_1"); err != nil {
+		return err
+	}
+	return createIndexes(ctx, phoneNumberIndex)
+}
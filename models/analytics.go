@@ -0,0 +1,67 @@
+package models
+
+// DailyOccupancy is how full the rooms were on one IST night, with the
+// arrivals and departures of that day
+type DailyOccupancy struct {
+	Date       string  `json:"date"` // YYYY-MM-DD in IST
+	Occupied   int     `json:"occupied"`
+	Total      int     `json:"total"`
+	Rate       float64 `json:"rate"` // Percent of rooms occupied
+	Arrivals   int     `json:"arrivals"`
+	Departures int     `json:"departures"`
+}
+
+// GroupOccupancy is the occupancy of a building or room type over a range
+type GroupOccupancy struct {
+	Name            string  `json:"name"`
+	Rooms           int     `json:"rooms"`
+	OccupiedNights  int     `json:"occupied_nights"`
+	AvailableNights int     `json:"available_nights"`
+	Rate            float64 `json:"rate"` // Percent of available room nights occupied
+}
+
+// LatencyStats summarises how long requests waited to be processed, in hours
+type LatencyStats struct {
+	Count   int     `json:"count"`
+	Average float64 `json:"average_hours"`
+	Median  float64 `json:"median_hours"`
+	P90     float64 `json:"p90_hours"`
+	Max     float64 `json:"max_hours"`
+}
+
+// CountedValue is how often a value came up
+type CountedValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// RepeatGuestStats counts guests by how many times they have booked
+type RepeatGuestStats struct {
+	Guests          int            `json:"guests"`        // Guests with at least one booking
+	RepeatGuests    int            `json:"repeat_guests"` // Guests with two or more
+	RepeatRate      float64        `json:"repeat_rate"`   // Percent of guests who came back
+	AverageBookings float64        `json:"average_bookings"`
+	Distribution    []CountedValue `json:"distribution"` // Guests per bookings bucket, e.g. "3-5"
+	RangeGuests     int            `json:"range_guests"` // Guests who requested a room in the range
+	RangeRepeat     int            `json:"range_repeat_guests"`
+}
+
+// Analytics is the occupancy and operations report for an IST date range
+type Analytics struct {
+	From              string           `json:"from"`
+	To                string           `json:"to"`
+	Building          string           `json:"building,omitempty"`
+	Occupancy         float64          `json:"occupancy"` // Percent of room nights occupied over the range
+	Daily             []DailyOccupancy `json:"daily"`
+	ByBuilding        []GroupOccupancy `json:"by_building"`
+	ByRoomType        []GroupOccupancy `json:"by_room_type"`
+	Stays             int              `json:"stays"` // Stays arriving in the range
+	AverageStayNights float64          `json:"average_stay_nights"`
+	Requests          int              `json:"requests"` // Requests created in the range
+	RequestsByStatus  []CountedValue   `json:"requests_by_status"`
+	ApprovalLatency   LatencyStats     `json:"approval_latency"`
+	RejectionReasons  []CountedValue   `json:"rejection_reasons"`
+	TopPlaces         []CountedValue   `json:"top_places"`
+	TopPurposes       []CountedValue   `json:"top_purposes"`
+	RepeatGuests      RepeatGuestStats `json:"repeat_guests"`
+}
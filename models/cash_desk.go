@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CashDeskMethodTotal sums one payment method's desk takings for a day
+type CashDeskMethodTotal struct {
+	Method    PaymentMethod `json:"method" bson:"method"`
+	Count     int           `json:"count" bson:"count"`
+	Collected int           `json:"collected" bson:"collected"` // Amount in paise
+	Refunded  int           `json:"refunded" bson:"refunded"`   // Paid back out at the desk
+	Net       int           `json:"net" bson:"net"`
+}
+
+// CashDeskReport is what a staff member collected and paid out at the desk
+// on one IST day. ExpectedCash is the cash that should be in the drawer.
+type CashDeskReport struct {
+	StaffID        primitive.ObjectID    `json:"staff_id"`
+	StaffName      string                `json:"staff_name"`
+	Date           string                `json:"date"` // YYYY-MM-DD in IST
+	Methods        []CashDeskMethodTotal `json:"methods"`
+	TotalCollected int                   `json:"total_collected"`
+	TotalRefunded  int                   `json:"total_refunded"`
+	ExpectedCash   int                   `json:"expected_cash"`
+	Payments       []Payment             `json:"payments"`
+	Refunds        []Payment             `json:"refunds"`
+	Closing        *CashDeskClosing      `json:"closing,omitempty"`
+}
+
+// CashDeskClosing records a staff member counting their drawer at the end of
+// a day. Difference is DeclaredCash minus ExpectedCash.
+type CashDeskClosing struct {
+	ID           primitive.ObjectID    `json:"id" bson:"_id,omitempty"`
+	StaffID      primitive.ObjectID    `json:"staff_id" bson:"staff_id"`
+	StaffName    string                `json:"staff_name" bson:"staff_name"`
+	Date         string                `json:"date" bson:"date"` // YYYY-MM-DD in IST
+	Methods      []CashDeskMethodTotal `json:"methods" bson:"methods"`
+	ExpectedCash int                   `json:"expected_cash" bson:"expected_cash"` // Amount in paise
+	DeclaredCash int                   `json:"declared_cash" bson:"declared_cash"`
+	Difference   int                   `json:"difference" bson:"difference"`
+	PaymentIDs   []primitive.ObjectID  `json:"payment_ids" bson:"payment_ids"`
+	RefundIDs    []primitive.ObjectID  `json:"refund_ids" bson:"refund_ids"` // Payments refunded at the desk
+	Notes        string                `json:"notes,omitempty" bson:"notes,omitempty"`
+	ClosedAt     time.Time             `json:"closed_at" bson:"closed_at"`
+}
+
+type CloseCashDeskRequest struct {
+	Date         string `json:"date"` // YYYY-MM-DD, defaults to today
+	DeclaredCash *int   `json:"declared_cash" binding:"required"`
+	Notes        string `json:"notes"`
+}
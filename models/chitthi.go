@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ChitthiFile is the reference letter attached to a room request. It is kept
+// in private storage and only served to the requester and staff.
+type ChitthiFile struct {
+	Key         string             `json:"-" bson:"key"`
+	FileName    string             `json:"file_name" bson:"file_name"`
+	ContentType string             `json:"content_type" bson:"content_type"`
+	Size        int64              `json:"size" bson:"size"`
+	UploadedBy  primitive.ObjectID `json:"uploaded_by" bson:"uploaded_by"`
+	UploadedAt  time.Time          `json:"uploaded_at" bson:"uploaded_at"`
+}
+
+type RoomRequestAction string
+
+const (
+	RequestChitthiUploaded RoomRequestAction = "CHITTHI_UPLOADED"
+	RequestChitthiReplaced RoomRequestAction = "CHITTHI_REPLACED"
+	RequestChitthiViewed   RoomRequestAction = "CHITTHI_VIEWED"
+	RequestMarkedNoShow    RoomRequestAction = "MARKED_NO_SHOW"
+	RequestNoShowCleared   RoomRequestAction = "NO_SHOW_CLEARED" // The guest checked in after all
+)
+
+// RoomRequestEvent is an entry in the audit trail of a room request
+type RoomRequestEvent struct {
+	Action  RoomRequestAction  `json:"action" bson:"action"`
+	Details string             `json:"details,omitempty" bson:"details,omitempty"`
+	By      primitive.ObjectID `json:"by" bson:"by"`
+	At      time.Time          `json:"at" bson:"at"`
+}
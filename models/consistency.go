@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ConsistencyCheck string
+
+const (
+	CheckOccupiedWithoutStay ConsistencyCheck = "OCCUPIED_WITHOUT_STAY" // Room flagged occupied with no stay holding it
+	CheckStayInFreeRoom      ConsistencyCheck = "STAY_IN_FREE_ROOM"     // Stay holding a room flagged free
+	CheckMissingRoom         ConsistencyCheck = "MISSING_ROOM"          // Stay in a room that was deleted
+	CheckMissingUser         ConsistencyCheck = "MISSING_USER"          // Stay or open request of a user that was deleted
+	CheckMissingRequest      ConsistencyCheck = "MISSING_REQUEST"       // Stay or payment of a request that was deleted
+	CheckRequestNotApproved  ConsistencyCheck = "REQUEST_NOT_APPROVED"  // Stay holding a room for a request that isn't approved
+	CheckDuplicateStay       ConsistencyCheck = "DUPLICATE_STAY"        // Request with more than one stay holding a room
+	CheckDoubleBooked        ConsistencyCheck = "DOUBLE_BOOKED"         // Room held by stays with overlapping dates
+	CheckInvalidStay         ConsistencyCheck = "INVALID_STAY"          // Checked out without checking in, or dates out of order
+	CheckInvalidPayment      ConsistencyCheck = "INVALID_PAYMENT"       // Paid without a paid date, or refunded more than was paid
+	CheckPassWithoutStay     ConsistencyCheck = "PASS_WITHOUT_STAY"     // Unused food pass of a stay that was deleted
+	CheckPassAfterCheckout   ConsistencyCheck = "PASS_AFTER_CHECKOUT"   // Unused food pass of a stay that has checked out
+)
+
+// ConsistencyViolation is one document whose state disagrees with the
+// documents it refers to. RefID is the document in Collection it is about.
+type ConsistencyViolation struct {
+	Check       ConsistencyCheck   `json:"check"`
+	Collection  string             `json:"collection"`
+	RefID       primitive.ObjectID `json:"ref_id"`
+	Description string             `json:"description"`
+	Fixable     bool               `json:"fixable"` // Can be fixed without a person deciding how
+	Fixed       bool               `json:"fixed"`
+}
+
+// ConsistencyReport is the result of cross-checking rooms, stays, requests,
+// payments and food passes
+type ConsistencyReport struct {
+	Fix        bool                     `json:"fix"`
+	Violations []ConsistencyViolation   `json:"violations"`
+	Counts     map[ConsistencyCheck]int `json:"counts"`
+	Fixed      int                      `json:"fixed"`
+	CheckedAt  time.Time                `json:"checked_at"`
+}
+
+// ConsistencyCheckRequest runs the consistency checks, fixing the fixable
+// violations if Fix is set
+type ConsistencyCheckRequest struct {
+	Fix bool `json:"fix"`
+}
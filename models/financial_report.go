@@ -0,0 +1,120 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CollectionRow totals the payments of one type and method in a day or month.
+// Refunds are counted in the period they were issued, not when the refunded
+// payment was made.
+type CollectionRow struct {
+	Period    string        `json:"period"` // YYYY-MM-DD or YYYY-MM in IST
+	Type      PaymentType   `json:"type"`
+	Method    PaymentMethod `json:"method"`
+	Payments  int           `json:"payments"`
+	Collected int           `json:"collected"` // Amount in paise
+	Refunds   int           `json:"refunds"`
+	Refunded  int           `json:"refunded"`
+	Net       int           `json:"net"`
+}
+
+// CollectionsReport is collections and refunds over a date range
+type CollectionsReport struct {
+	From           string          `json:"from"`
+	To             string          `json:"to"`
+	Period         string          `json:"period"` // day or month
+	Rows           []CollectionRow `json:"rows"`
+	ByType         []CollectionRow `json:"by_type"`   // Range totals per type, Method and Period empty
+	ByMethod       []CollectionRow `json:"by_method"` // Range totals per method, Type and Period empty
+	TotalCollected int             `json:"total_collected"`
+	TotalRefunded  int             `json:"total_refunded"`
+	Net            int             `json:"net"`
+}
+
+// RefundRow is one refund issued
+type RefundRow struct {
+	PaymentID  primitive.ObjectID  `json:"payment_id"`
+	UserID     primitive.ObjectID  `json:"user_id"`
+	UserName   string              `json:"user_name"`
+	RequestID  *primitive.ObjectID `json:"request_id,omitempty"`
+	Type       PaymentType         `json:"type"`
+	Method     PaymentMethod       `json:"method"`
+	Amount     int                 `json:"amount"` // Amount paid, in paise
+	Refunded   int                 `json:"refunded"`
+	RefundID   string              `json:"refund_id"`
+	RefundedAt time.Time           `json:"refunded_at"`
+}
+
+// RefundsReport lists the refunds issued over a date range
+type RefundsReport struct {
+	From          string      `json:"from"`
+	To            string      `json:"to"`
+	Refunds       []RefundRow `json:"refunds"`
+	Count         int         `json:"count"`
+	TotalRefunded int         `json:"total_refunded"`
+}
+
+// DepositRow is a deposit still held
+type DepositRow struct {
+	PaymentID    primitive.ObjectID  `json:"payment_id"`
+	UserID       primitive.ObjectID  `json:"user_id"`
+	UserName     string              `json:"user_name"`
+	RequestID    *primitive.ObjectID `json:"request_id,omitempty"`
+	Method       PaymentMethod       `json:"method"`
+	Amount       int                 `json:"amount"`
+	PaidAt       *time.Time          `json:"paid_at,omitempty"`
+	CheckedOutAt *time.Time          `json:"checked_out_at,omitempty"` // Set once the guest has left and the deposit is due back
+}
+
+// DepositsReport compares deposits held with those returned. The held and
+// returned figures are as of now; the range figures cover ?from= to ?to=.
+type DepositsReport struct {
+	From             string       `json:"from"`
+	To               string       `json:"to"`
+	HeldCount        int          `json:"held_count"`
+	HeldAmount       int          `json:"held_amount"`
+	DueCount         int          `json:"due_count"` // Held for guests who have checked out
+	DueAmount        int          `json:"due_amount"`
+	ReturnedCount    int          `json:"returned_count"`
+	ReturnedAmount   int          `json:"returned_amount"`
+	RetainedAmount   int          `json:"retained_amount"` // Kept back from partly returned deposits
+	CollectedInRange int          `json:"collected_in_range"`
+	ReturnedInRange  int          `json:"returned_in_range"`
+	Held             []DepositRow `json:"held"`
+}
+
+// FOCRow counts free of charge stays for one user type
+type FOCRow struct {
+	UserType string  `json:"user_type"`
+	Stays    int     `json:"stays"`
+	FOCStays int     `json:"foc_stays"`
+	FOCRate  float64 `json:"foc_rate"` // Percent of stays that were FOC
+}
+
+// FOCReport counts FOC stays arriving over a date range by user type
+type FOCReport struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Rows     []FOCRow `json:"rows"`
+	Stays    int      `json:"stays"`
+	FOCStays int      `json:"foc_stays"`
+}
+
+// PendingRefundRow is a payment flagged for refund, when its request was
+// rejected, that hasn't been refunded
+type PendingRefundRow struct {
+	PaymentID   primitive.ObjectID  `json:"payment_id"`
+	UserID      primitive.ObjectID  `json:"user_id"`
+	UserName    string              `json:"user_name"`
+	PhoneNumber string              `json:"phone_number"`
+	RequestID   *primitive.ObjectID `json:"request_id,omitempty"`
+	Type        PaymentType         `json:"type"`
+	Method      PaymentMethod       `json:"method"`
+	Amount      int                 `json:"amount"`
+	Reason      string              `json:"reason"`
+	PaidAt      *time.Time          `json:"paid_at,omitempty"`
+	FlaggedAt   time.Time           `json:"flagged_at"`
+	DaysPending int                 `json:"days_pending"`
+}
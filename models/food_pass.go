@@ -15,29 +15,72 @@ const (
 )
 
 type FoodPass struct {
-	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	UserID     primitive.ObjectID `json:"user_id" bson:"user_id"`
-	MemberName string             `json:"member_name" bson:"member_name"`
-	MealType   MealType           `json:"meal_type" bson:"meal_type"`
-	Date       time.Time          `json:"date" bson:"date"`
-	QRCode     string             `json:"qr_code" bson:"qr_code"`
-	IsUsed     bool               `json:"is_used" bson:"is_used"`
-	IsExpired  bool               `json:"is_expired" bson:"is_expired"`
-	ExpiredAt  *time.Time         `json:"expired_at,omitempty" bson:"expired_at,omitempty"`
-	DiningHall string             `json:"dining_hall" bson:"dining_hall"`
-	ColorCode  string             `json:"color_code" bson:"color_code"`
-	UsedAt     *time.Time         `json:"used_at,omitempty" bson:"used_at,omitempty"`
-	CreatedBy  primitive.ObjectID `json:"created_by" bson:"created_by"`
-	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+	ID           primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	UserID       primitive.ObjectID  `json:"user_id" bson:"user_id"`
+	AssignmentID *primitive.ObjectID `json:"assignment_id,omitempty" bson:"assignment_id,omitempty"`
+	MemberName   string              `json:"member_name" bson:"member_name"`
+	MealType     MealType            `json:"meal_type" bson:"meal_type"`
+	Date         time.Time           `json:"date" bson:"date"`
+	QRCode       string              `json:"qr_code" bson:"qr_code"`
+	QRToken      *primitive.ObjectID `json:"-" bson:"qr_token,omitempty"` // Replaces the pass ID in the QR once it is regenerated
+	IsUsed       bool                `json:"is_used" bson:"is_used"`
+	IsExpired    bool                `json:"is_expired" bson:"is_expired"`
+	ExpiredAt    *time.Time          `json:"expired_at,omitempty" bson:"expired_at,omitempty"`
+	DiningHall   string              `json:"dining_hall" bson:"dining_hall"`
+	ColorCode    string              `json:"color_code" bson:"color_code"`
+	UsedAt       *time.Time          `json:"used_at,omitempty" bson:"used_at,omitempty"`
+	CreatedBy    primitive.ObjectID  `json:"created_by" bson:"created_by"`
+	CreatedAt    time.Time           `json:"created_at" bson:"created_at"`
+	History      []FoodPassEvent     `json:"history,omitempty" bson:"history,omitempty"`
+
+	// Single-meal passes for visitors joining a host guest
+	IsGuestPass     bool                `json:"is_guest_pass,omitempty" bson:"is_guest_pass,omitempty"`
+	PaymentID       *primitive.ObjectID `json:"payment_id,omitempty" bson:"payment_id,omitempty"`
+	AwaitingPayment bool                `json:"awaiting_payment,omitempty" bson:"awaiting_payment,omitempty"` // Can't be scanned until paid
+	GuestQuotaKey   string              `json:"-" bson:"guest_quota_key,omitempty"`                           // Daily guest quota the pass holds a slot of
+}
+
+// GuestPassQuota counts the guest passes holding a slot of a hall's daily
+// quota. There is one per hall and IST day in guest_pass_quotas.
+type GuestPassQuota struct {
+	Key    string `json:"key" bson:"_id"`
+	Issued int    `json:"issued" bson:"issued"`
+}
+
+// QRPayload returns the value encoded in the pass QR code
+func (p FoodPass) QRPayload() string {
+	if p.QRToken != nil {
+		return p.QRToken.Hex()
+	}
+	return p.ID.Hex()
+}
+
+type FoodPassAction string
+
+const (
+	FoodPassTransferred FoodPassAction = "TRANSFERRED"
+	FoodPassExpired     FoodPassAction = "EXPIRED"
+)
+
+// FoodPassEvent is an entry in the history of a food pass
+type FoodPassEvent struct {
+	Action         FoodPassAction      `json:"action" bson:"action"`
+	FromDiningHall string              `json:"from_dining_hall,omitempty" bson:"from_dining_hall,omitempty"`
+	ToDiningHall   string              `json:"to_dining_hall,omitempty" bson:"to_dining_hall,omitempty"`
+	TransferID     *primitive.ObjectID `json:"transfer_id,omitempty" bson:"transfer_id,omitempty"`
+	Reason         string              `json:"reason,omitempty" bson:"reason,omitempty"`
+	By             primitive.ObjectID  `json:"by" bson:"by"`
+	At             time.Time           `json:"at" bson:"at"`
 }
 
 type GenerateFoodPassRequest struct {
-	UserID      primitive.ObjectID `json:"user_id" binding:"required"`
-	MemberNames []string           `json:"member_names" binding:"required"`
-	StartDate   time.Time          `json:"start_date" binding:"required"`
-	EndDate     time.Time          `json:"end_date" binding:"required"`
-	DiningHall  string             `json:"dining_hall"`
-	ColorCode   string             `json:"color_code"`
+	UserID       primitive.ObjectID  `json:"user_id" binding:"required"`
+	AssignmentID *primitive.ObjectID `json:"assignment_id"`
+	MemberNames  []string            `json:"member_names" binding:"required"`
+	StartDate    time.Time           `json:"start_date" binding:"required"`
+	EndDate      time.Time           `json:"end_date" binding:"required"`
+	DiningHall   string              `json:"dining_hall"`
+	ColorCode    string              `json:"color_code"`
 }
 
 type ScanFoodPassRequest struct {
@@ -45,8 +88,88 @@ type ScanFoodPassRequest struct {
 }
 
 type FoodPassCategory struct {
-	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	BuildingName string             `json:"building_name" bson:"building_name"`
-	ColorCode    string             `json:"color_code" bson:"color_code"`
-	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	BuildingName    string             `json:"building_name" bson:"building_name"`
+	ColorCode       string             `json:"color_code" bson:"color_code"`
+	DailyGuestQuota int                `json:"daily_guest_quota" bson:"daily_guest_quota"` // Guest passes per day, 0 for unlimited
+	GuestPassPrice  int                `json:"guest_pass_price" bson:"guest_pass_price"`   // Amount in paise per guest pass
+	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
+}
+
+type GuestFoodPassRequest struct {
+	UserID        primitive.ObjectID  `json:"user_id" binding:"required"` // Host guest
+	AssignmentID  *primitive.ObjectID `json:"assignment_id"`              // Defaults to the host's stay covering the date
+	MealType      MealType            `json:"meal_type" binding:"required"`
+	Date          time.Time           `json:"date" binding:"required"`
+	Count         int                 `json:"count" binding:"required,min=1,max=50"`
+	GuestNames    []string            `json:"guest_names"`
+	DiningHall    string              `json:"dining_hall"`
+	Chargeable    bool                `json:"chargeable"`
+	AmountPerPass *int                `json:"amount_per_pass"` // Amount in paise, defaults to the hall's guest pass price
+}
+
+// WalletPass is a food pass as shown in the guest wallet, without the QR image
+type WalletPass struct {
+	ID         primitive.ObjectID `json:"id"`
+	MealType   MealType           `json:"meal_type"`
+	DiningHall string             `json:"dining_hall"`
+	ColorCode  string             `json:"color_code"`
+	IsUsed     bool               `json:"is_used"`
+	UsedAt     *time.Time         `json:"used_at,omitempty"`
+	QRURL      string             `json:"qr_url"`
+	IsCurrent  bool               `json:"is_current"`
+	IsNext     bool               `json:"is_next"`
+}
+
+type WalletMember struct {
+	MemberName string       `json:"member_name"`
+	Passes     []WalletPass `json:"passes"`
+}
+
+type WalletDay struct {
+	Date    string         `json:"date"` // YYYY-MM-DD in IST
+	Members []WalletMember `json:"members"`
+}
+
+// WalletMeal identifies a meal service on a given day
+type WalletMeal struct {
+	Date     string    `json:"date"`
+	MealType MealType  `json:"meal_type"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+type FoodPassWallet struct {
+	UserID      primitive.ObjectID `json:"user_id"`
+	CurrentMeal *WalletMeal        `json:"current_meal,omitempty"`
+	NextMeal    *WalletMeal        `json:"next_meal,omitempty"`
+	TotalPasses int                `json:"total_passes"`
+	Days        []WalletDay        `json:"days"`
+}
+
+type TransferFoodPassRequest struct {
+	UserID         *primitive.ObjectID `json:"user_id"`
+	AssignmentID   *primitive.ObjectID `json:"assignment_id"`
+	MemberNames    []string            `json:"member_names"` // Empty moves every member
+	FromCategoryID primitive.ObjectID  `json:"from_category_id" binding:"required"`
+	ToCategoryID   primitive.ObjectID  `json:"to_category_id" binding:"required"`
+	FromDate       *time.Time          `json:"from_date"` // Defaults to today
+	Reason         string              `json:"reason"`
+}
+
+// FoodPassTransfer records a move of passes between dining halls
+type FoodPassTransfer struct {
+	ID             primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	UserID         primitive.ObjectID  `json:"user_id" bson:"user_id"`
+	AssignmentID   *primitive.ObjectID `json:"assignment_id,omitempty" bson:"assignment_id,omitempty"`
+	MemberNames    []string            `json:"member_names,omitempty" bson:"member_names,omitempty"`
+	FromCategoryID primitive.ObjectID  `json:"from_category_id" bson:"from_category_id"`
+	ToCategoryID   primitive.ObjectID  `json:"to_category_id" bson:"to_category_id"`
+	FromDiningHall string              `json:"from_dining_hall" bson:"from_dining_hall"`
+	ToDiningHall   string              `json:"to_dining_hall" bson:"to_dining_hall"`
+	FromDate       time.Time           `json:"from_date" bson:"from_date"`
+	PassCount      int                 `json:"pass_count" bson:"pass_count"`
+	Reason         string              `json:"reason,omitempty" bson:"reason,omitempty"`
+	TransferredBy  primitive.ObjectID  `json:"transferred_by" bson:"transferred_by"`
+	CreatedAt      time.Time           `json:"created_at" bson:"created_at"`
 }
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CleaningTaskStatus string
+
+// A task is QUEUED until a housekeeper starts it, DONE once the room is clean
+// and released, and INSPECTED after a supervisor has signed it off. A failed
+// inspection puts the task back in the queue.
+const (
+	CleaningQueued     CleaningTaskStatus = "QUEUED"
+	CleaningInProgress CleaningTaskStatus = "IN_PROGRESS"
+	CleaningDone       CleaningTaskStatus = "DONE"
+	CleaningInspected  CleaningTaskStatus = "INSPECTED"
+)
+
+type CleaningPriority string
+
+const (
+	CleaningPriorityNormal CleaningPriority = "NORMAL"
+	CleaningPriorityHigh   CleaningPriority = "HIGH" // Room has an arrival today
+)
+
+type CleaningTaskSource string
+
+const (
+	CleaningSourceCheckout CleaningTaskSource = "CHECKOUT"
+	CleaningSourceManual   CleaningTaskSource = "MANUAL"
+)
+
+type CleaningTask struct {
+	ID                primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	RoomID            primitive.ObjectID  `json:"room_id" bson:"room_id"`
+	RoomNumber        string              `json:"room_number" bson:"room_number"`
+	Building          string              `json:"building" bson:"building"`
+	Floor             int                 `json:"floor" bson:"floor"`
+	AssignmentID      *primitive.ObjectID `json:"assignment_id,omitempty" bson:"assignment_id,omitempty"` // Checkout that created the task
+	Source            CleaningTaskSource  `json:"source" bson:"source"`
+	Status            CleaningTaskStatus  `json:"status" bson:"status"`
+	Priority          CleaningPriority    `json:"priority" bson:"priority"`
+	HasSameDayArrival bool                `json:"has_same_day_arrival" bson:"has_same_day_arrival"`
+	AssignedTo        *primitive.ObjectID `json:"assigned_to,omitempty" bson:"assigned_to,omitempty"`
+	AssignedBy        *primitive.ObjectID `json:"assigned_by,omitempty" bson:"assigned_by,omitempty"`
+	AssignedAt        *time.Time          `json:"assigned_at,omitempty" bson:"assigned_at,omitempty"`
+	StartedAt         *time.Time          `json:"started_at,omitempty" bson:"started_at,omitempty"`
+	CompletedAt       *time.Time          `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+	CompletedBy       *primitive.ObjectID `json:"completed_by,omitempty" bson:"completed_by,omitempty"`
+	DurationMinutes   int                 `json:"duration_minutes,omitempty" bson:"duration_minutes,omitempty"` // Time spent cleaning, summed over reopened attempts
+	InspectedAt       *time.Time          `json:"inspected_at,omitempty" bson:"inspected_at,omitempty"`
+	InspectedBy       *primitive.ObjectID `json:"inspected_by,omitempty" bson:"inspected_by,omitempty"`
+	ReopenCount       int                 `json:"reopen_count,omitempty" bson:"reopen_count,omitempty"`
+	Notes             string              `json:"notes,omitempty" bson:"notes,omitempty"`
+	CreatedBy         primitive.ObjectID  `json:"created_by" bson:"created_by"`
+	CreatedAt         time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt         time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+type CreateCleaningTaskRequest struct {
+	RoomID     primitive.ObjectID  `json:"room_id" binding:"required"`
+	AssignedTo *primitive.ObjectID `json:"assigned_to"`
+	Notes      string              `json:"notes"`
+}
+
+type AssignCleaningTaskRequest struct {
+	AssignedTo primitive.ObjectID `json:"assigned_to" binding:"required"`
+}
+
+type InspectCleaningTaskRequest struct {
+	Passed *bool  `json:"passed" binding:"required"`
+	Notes  string `json:"notes"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type InvoiceKind string
+
+const (
+	InvoiceKindInvoice    InvoiceKind = "INVOICE"
+	InvoiceKindCreditNote InvoiceKind = "CREDIT_NOTE"
+)
+
+type InvoiceLineKind string
+
+const (
+	InvoiceLineRoom     InvoiceLineKind = "ROOM"
+	InvoiceLineDeposit  InvoiceLineKind = "DEPOSIT"
+	InvoiceLineFoodPass InvoiceLineKind = "FOOD_PASS"
+	InvoiceLineDiscount InvoiceLineKind = "DISCOUNT"
+	InvoiceLineOther    InvoiceLineKind = "OTHER"
+	InvoiceLineRefund   InvoiceLineKind = "REFUND"
+)
+
+// InvoiceLine is a tax inclusive charge. TaxableAmount plus TaxAmount is
+// Amount.
+type InvoiceLine struct {
+	Kind          InvoiceLineKind `json:"kind" bson:"kind"`
+	Description   string          `json:"description" bson:"description"`
+	Quantity      int             `json:"quantity" bson:"quantity"`
+	UnitAmount    int             `json:"unit_amount" bson:"unit_amount"` // Amount in paise
+	Amount        int             `json:"amount" bson:"amount"`           // Negative for discounts
+	TaxRate       float64         `json:"tax_rate" bson:"tax_rate"`       // Percent
+	TaxableAmount int             `json:"taxable_amount" bson:"taxable_amount"`
+	TaxAmount     int             `json:"tax_amount" bson:"tax_amount"`
+}
+
+type InvoiceParty struct {
+	Name        string `json:"name" bson:"name"`
+	Email       string `json:"email,omitempty" bson:"email,omitempty"`
+	PhoneNumber string `json:"phone_number,omitempty" bson:"phone_number,omitempty"`
+	Address     string `json:"address,omitempty" bson:"address,omitempty"`
+	GSTIN       string `json:"gstin,omitempty" bson:"gstin,omitempty"`
+}
+
+// Invoice is a receipt for a paid payment, or a credit note for a refund of
+// one. Numbers run sequentially per kind and financial year, e.g.
+// "INV/2026-27/000042".
+type Invoice struct {
+	ID            primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Number        string              `json:"number" bson:"number"`
+	Kind          InvoiceKind         `json:"kind" bson:"kind"`
+	FinancialYear string              `json:"financial_year" bson:"financial_year"`
+	PaymentID     primitive.ObjectID  `json:"payment_id" bson:"payment_id"`
+	UserID        primitive.ObjectID  `json:"user_id" bson:"user_id"`
+	RequestID     *primitive.ObjectID `json:"request_id,omitempty" bson:"request_id,omitempty"`
+	InvoiceID     *primitive.ObjectID `json:"invoice_id,omitempty" bson:"invoice_id,omitempty"` // Invoice a credit note reverses
+	RefundID      string              `json:"refund_id,omitempty" bson:"refund_id,omitempty"`
+	Reason        string              `json:"reason,omitempty" bson:"reason,omitempty"`
+	Seller        InvoiceParty        `json:"seller" bson:"seller"`
+	BilledTo      InvoiceParty        `json:"billed_to" bson:"billed_to"`
+	Lines         []InvoiceLine       `json:"lines" bson:"lines"`
+	TaxableTotal  int                 `json:"taxable_total" bson:"taxable_total"`
+	TaxTotal      int                 `json:"tax_total" bson:"tax_total"`
+	Total         int                 `json:"total" bson:"total"` // Amount in paise
+	Currency      string              `json:"currency" bson:"currency"`
+	IssuedAt      time.Time           `json:"issued_at" bson:"issued_at"`
+}
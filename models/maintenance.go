@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MaintenanceCategory string
+
+const (
+	MaintenancePlumbing   MaintenanceCategory = "PLUMBING"
+	MaintenanceElectrical MaintenanceCategory = "ELECTRICAL"
+	MaintenanceAC         MaintenanceCategory = "AC"
+	MaintenanceCarpentry  MaintenanceCategory = "CARPENTRY"
+	MaintenanceFurniture  MaintenanceCategory = "FURNITURE"
+	MaintenanceOther      MaintenanceCategory = "OTHER"
+)
+
+// IsValid reports whether the category is one of the known categories
+func (c MaintenanceCategory) IsValid() bool {
+	switch c {
+	case MaintenancePlumbing, MaintenanceElectrical, MaintenanceAC, MaintenanceCarpentry, MaintenanceFurniture, MaintenanceOther:
+		return true
+	}
+	return false
+}
+
+type MaintenanceStatus string
+
+const (
+	MaintenanceOpen       MaintenanceStatus = "OPEN"
+	MaintenanceInProgress MaintenanceStatus = "IN_PROGRESS"
+	MaintenanceResolved   MaintenanceStatus = "RESOLVED"
+	MaintenanceClosed     MaintenanceStatus = "CLOSED"
+)
+
+type MaintenancePhoto struct {
+	URL        string             `json:"url" bson:"url"`
+	UploadedBy primitive.ObjectID `json:"uploaded_by" bson:"uploaded_by"`
+	UploadedAt time.Time          `json:"uploaded_at" bson:"uploaded_at"`
+}
+
+// MaintenanceTicket is a repair job for a room. While a ticket with an
+// out-of-order range is open, the room can't be assigned for those dates.
+type MaintenanceTicket struct {
+	ID              primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	RoomID          primitive.ObjectID  `json:"room_id" bson:"room_id"`
+	RoomNumber      string              `json:"room_number" bson:"room_number"`
+	Building        string              `json:"building" bson:"building"`
+	Floor           int                 `json:"floor" bson:"floor"`
+	Issue           string              `json:"issue" bson:"issue"`
+	Description     string              `json:"description,omitempty" bson:"description,omitempty"`
+	Category        MaintenanceCategory `json:"category" bson:"category"`
+	Photos          []MaintenancePhoto  `json:"photos,omitempty" bson:"photos,omitempty"`
+	Status          MaintenanceStatus   `json:"status" bson:"status"`
+	ReportedBy      primitive.ObjectID  `json:"reported_by" bson:"reported_by"`
+	AssignedTo      *primitive.ObjectID `json:"assigned_to,omitempty" bson:"assigned_to,omitempty"`
+	OutOfOrderFrom  *time.Time          `json:"out_of_order_from,omitempty" bson:"out_of_order_from,omitempty"`
+	OutOfOrderUntil *time.Time          `json:"out_of_order_until,omitempty" bson:"out_of_order_until,omitempty"`
+	ResolutionNotes string              `json:"resolution_notes,omitempty" bson:"resolution_notes,omitempty"`
+	ResolvedBy      *primitive.ObjectID `json:"resolved_by,omitempty" bson:"resolved_by,omitempty"`
+	ResolvedAt      *time.Time          `json:"resolved_at,omitempty" bson:"resolved_at,omitempty"`
+	ClosedAt        *time.Time          `json:"closed_at,omitempty" bson:"closed_at,omitempty"`
+	CreatedAt       time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+type CreateMaintenanceTicketRequest struct {
+	RoomID          primitive.ObjectID  `json:"room_id" binding:"required"`
+	Issue           string              `json:"issue" binding:"required"`
+	Description     string              `json:"description"`
+	Category        MaintenanceCategory `json:"category" binding:"required"`
+	PhotoURLs       []string            `json:"photo_urls"`
+	AssignedTo      *primitive.ObjectID `json:"assigned_to"`
+	OutOfOrderFrom  *time.Time          `json:"out_of_order_from"`
+	OutOfOrderUntil *time.Time          `json:"out_of_order_until"`
+}
+
+type UpdateMaintenanceTicketRequest struct {
+	Status          *MaintenanceStatus   `json:"status"`
+	Category        *MaintenanceCategory `json:"category"`
+	Description     *string              `json:"description"`
+	AssignedTo      *primitive.ObjectID  `json:"assigned_to"`
+	AddPhotoURLs    []string             `json:"add_photo_urls"`
+	OutOfOrderFrom  *time.Time           `json:"out_of_order_from"`
+	OutOfOrderUntil *time.Time           `json:"out_of_order_until"`
+	ClearOutOfOrder bool                 `json:"clear_out_of_order"`
+	ResolutionNotes *string              `json:"resolution_notes"`
+}
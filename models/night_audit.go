@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type NightAuditIssue string
+
+const (
+	AuditOverdueCheckout     NightAuditIssue = "OVERDUE_CHECKOUT"      // Checked in guest past their checkout date
+	AuditNoShow              NightAuditIssue = "NO_SHOW"               // Approved stay whose arrival day passed without a check-in
+	AuditOccupiedWithoutStay NightAuditIssue = "OCCUPIED_WITHOUT_STAY" // Room flagged occupied with no stay holding it
+	AuditPassAfterCheckout   NightAuditIssue = "PASS_AFTER_CHECKOUT"   // Unused food pass of a stay that has checked out
+)
+
+// NightAuditFinding is one inconsistency found by the night audit. RefID is
+// the document in Collection it is about.
+type NightAuditFinding struct {
+	Issue       NightAuditIssue    `json:"issue" bson:"issue"`
+	Collection  string             `json:"collection" bson:"collection"`
+	RefID       primitive.ObjectID `json:"ref_id" bson:"ref_id"`
+	Description string             `json:"description" bson:"description"`
+	Corrected   bool               `json:"corrected" bson:"corrected"`
+}
+
+// NightAuditSnapshot is the occupancy and revenue of the audited day
+type NightAuditSnapshot struct {
+	Rooms      int             `json:"rooms" bson:"rooms"`
+	Occupied   int             `json:"occupied" bson:"occupied"`
+	Occupancy  float64         `json:"occupancy" bson:"occupancy"` // Percent of rooms occupied
+	Arrivals   int             `json:"arrivals" bson:"arrivals"`
+	Departures int             `json:"departures" bson:"departures"`
+	NoShows    int             `json:"no_shows" bson:"no_shows"`
+	Collected  int             `json:"collected" bson:"collected"` // Amount in paise
+	Refunded   int             `json:"refunded" bson:"refunded"`
+	Net        int             `json:"net" bson:"net"`
+	Revenue    []CollectionRow `json:"revenue" bson:"revenue"` // Per payment type
+}
+
+// NightAudit closes an IST business day. There is one per day; rerunning the
+// audit replaces it.
+type NightAudit struct {
+	ID          primitive.ObjectID      `json:"id" bson:"_id,omitempty"`
+	Date        string                  `json:"date" bson:"date"` // YYYY-MM-DD in IST
+	AutoCorrect bool                    `json:"auto_correct" bson:"auto_correct"`
+	Findings    []NightAuditFinding     `json:"findings" bson:"findings"`
+	Counts      map[NightAuditIssue]int `json:"counts" bson:"counts"`
+	Corrected   int                     `json:"corrected" bson:"corrected"`
+	Snapshot    NightAuditSnapshot      `json:"snapshot" bson:"snapshot"`
+	StartedAt   time.Time               `json:"started_at" bson:"started_at"`
+	CompletedAt time.Time               `json:"completed_at" bson:"completed_at"`
+}
+
+// RunNightAuditRequest audits a day on demand. Date is YYYY-MM-DD in IST,
+// yesterday if empty.
+type RunNightAuditRequest struct {
+	Date        string `json:"date"`
+	AutoCorrect bool   `json:"auto_correct"`
+}
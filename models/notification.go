@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NotificationTemplate overrides the built-in text of an event for a
+// language, and optionally for one channel only
+type NotificationTemplate struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Event     string             `json:"event" bson:"event"`
+	Channel   string             `json:"channel,omitempty" bson:"channel,omitempty"` // Empty for every channel
+	Language  string             `json:"language" bson:"language"`
+	Subject   string             `json:"subject,omitempty" bson:"subject,omitempty"`
+	Body      string             `json:"body" bson:"body"`
+	IsActive  bool               `json:"is_active" bson:"is_active"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type NotificationTemplateRequest struct {
+	Event    string `json:"event" binding:"required"`
+	Channel  string `json:"channel"`
+	Language string `json:"language" binding:"required"`
+	Subject  string `json:"subject"`
+	Body     string `json:"body" binding:"required"`
+	IsActive *bool  `json:"is_active"`
+}
+
+// NotificationPreferences are a user's language and the channels they don't
+// want messages on
+type NotificationPreferences struct {
+	Language      *string  `json:"language"`
+	MutedChannels []string `json:"muted_channels"`
+}
+
+// SendNotificationRequest sends an event's message to a user, for testing
+// templates or resending by hand
+type SendNotificationRequest struct {
+	UserID    primitive.ObjectID `json:"user_id" binding:"required"`
+	Event     string             `json:"event" binding:"required"`
+	Variables map[string]string  `json:"variables"`
+}
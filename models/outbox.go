@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "PENDING"   // Waiting for its first or next attempt
+	OutboxSending   OutboxStatus = "SENDING"   // Claimed by a worker
+	OutboxSent      OutboxStatus = "SENT"      // Accepted by the provider
+	OutboxDelivered OutboxStatus = "DELIVERED" // Reached the recipient, per provider callback
+	OutboxRead      OutboxStatus = "READ"
+	OutboxFailed    OutboxStatus = "FAILED" // Provider reported it undeliverable after accepting it
+	OutboxDead      OutboxStatus = "DEAD"   // Gave up after the maximum attempts
+)
+
+// OutboxEvent is an entry in the history of an outbox message
+type OutboxEvent struct {
+	Status OutboxStatus        `json:"status" bson:"status"`
+	Error  string              `json:"error,omitempty" bson:"error,omitempty"`
+	By     *primitive.ObjectID `json:"by,omitempty" bson:"by,omitempty"` // Staff who resent it
+	At     time.Time           `json:"at" bson:"at"`
+}
+
+// OutboxMessage is a rendered notification waiting for, or after, delivery
+// on one channel. Workers claim messages by setting SENDING with a lease in
+// LockedUntil, so a message held by a crashed worker is picked up again.
+type OutboxMessage struct {
+	ID                primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID            primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Event             string             `json:"event" bson:"event"`
+	Channel           string             `json:"channel" bson:"channel"`
+	To                string             `json:"to" bson:"to"`
+	Language          string             `json:"language" bson:"language"`
+	Subject           string             `json:"subject,omitempty" bson:"subject,omitempty"`
+	Body              string             `json:"body" bson:"body"`
+	Status            OutboxStatus       `json:"status" bson:"status"`
+	Attempts          int                `json:"attempts" bson:"attempts"`
+	NextAttemptAt     time.Time          `json:"next_attempt_at" bson:"next_attempt_at"`
+	LockedUntil       *time.Time         `json:"locked_until,omitempty" bson:"locked_until,omitempty"`
+	LastError         string             `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	ProviderMessageID string             `json:"provider_message_id,omitempty" bson:"provider_message_id,omitempty"`
+	History           []OutboxEvent      `json:"history,omitempty" bson:"history,omitempty"`
+	CreatedAt         time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at" bson:"updated_at"`
+	SentAt            *time.Time         `json:"sent_at,omitempty" bson:"sent_at,omitempty"`
+	DeliveredAt       *time.Time         `json:"delivered_at,omitempty" bson:"delivered_at,omitempty"`
+}
+
+// OutboxStats counts outbox messages by channel and status
+type OutboxStats struct {
+	Channel string       `json:"channel" bson:"channel"`
+	Status  OutboxStatus `json:"status" bson:"status"`
+	Count   int          `json:"count" bson:"count"`
+}
@@ -17,6 +17,23 @@ const (
 	PaymentStatusRefunded  PaymentStatus = "REFUNDED"
 )
 
+// PaymentMethod is how a payment was collected. Payments without one went
+// through Razorpay.
+type PaymentMethod string
+
+const (
+	PaymentMethodRazorpay     PaymentMethod = "RAZORPAY"
+	PaymentMethodCash         PaymentMethod = "CASH"
+	PaymentMethodUPI          PaymentMethod = "UPI"
+	PaymentMethodCheque       PaymentMethod = "CHEQUE"
+	PaymentMethodBankTransfer PaymentMethod = "BANK_TRANSFER"
+)
+
+// IsOffline reports whether the payment was collected at the desk
+func (m PaymentMethod) IsOffline() bool {
+	return m != "" && m != PaymentMethodRazorpay
+}
+
 type PaymentType string
 
 const (
@@ -26,15 +43,29 @@ const (
 	PaymentTypeOther       PaymentType = "OTHER"
 )
 
+// IsValid reports whether t is one of the payment types above
+func (t PaymentType) IsValid() bool {
+	switch t {
+	case PaymentTypeRoomBooking, PaymentTypeFoodPass, PaymentTypeDeposit, PaymentTypeOther:
+		return true
+	}
+	return false
+}
+
 // Payment represents a payment transaction
 type Payment struct {
 	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	UserID          primitive.ObjectID `json:"user_id" bson:"user_id"`
 	RequestID       *primitive.ObjectID `json:"request_id,omitempty" bson:"request_id,omitempty"`
+	QuoteID         *primitive.ObjectID `json:"quote_id,omitempty" bson:"quote_id,omitempty"`
+	AssignmentID    *primitive.ObjectID `json:"assignment_id,omitempty" bson:"assignment_id,omitempty"` // Stay the guest passes paid for are linked to
 	Amount          int                `json:"amount" bson:"amount"` // Amount in paise
 	Currency        string             `json:"currency" bson:"currency"`
 	Type            PaymentType        `json:"type" bson:"type"`
 	Status          PaymentStatus      `json:"status" bson:"status"`
+	Method          PaymentMethod      `json:"method,omitempty" bson:"method,omitempty"`
+	ReferenceNumber string             `json:"reference_number,omitempty" bson:"reference_number,omitempty"` // UPI, cheque or transfer reference
+	CollectedBy     *primitive.ObjectID `json:"collected_by,omitempty" bson:"collected_by,omitempty"`
 	RazorpayOrderID string             `json:"razorpay_order_id,omitempty" bson:"razorpay_order_id,omitempty"`
 	RazorpayPaymentID string           `json:"razorpay_payment_id,omitempty" bson:"razorpay_payment_id,omitempty"`
 	RazorpaySignature string           `json:"razorpay_signature,omitempty" bson:"razorpay_signature,omitempty"`
@@ -43,21 +74,47 @@ type Payment struct {
 	FailureReason   string             `json:"failure_reason,omitempty" bson:"failure_reason,omitempty"`
 	RefundID        string             `json:"refund_id,omitempty" bson:"refund_id,omitempty"`
 	RefundedAmount  int                `json:"refunded_amount,omitempty" bson:"refunded_amount,omitempty"`
+	RefundedBy      *primitive.ObjectID `json:"refunded_by,omitempty" bson:"refunded_by,omitempty"` // Staff who paid out an offline refund
+	RefundedAt      *time.Time         `json:"refunded_at,omitempty" bson:"refunded_at,omitempty"`
+	InvoiceID       *primitive.ObjectID `json:"invoice_id,omitempty" bson:"invoice_id,omitempty"`
+	CreditNoteID    *primitive.ObjectID `json:"credit_note_id,omitempty" bson:"credit_note_id,omitempty"`
 	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt       time.Time          `json:"updated_at" bson:"updated_at"`
 	PaidAt          *time.Time         `json:"paid_at,omitempty" bson:"paid_at,omitempty"`
 }
 
 // CreatePaymentRequest represents a request to create a new payment
+// Amount, currency, type and description are required unless QuoteID is
+// given, in which case the quote's total is charged as a room booking.
 type CreatePaymentRequest struct {
-	Amount      int               `json:"amount" binding:"required"` // Amount in paise
-	Currency    string            `json:"currency" binding:"required"`
-	Type        PaymentType       `json:"type" binding:"required"`
+	Amount      int               `json:"amount"` // Amount in paise
+	Currency    string            `json:"currency"`
+	Type        PaymentType       `json:"type"`
 	RequestID   *string           `json:"request_id,omitempty"`
-	Description string            `json:"description" binding:"required"`
+	QuoteID     *string           `json:"quote_id,omitempty"`
+	Description string            `json:"description"`
 	Notes       map[string]string `json:"notes,omitempty"`
 }
 
+// RecordOfflinePaymentRequest records a payment taken at the front desk.
+// ReferenceNumber is required for every method except cash. As with online
+// payments, QuoteID fixes the amount. A payment still awaiting payment online,
+// given by PaymentID or found for the request, is settled instead of
+// recording a second one.
+type RecordOfflinePaymentRequest struct {
+	UserID          primitive.ObjectID `json:"user_id" binding:"required"`
+	PaymentID       *string            `json:"payment_id,omitempty"`
+	Method          PaymentMethod      `json:"method" binding:"required"`
+	ReferenceNumber string             `json:"reference_number"`
+	Amount          int                `json:"amount"` // Amount in paise
+	Currency        string             `json:"currency"`
+	Type            PaymentType        `json:"type"`
+	RequestID       *string            `json:"request_id,omitempty"`
+	QuoteID         *string            `json:"quote_id,omitempty"`
+	Description     string             `json:"description"`
+	Notes           map[string]string  `json:"notes,omitempty"`
+}
+
 // VerifyPaymentRequest represents a request to verify a payment
 type VerifyPaymentRequest struct {
 	OrderID   string `json:"order_id" binding:"required"`
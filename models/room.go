@@ -6,17 +6,9 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// RoomType is the code of a room type defined in the room_types collection
 type RoomType string
 
-const (
-	ShreeHariPlus RoomType = "SHREEHARIPLUS"
-	ShreeHari     RoomType = "SHREEHARI"
-	SarjuPlus     RoomType = "SARJUPLUS"
-	Sarju         RoomType = "SARJU"
-	NeelkanthPlus RoomType = "NEELKANTHPLUS"
-	Neelkanth     RoomType = "NEELKANTH"
-)
-
 type BedType string
 
 const (
@@ -30,10 +22,20 @@ type Bed struct {
 	Quantity int     `json:"quantity" bson:"quantity"`
 }
 
+// RoomImage is a photo of a room or room category. Images uploaded through
+// the media endpoints carry their storage keys; older entries only have a URL.
 type RoomImage struct {
-	URL         string    `json:"url" bson:"url"`
-	Description string    `json:"description" bson:"description"`
-	UploadedAt  time.Time `json:"uploaded_at" bson:"uploaded_at"`
+	ID           primitive.ObjectID `json:"id,omitempty" bson:"id,omitempty"`
+	URL          string             `json:"url" bson:"url"`
+	ThumbnailURL string             `json:"thumbnail_url,omitempty" bson:"thumbnail_url,omitempty"`
+	Key          string             `json:"-" bson:"key,omitempty"`
+	ThumbnailKey string             `json:"-" bson:"thumbnail_key,omitempty"`
+	ContentType  string             `json:"content_type,omitempty" bson:"content_type,omitempty"`
+	Size         int64              `json:"size,omitempty" bson:"size,omitempty"`
+	Width        int                `json:"width,omitempty" bson:"width,omitempty"`
+	Height       int                `json:"height,omitempty" bson:"height,omitempty"`
+	Description  string             `json:"description" bson:"description"`
+	UploadedAt   time.Time          `json:"uploaded_at" bson:"uploaded_at"`
 }
 
 type Room struct {
@@ -54,6 +56,8 @@ type Room struct {
 	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt       time.Time          `json:"updated_at" bson:"updated_at"`
 	RoomCategoryId  string             `json:"room_category_id,omitempty" bson:"room_category_id,omitempty"`
+	Images          []RoomImage        `json:"images,omitempty" bson:"images,omitempty"`
+	OutOfOrder      bool               `json:"out_of_order" bson:"-"` // Computed from open maintenance tickets
 }
 
 type CreateRoomRequest struct {
@@ -88,13 +92,14 @@ type UpdateRoomRequest struct {
 type RoomCategory struct {
 	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	RoomName  string             `json:"room_name" bson:"room_name"`
-	Images    []RoomImage        `json:"images"`
+	Images    []RoomImage        `json:"images" bson:"images"`
 	Price     string             `json:"price" bson:"price"`
 	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
 }
 
-// RoomTypeCost stores the deposit cost for each room type (Sarju, Shri Hari, etc.)
+// RoomTypeCost is the deposit view of a room type served by the legacy
+// /room-type-costs endpoints. Deposits now live on RoomTypeConfig.
 type RoomTypeCost struct {
 	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	RoomType      RoomType           `json:"room_type" bson:"room_type"`           // SARJU, SHREEHARI, etc.
@@ -106,15 +111,34 @@ type RoomTypeCost struct {
 	UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
 }
 
-// GetDefaultRoomTypeCosts returns default deposit amounts for each room type
-func GetDefaultRoomTypeCosts() []RoomTypeCost {
-	now := time.Now()
-	return []RoomTypeCost{
-		{RoomType: ShreeHariPlus, DepositAmount: 50000, Currency: "INR", Description: "Shree Hari Plus rooms", IsActive: true, CreatedAt: now, UpdatedAt: now},
-		{RoomType: ShreeHari, DepositAmount: 30000, Currency: "INR", Description: "Shree Hari rooms", IsActive: true, CreatedAt: now, UpdatedAt: now},
-		{RoomType: SarjuPlus, DepositAmount: 50000, Currency: "INR", Description: "Sarju Plus rooms", IsActive: true, CreatedAt: now, UpdatedAt: now},
-		{RoomType: Sarju, DepositAmount: 30000, Currency: "INR", Description: "Sarju rooms", IsActive: true, CreatedAt: now, UpdatedAt: now},
-		{RoomType: NeelkanthPlus, DepositAmount: 50000, Currency: "INR", Description: "Neelkanth Plus rooms", IsActive: true, CreatedAt: now, UpdatedAt: now},
-		{RoomType: Neelkanth, DepositAmount: 30000, Currency: "INR", Description: "Neelkanth rooms", IsActive: true, CreatedAt: now, UpdatedAt: now},
-	}
+type RoomImportAction string
+
+const (
+	RoomImportCreate RoomImportAction = "CREATE"
+	RoomImportUpdate RoomImportAction = "UPDATE"
+	RoomImportSkip   RoomImportAction = "SKIP"
+	RoomImportError  RoomImportAction = "ERROR"
+)
+
+// RoomImportRow is the outcome of one data row of a room import file
+type RoomImportRow struct {
+	Line       int              `json:"line"`
+	RoomNumber string           `json:"room_number,omitempty"`
+	Building   string           `json:"building,omitempty"`
+	Action     RoomImportAction `json:"action"`
+	Errors     []string         `json:"errors,omitempty"`
+	Warnings   []string         `json:"warnings,omitempty"`
+}
+
+// RoomImportReport summarises a room import. In dry-run mode nothing is written
+// and the actions say what would have happened.
+type RoomImportReport struct {
+	DryRun    bool            `json:"dry_run"`
+	Upsert    bool            `json:"upsert"`
+	TotalRows int             `json:"total_rows"`
+	Created   int             `json:"created"`
+	Updated   int             `json:"updated"`
+	Skipped   int             `json:"skipped"`
+	Failed    int             `json:"failed"`
+	Rows      []RoomImportRow `json:"rows"`
 }
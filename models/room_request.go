@@ -12,6 +12,7 @@ const (
 	StatusPending  RequestStatus = "PENDING"
 	StatusApproved RequestStatus = "APPROVED"
 	StatusRejected RequestStatus = "REJECTED"
+	StatusNoShow   RequestStatus = "NO_SHOW" // Approved but never checked in, set by the night audit
 )
 
 type PeopleCount struct {
@@ -36,11 +37,14 @@ type RoomRequest struct {
 	Status          RequestStatus       `json:"status" bson:"status"`
 	ProcessedBy     *primitive.ObjectID `json:"processed_by,omitempty" bson:"processed_by,omitempty"`
 	ProcessedAt     *time.Time          `json:"processed_at,omitempty" bson:"processed_at,omitempty"`
+	RejectionReason string              `json:"rejection_reason,omitempty" bson:"rejection_reason,omitempty"`
 	CreatedAt       time.Time           `json:"created_at" bson:"created_at"`
 	UpdatedAt       time.Time           `json:"updated_at" bson:"updated_at"`
 	Reference       string              `json:"reference" bson:"reference"`
 	PublicID        string              `json:"public_id" bson:"public_id"`
-	ChitthiURL      string              `json:"chitthi_url" bson:"chitthi_url"`
+	ChitthiURL      string              `json:"chitthi_url" bson:"chitthi_url"` // API path of the chitthi, see Chitthi
+	Chitthi         *ChitthiFile        `json:"chitthi,omitempty" bson:"chitthi,omitempty"`
+	History         []RoomRequestEvent  `json:"history,omitempty" bson:"history,omitempty"`
 }
 
 type RoomAssignment struct {
@@ -58,6 +62,10 @@ type RoomAssignment struct {
 	CheckedInAt          *time.Time         `json:"checked_in_at,omitempty" bson:"checked_in_at,omitempty"`
 	CheckedOut           bool               `json:"checked_out" bson:"checked_out"`
 	CheckedOutAt         *time.Time         `json:"checked_out_at,omitempty" bson:"checked_out_at,omitempty"`
+	CheckInReminderAt    *time.Time         `json:"check_in_reminder_at,omitempty" bson:"check_in_reminder_at,omitempty"`
+	CheckOutReminderAt   *time.Time         `json:"check_out_reminder_at,omitempty" bson:"check_out_reminder_at,omitempty"`
+	NoShow               bool               `json:"no_show,omitempty" bson:"no_show,omitempty"` // Never arrived, the room was released
+	NoShowAt             *time.Time         `json:"no_show_at,omitempty" bson:"no_show_at,omitempty"`
 	DepositPaid          *int               `json:"deposit_paid,omitempty" bson:"deposit_paid,omitempty"`
 	IsFOC                *bool              `json:"is_foc,omitempty" bson:"is_foc,omitempty"`
 	PaymentID            *primitive.ObjectID `json:"payment_id,omitempty" bson:"payment_id,omitempty"` // Link to payment record
@@ -105,10 +113,11 @@ type CreateRoomRequestRequest struct {
 }
 
 type ProcessRoomRequestRequest struct {
-	Status      RequestStatus       `json:"status" binding:"required"`
-	RoomID      *primitive.ObjectID `json:"room_id"`
-	DepositPaid *int                `json:"deposit_paid"`
-	IsFOC       *bool               `json:"is_foc"`
+	Status          RequestStatus       `json:"status" binding:"required"`
+	RoomID          *primitive.ObjectID `json:"room_id"`
+	DepositPaid     *int                `json:"deposit_paid"`
+	IsFOC           *bool               `json:"is_foc"`
+	RejectionReason string              `json:"rejection_reason"` // Kept for analytics when Status is REJECTED
 }
 
 // type RoomAssignmentRequest struct {
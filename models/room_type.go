@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RoomTypeConfig defines a room type in the room_types collection. Rooms
+// store the code; the aliases, display name and linked user type are other
+// spellings accepted wherever a room or user type is given as input.
+type RoomTypeConfig struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Code            RoomType           `json:"code" bson:"code"` // e.g. SHREEHARIPLUS
+	DisplayName     string             `json:"display_name" bson:"display_name"`
+	Aliases         []string           `json:"aliases,omitempty" bson:"aliases,omitempty"`
+	IsPlus          bool               `json:"is_plus" bson:"is_plus"`                                       // Plus tier, free of cost for the linked user type
+	DefaultCapacity int                `json:"default_capacity,omitempty" bson:"default_capacity,omitempty"` // Guests a room of this type sleeps
+	DefaultBeds     []Bed              `json:"default_beds,omitempty" bson:"default_beds,omitempty"`
+	DepositAmount   int                `json:"deposit_amount" bson:"deposit_amount"` // Amount in paise
+	Currency        string             `json:"currency" bson:"currency"`
+	UserType        string             `json:"user_type,omitempty" bson:"user_type,omitempty"` // Customer category for this tier, e.g. "Shri Hari+"
+	SortOrder       int                `json:"sort_order" bson:"sort_order"`
+	IsActive        bool               `json:"is_active" bson:"is_active"`
+	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type CreateRoomTypeRequest struct {
+	Code            string   `json:"code" binding:"required"`
+	DisplayName     string   `json:"display_name" binding:"required"`
+	Aliases         []string `json:"aliases"`
+	IsPlus          bool     `json:"is_plus"`
+	DefaultCapacity int      `json:"default_capacity"`
+	DefaultBeds     []Bed    `json:"default_beds"`
+	DepositAmount   int      `json:"deposit_amount"`
+	Currency        string   `json:"currency"`
+	UserType        string   `json:"user_type"`
+	SortOrder       int      `json:"sort_order"`
+	IsActive        *bool    `json:"is_active"`
+}
+
+// UpdateRoomTypeRequest changes a room type. The code can't be changed since
+// rooms refer to it.
+type UpdateRoomTypeRequest struct {
+	DisplayName     *string  `json:"display_name"`
+	Aliases         []string `json:"aliases"`
+	IsPlus          *bool    `json:"is_plus"`
+	DefaultCapacity *int     `json:"default_capacity"`
+	DefaultBeds     []Bed    `json:"default_beds"`
+	DepositAmount   *int     `json:"deposit_amount"`
+	Currency        *string  `json:"currency"`
+	UserType        *string  `json:"user_type"`
+	SortOrder       *int     `json:"sort_order"`
+	IsActive        *bool    `json:"is_active"`
+}
+
+// DefaultRoomTypes returns the room types seeded into an empty room_types
+// collection, with the spellings used before room types were configurable
+func DefaultRoomTypes() []RoomTypeConfig {
+	now := time.Now()
+	defaults := []RoomTypeConfig{
+		{Code: "SHREEHARIPLUS", DisplayName: "Shree Hari Plus", Aliases: []string{"Shri Hari+", "Shree Hari+", "Shri Hari Plus"}, IsPlus: true, DepositAmount: 50000, UserType: "Shri Hari+"},
+		{Code: "SHREEHARI", DisplayName: "Shree Hari", Aliases: []string{"Shri Hari"}, DepositAmount: 30000, UserType: "Shri Hari"},
+		{Code: "SARJUPLUS", DisplayName: "Sarju Plus", Aliases: []string{"Sarju+"}, IsPlus: true, DepositAmount: 50000, UserType: "Sarju+"},
+		{Code: "SARJU", DisplayName: "Sarju", DepositAmount: 30000, UserType: "Sarju"},
+		{Code: "NEELKANTHPLUS", DisplayName: "Neelkanth Plus", Aliases: []string{"Neelkanth+"}, IsPlus: true, DepositAmount: 50000, UserType: "Neelkanth+"},
+		{Code: "NEELKANTH", DisplayName: "Neelkanth", DepositAmount: 30000, UserType: "Neelkanth"},
+	}
+	for i := range defaults {
+		defaults[i].Currency = "INR"
+		defaults[i].SortOrder = i + 1
+		defaults[i].IsActive = true
+		defaults[i].CreatedAt = now
+		defaults[i].UpdatedAt = now
+	}
+	return defaults
+}
+
+// RoomTypeMigrationReport summarises a run of the room type migration
+type RoomTypeMigrationReport struct {
+	TypesSeeded      int      `json:"types_seeded"`
+	TypesCreated     []string `json:"types_created"`
+	RoomsUpdated     int64    `json:"rooms_updated"`
+	UsersUpdated     int64    `json:"users_updated"`
+	UnknownUserTypes []string `json:"unknown_user_types"`
+}
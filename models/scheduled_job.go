@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type JobRunStatus string
+
+const (
+	JobRunRunning JobRunStatus = "RUNNING"
+	JobRunSuccess JobRunStatus = "SUCCESS"
+	JobRunFailed  JobRunStatus = "FAILED"
+)
+
+type JobTrigger string
+
+const (
+	JobTriggerSchedule JobTrigger = "SCHEDULE"
+	JobTriggerManual   JobTrigger = "MANUAL"
+)
+
+// ScheduledJob stores the DB overrides and runtime state for a background job.
+// Cron, Timezone and Enabled override the defaults registered in code; env vars
+// (JOB_<NAME>_CRON, JOB_<NAME>_TIMEZONE, JOB_<NAME>_ENABLED) override both.
+type ScheduledJob struct {
+	Name      string     `json:"name" bson:"_id"`
+	Cron      string     `json:"cron,omitempty" bson:"cron,omitempty"`
+	Timezone  string     `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	Enabled   *bool      `json:"enabled,omitempty" bson:"enabled,omitempty"`
+	Paused    bool       `json:"paused" bson:"paused"`
+	PausedBy  string     `json:"paused_by,omitempty" bson:"paused_by,omitempty"`
+	PausedAt  *time.Time `json:"paused_at,omitempty" bson:"paused_at,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at" bson:"updated_at"`
+}
+
+// JobLock is a lease held by the replica currently running a job
+type JobLock struct {
+	Name        string    `json:"name" bson:"_id"`
+	LockedBy    string    `json:"locked_by" bson:"locked_by"`
+	LockedAt    time.Time `json:"locked_at" bson:"locked_at"`
+	LockedUntil time.Time `json:"locked_until" bson:"locked_until"`
+	LastSlot    string    `json:"last_slot" bson:"last_slot"` // Schedule slot last claimed, so replicas don't repeat a tick
+}
+
+// JobRun is one execution of a background job
+type JobRun struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	JobName     string             `json:"job_name" bson:"job_name"`
+	Trigger     JobTrigger         `json:"trigger" bson:"trigger"`
+	TriggeredBy string             `json:"triggered_by,omitempty" bson:"triggered_by,omitempty"`
+	Instance    string             `json:"instance" bson:"instance"`
+	Status      JobRunStatus       `json:"status" bson:"status"`
+	Result      string             `json:"result,omitempty" bson:"result,omitempty"`
+	Error       string             `json:"error,omitempty" bson:"error,omitempty"`
+	StartedAt   time.Time          `json:"started_at" bson:"started_at"`
+	FinishedAt  *time.Time         `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+	DurationMs  int64              `json:"duration_ms" bson:"duration_ms"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// SchemaMigration records a database migration that has been applied. There
+// is one per version in the schema_migrations collection.
+type SchemaMigration struct {
+	Version    int       `json:"version" bson:"_id"`
+	Name       string    `json:"name" bson:"name"`
+	AppliedAt  time.Time `json:"applied_at" bson:"applied_at"`
+	DurationMs int64     `json:"duration_ms" bson:"duration_ms"`
+}
+
+// MigrationStatus is a known migration and whether it has been applied
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"` // Unset while pending
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tariff is the nightly rate for a room type, or for a room category when
+// RoomCategoryID is set. A category tariff wins over its room type's tariff.
+type Tariff struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name           string             `json:"name" bson:"name"`
+	RoomType       RoomType           `json:"room_type,omitempty" bson:"room_type,omitempty"`
+	RoomCategoryID string             `json:"room_category_id,omitempty" bson:"room_category_id,omitempty"`
+	NightlyRate    int                `json:"nightly_rate" bson:"nightly_rate"`         // Amount in paise
+	BaseOccupancy  int                `json:"base_occupancy" bson:"base_occupancy"`     // Guests included in the nightly rate
+	ExtraAdultRate int                `json:"extra_adult_rate" bson:"extra_adult_rate"` // Per extra adult per night, in paise
+	ExtraChildRate int                `json:"extra_child_rate" bson:"extra_child_rate"` // Per extra child per night, in paise
+	Currency       string             `json:"currency" bson:"currency"`
+	IsActive       bool               `json:"is_active" bson:"is_active"`
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type TariffOverrideKind string
+
+const (
+	OverrideSeason   TariffOverrideKind = "SEASON"
+	OverrideFestival TariffOverrideKind = "FESTIVAL"
+)
+
+// TariffOverride changes the nightly rate for a date range, either to a
+// fixed NightlyRate or by AdjustmentPercent of the base rate. Without a room
+// type or category it applies to every room. Festivals win over seasons.
+type TariffOverride struct {
+	ID                primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name              string             `json:"name" bson:"name"`
+	Kind              TariffOverrideKind `json:"kind" bson:"kind"`
+	StartDate         time.Time          `json:"start_date" bson:"start_date"` // First night, IST
+	EndDate           time.Time          `json:"end_date" bson:"end_date"`     // Last night, IST
+	RoomType          RoomType           `json:"room_type,omitempty" bson:"room_type,omitempty"`
+	RoomCategoryID    string             `json:"room_category_id,omitempty" bson:"room_category_id,omitempty"`
+	NightlyRate       int                `json:"nightly_rate,omitempty" bson:"nightly_rate,omitempty"`
+	AdjustmentPercent int                `json:"adjustment_percent,omitempty" bson:"adjustment_percent,omitempty"` // e.g. 25 or -10
+	IsActive          bool               `json:"is_active" bson:"is_active"`
+	CreatedAt         time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type TariffRequest struct {
+	Name           string   `json:"name" binding:"required"`
+	RoomType       RoomType `json:"room_type"`
+	RoomCategoryID string   `json:"room_category_id"`
+	NightlyRate    int      `json:"nightly_rate"`
+	BaseOccupancy  int      `json:"base_occupancy"`
+	ExtraAdultRate int      `json:"extra_adult_rate"`
+	ExtraChildRate int      `json:"extra_child_rate"`
+	Currency       string   `json:"currency"`
+	IsActive       *bool    `json:"is_active"`
+}
+
+type TariffOverrideRequest struct {
+	Name              string             `json:"name" binding:"required"`
+	Kind              TariffOverrideKind `json:"kind" binding:"required"`
+	StartDate         time.Time          `json:"start_date" binding:"required"`
+	EndDate           time.Time          `json:"end_date" binding:"required"`
+	RoomType          RoomType           `json:"room_type"`
+	RoomCategoryID    string             `json:"room_category_id"`
+	NightlyRate       int                `json:"nightly_rate"`
+	AdjustmentPercent int                `json:"adjustment_percent"`
+	IsActive          *bool              `json:"is_active"`
+}
+
+type QuoteLineKind string
+
+const (
+	QuoteLineNight      QuoteLineKind = "NIGHT"
+	QuoteLineExtraAdult QuoteLineKind = "EXTRA_ADULT"
+	QuoteLineExtraChild QuoteLineKind = "EXTRA_CHILD"
+	QuoteLineDiscount   QuoteLineKind = "DISCOUNT"
+)
+
+type QuoteLine struct {
+	Kind        QuoteLineKind `json:"kind" bson:"kind"`
+	Description string        `json:"description" bson:"description"`
+	Date        *time.Time    `json:"date,omitempty" bson:"date,omitempty"` // Night the line is for
+	Quantity    int           `json:"quantity" bson:"quantity"`
+	UnitAmount  int           `json:"unit_amount" bson:"unit_amount"` // Amount in paise
+	Amount      int           `json:"amount" bson:"amount"`           // Negative for discounts
+}
+
+type QuoteStatus string
+
+const (
+	QuoteOpen QuoteStatus = "OPEN"
+	QuotePaid QuoteStatus = "PAID"
+)
+
+// Quote is an itemized price for a stay. It is stored so a payment charges
+// the quoted total rather than an amount sent by the client.
+type Quote struct {
+	ID              primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	UserID          primitive.ObjectID  `json:"user_id" bson:"user_id"`
+	RequestID       *primitive.ObjectID `json:"request_id,omitempty" bson:"request_id,omitempty"`
+	RoomType        RoomType            `json:"room_type" bson:"room_type"`
+	RoomCategoryID  string              `json:"room_category_id,omitempty" bson:"room_category_id,omitempty"`
+	TariffID        primitive.ObjectID  `json:"tariff_id" bson:"tariff_id"`
+	CheckInDate     time.Time           `json:"check_in_date" bson:"check_in_date"`
+	CheckOutDate    time.Time           `json:"check_out_date" bson:"check_out_date"`
+	Nights          int                 `json:"nights" bson:"nights"`
+	NumberOfPeople  PeopleCount         `json:"number_of_people" bson:"number_of_people"`
+	UserType        string              `json:"user_type,omitempty" bson:"user_type,omitempty"`
+	Lines           []QuoteLine         `json:"lines" bson:"lines"`
+	Subtotal        int                 `json:"subtotal" bson:"subtotal"`
+	Discount        int                 `json:"discount" bson:"discount"`
+	DiscountPercent int                 `json:"discount_percent,omitempty" bson:"discount_percent,omitempty"`
+	Total           int                 `json:"total" bson:"total"` // Amount in paise
+	Currency        string              `json:"currency" bson:"currency"`
+	IsFOC           bool                `json:"is_foc" bson:"is_foc"`
+	Status          QuoteStatus         `json:"status" bson:"status"`
+	PaymentID       *primitive.ObjectID `json:"payment_id,omitempty" bson:"payment_id,omitempty"`
+	ExpiresAt       time.Time           `json:"expires_at" bson:"expires_at"`
+	CreatedAt       time.Time           `json:"created_at" bson:"created_at"`
+}
+
+// QuoteRequest asks for the price of a stay. With RequestID the dates and
+// guests come from the room request. Staff can quote for another user.
+type QuoteRequest struct {
+	RequestID      *primitive.ObjectID `json:"request_id"`
+	RoomType       string              `json:"room_type"`
+	RoomCategoryID string              `json:"room_category_id"`
+	CheckInDate    time.Time           `json:"check_in_date"`
+	CheckOutDate   time.Time           `json:"check_out_date"`
+	NumberOfPeople PeopleCount         `json:"number_of_people"`
+	UserID         *primitive.ObjectID `json:"user_id"`
+}
@@ -9,9 +9,10 @@ import (
 type UserRole string
 
 const (
-	RoleSuperAdmin UserRole = "SUPER_ADMIN"
-	RoleStaff      UserRole = "STAFF"
-	RoleUser       UserRole = "USER"
+	RoleSuperAdmin   UserRole = "SUPER_ADMIN"
+	RoleStaff        UserRole = "STAFF"
+	RoleHousekeeping UserRole = "HOUSEKEEPING"
+	RoleUser         UserRole = "USER"
 )
 
 type User struct {
@@ -29,19 +30,22 @@ type User struct {
 	OtpExpiry      time.Time          `json:"otp_expiry,omitempty" bson:"otp_expiry,omitempty"`
 	TotalBookings  int                `json:"total_bookings" bson:"total_bookings"`
 	LastBookingAt  *time.Time         `json:"last_booking_at,omitempty" bson:"last_booking_at,omitempty"`
+	Language       string             `json:"language,omitempty" bson:"language,omitempty"`             // Notification language, e.g. "en" or "hi"
+	MutedChannels  []string           `json:"muted_channels,omitempty" bson:"muted_channels,omitempty"` // Notification channels opted out of
 	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
 }
 
 // UserTypeConfig stores deposit configuration for each customer category/type
 type UserTypeConfig struct {
-	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	UserType      string             `json:"user_type" bson:"user_type"`           // e.g., "Plus", "Regular", "VIP"
-	DepositAmount int                `json:"deposit_amount" bson:"deposit_amount"` // Amount in paise (0 for free/FOC)
-	IsFOC         bool               `json:"is_foc" bson:"is_foc"`                 // Free of cost - no deposit required
-	Description   string             `json:"description" bson:"description"`
-	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserType        string             `json:"user_type" bson:"user_type"`               // e.g., "Plus", "Regular", "VIP"
+	DepositAmount   int                `json:"deposit_amount" bson:"deposit_amount"`     // Amount in paise (0 for free/FOC)
+	IsFOC           bool               `json:"is_foc" bson:"is_foc"`                     // Free of cost - no deposit required
+	DiscountPercent int                `json:"discount_percent" bson:"discount_percent"` // Discount on quoted room charges
+	Description     string             `json:"description" bson:"description"`
+	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at" bson:"updated_at"`
 }
 
 type LoginRequest struct {
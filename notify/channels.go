@@ -0,0 +1,251 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// postJSON sends a JSON request to a messaging gateway, failing on any non
+// 2xx status, and returns the response body
+func postJSON(ctx context.Context, url, token string, payload any) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling JSON: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail := strings.TrimSpace(string(respBody))
+		if len(detail) > 512 {
+			detail = detail[:512]
+		}
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, detail)
+	}
+	return respBody, nil
+}
+
+// messageID finds the message ID in a gateway response such as
+// {"message": {"id": "..."}} or {"message_id": "..."}
+func messageID(body []byte) string {
+	var resp struct {
+		ID        string `json:"id"`
+		MessageID string `json:"message_id"`
+		Message   struct {
+			ID string `json:"id"`
+		} `json:"message"`
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return ""
+	}
+	for _, id := range []string{resp.Message.ID, resp.MessageID, resp.ID} {
+		if id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// WhatsApp sends text messages through whapi
+type WhatsApp struct {
+	BaseURL string
+	Token   string
+}
+
+// NewWhatsApp configures WhatsApp from WHATSAPP_API_BASE_URL and
+// WHATSAPP_API_TOKEN
+func NewWhatsApp() *WhatsApp {
+	return &WhatsApp{
+		BaseURL: getenv("WHATSAPP_API_BASE_URL", "https://gate.whapi.cloud"),
+		Token:   os.Getenv("WHATSAPP_API_TOKEN"),
+	}
+}
+
+func (w *WhatsApp) Name() string { return "whatsapp" }
+
+func (w *WhatsApp) Address(r Recipient) string { return digits(r.Phone) }
+
+func (w *WhatsApp) Send(ctx context.Context, to string, msg Message) (string, error) {
+	body, err := postJSON(ctx, w.BaseURL+"/messages/text", w.Token, map[string]string{
+		"to":   digits(to),
+		"body": msg.Body,
+	})
+	return messageID(body), err
+}
+
+// SMS sends text messages through an HTTP gateway which accepts a JSON body
+// of {"to", "from", "message"}
+type SMS struct {
+	URL    string
+	APIKey string
+	Sender string
+}
+
+// NewSMS configures SMS from SMS_API_URL, SMS_API_KEY and SMS_SENDER_ID
+func NewSMS() (*SMS, error) {
+	s := &SMS{
+		URL:    os.Getenv("SMS_API_URL"),
+		APIKey: os.Getenv("SMS_API_KEY"),
+		Sender: os.Getenv("SMS_SENDER_ID"),
+	}
+	if s.URL == "" {
+		return nil, fmt.Errorf("sms: SMS_API_URL is required")
+	}
+	return s, nil
+}
+
+func (s *SMS) Name() string { return "sms" }
+
+func (s *SMS) Address(r Recipient) string { return digits(r.Phone) }
+
+func (s *SMS) Send(ctx context.Context, to string, msg Message) (string, error) {
+	body, err := postJSON(ctx, s.URL, s.APIKey, map[string]string{
+		"to":      digits(to),
+		"from":    s.Sender,
+		"message": msg.Body,
+	})
+	return messageID(body), err
+}
+
+// Email sends plain text mail over SMTP. Port 465 uses implicit TLS, other
+// ports upgrade with STARTTLS when the server offers it.
+type Email struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// NewEmail configures Email from SMTP_HOST, SMTP_PORT (default 587),
+// SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM
+func NewEmail() (*Email, error) {
+	e := &Email{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     getenv("SMTP_PORT", "587"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	if e.From == "" {
+		e.From = e.Username
+	}
+	if e.Host == "" || e.From == "" {
+		return nil, fmt.Errorf("email: SMTP_HOST and SMTP_FROM are required")
+	}
+	return e, nil
+}
+
+func (e *Email) Name() string { return "email" }
+
+func (e *Email) Address(r Recipient) string { return r.Email }
+
+func (e *Email) Send(ctx context.Context, to string, msg Message) (string, error) {
+	id := fmt.Sprintf("<%d.%d@%s>", time.Now().UnixNano(), rand.Int63(), e.Host)
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "From: %s\r\n", e.From)
+	fmt.Fprintf(&body, "To: %s\r\n", to)
+	fmt.Fprintf(&body, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&body, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&body, "Message-ID: %s\r\n", id)
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	body.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	body.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	if err := e.send(ctx, to, body.Bytes()); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (e *Email) send(ctx context.Context, to string, data []byte) error {
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	}
+	addr := net.JoinHostPort(e.Host, e.Port)
+	if e.Port != "465" {
+		return smtp.SendMail(addr, auth, e.From, []string{to}, data)
+	}
+
+	dialer := &tls.Dialer{Config: &tls.Config{ServerName: e.Host}}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(e.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// Console logs messages instead of sending them, for development
+type Console struct{}
+
+func (Console) Name() string { return "console" }
+
+func (Console) Address(r Recipient) string {
+	if r.Phone != "" {
+		return r.Phone
+	}
+	return r.Email
+}
+
+func (Console) Send(ctx context.Context, to string, msg Message) (string, error) {
+	log.Printf("notify: to %s: %s", to, strings.ReplaceAll(msg.Body, "\n", " | "))
+	return "", nil
+}
@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Recipient is who a notification is for. Channels pick the address they
+// can deliver to.
+type Recipient struct {
+	Name     string
+	Phone    string
+	Email    string
+	Language string
+}
+
+// Message is a rendered notification. Subject is only used by email.
+type Message struct {
+	Subject string
+	Body    string
+}
+
+// Channel delivers messages over one medium
+type Channel interface {
+	Name() string
+	// Address returns where the channel would deliver to the recipient, or ""
+	// if it can't reach them
+	Address(r Recipient) string
+	// Send delivers the message and returns the provider's message ID, used
+	// to match delivery status callbacks, if it gives one
+	Send(ctx context.Context, to string, msg Message) (string, error)
+}
+
+// Channels are the enabled channels chosen by Init, the console until then
+var Channels = []Channel{Console{}}
+
+// Init enables the channels listed in NOTIFY_CHANNELS, a comma separated
+// list of "whatsapp", "sms", "email" and "console" (default "console").
+func Init() error {
+	names := os.Getenv("NOTIFY_CHANNELS")
+	if names == "" {
+		names = "console"
+	}
+
+	var channels []Channel
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		channel, err := newChannel(name)
+		if err != nil {
+			return err
+		}
+		channels = append(channels, channel)
+	}
+	Channels = channels
+	return nil
+}
+
+func newChannel(name string) (Channel, error) {
+	switch name {
+	case "whatsapp":
+		return NewWhatsApp(), nil
+	case "sms":
+		return NewSMS()
+	case "email":
+		return NewEmail()
+	case "console":
+		return Console{}, nil
+	}
+	return nil, fmt.Errorf("unknown notification channel %q", name)
+}
+
+// ByName returns an enabled channel
+func ByName(name string) (Channel, bool) {
+	for _, channel := range Channels {
+		if channel.Name() == name {
+			return channel, true
+		}
+	}
+	return nil, false
+}
+
+func getenv(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// digits strips everything but digits from a phone number, the format the
+// WhatsApp and SMS gateways expect
+func digits(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,205 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+type Event string
+
+const (
+	EventRequestReceived  Event = "REQUEST_RECEIVED"
+	EventRequestApproved  Event = "REQUEST_APPROVED"
+	EventRequestRejected  Event = "REQUEST_REJECTED"
+	EventRoomAssigned     Event = "ROOM_ASSIGNED"
+	EventCheckInReminder  Event = "CHECKIN_REMINDER"
+	EventCheckOutReminder Event = "CHECKOUT_REMINDER"
+	EventPaymentSuccess   Event = "PAYMENT_SUCCESS"
+	EventRefundProcessed  Event = "REFUND_PROCESSED"
+	EventCheckOutOverdue  Event = "CHECKOUT_OVERDUE" // Sent to staff
+)
+
+// DefaultLanguage is used when there is no template in the recipient's language
+const DefaultLanguage = "en"
+
+// EventVariables lists the variables each event's templates can use, besides
+// "name" which is always set to the recipient's name
+var EventVariables = map[Event][]string{
+	EventRequestReceived:  {"request_id", "check_in", "check_out"},
+	EventRequestApproved:  {"request_id", "check_in", "check_out"},
+	EventRequestRejected:  {"request_id", "check_in", "check_out"},
+	EventRoomAssigned:     {"room_number", "building", "dining_hall", "check_in", "check_out"},
+	EventCheckInReminder:  {"room_number", "building", "dining_hall", "check_in"},
+	EventCheckOutReminder: {"room_number", "building", "check_out"},
+	EventPaymentSuccess:   {"amount", "description", "invoice_number", "payment_id"},
+	EventRefundProcessed:  {"amount", "reason", "payment_id"},
+	EventCheckOutOverdue:  {"count", "rooms"},
+}
+
+// Template is the text for an event in one language. An empty Channel means
+// every channel. Subject and Body use text/template syntax, e.g. {{.name}}.
+type Template struct {
+	Event    Event  `json:"event"`
+	Channel  string `json:"channel,omitempty"`
+	Language string `json:"language"`
+	Subject  string `json:"subject,omitempty"`
+	Body     string `json:"body"`
+}
+
+// Render fills in the template. Missing variables render as empty text.
+func (t Template) Render(vars map[string]string) (Message, error) {
+	subject, err := execute(t.Subject, vars)
+	if err != nil {
+		return Message{}, fmt.Errorf("subject: %w", err)
+	}
+	body, err := execute(t.Body, vars)
+	if err != nil {
+		return Message{}, fmt.Errorf("body: %w", err)
+	}
+	return Message{Subject: subject, Body: body}, nil
+}
+
+// Validate checks the template parses
+func (t Template) Validate() error {
+	_, err := t.Render(map[string]string{})
+	return err
+}
+
+func execute(text string, vars map[string]string) (string, error) {
+	tmpl, err := template.New("").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// DefaultTemplate returns the built-in template of an event in a language
+func DefaultTemplate(event Event, language string) (Template, bool) {
+	for _, t := range defaultTemplates {
+		if t.Event == event && t.Language == language {
+			return t, true
+		}
+	}
+	return Template{}, false
+}
+
+// DefaultTemplates returns every built-in template
+func DefaultTemplates() []Template {
+	return append([]Template(nil), defaultTemplates...)
+}
+
+var defaultTemplates = []Template{
+	{
+		Event:    EventRequestReceived,
+		Language: "en",
+		Subject:  "We have received your room request",
+		Body:     "Hello {{.name}}, we have received your room request for {{.check_in}} to {{.check_out}}. We will let you know once it is reviewed.",
+	},
+	{
+		Event:    EventRequestReceived,
+		Language: "hi",
+		Subject:  "आपका कमरा अनुरोध प्राप्त हुआ",
+		Body:     "नमस्ते {{.name}}, {{.check_in}} से {{.check_out}} तक के लिए आपका कमरा अनुरोध हमें मिल गया है। समीक्षा के बाद हम आपको सूचित करेंगे।",
+	},
+	{
+		Event:    EventRequestApproved,
+		Language: "en",
+		Subject:  "Your room request is approved",
+		Body:     "Hello {{.name}}, your room request for {{.check_in}} to {{.check_out}} has been approved. Room details will follow.",
+	},
+	{
+		Event:    EventRequestApproved,
+		Language: "hi",
+		Subject:  "आपका कमरा अनुरोध स्वीकृत हुआ",
+		Body:     "नमस्ते {{.name}}, {{.check_in}} से {{.check_out}} तक के लिए आपका कमरा अनुरोध स्वीकृत हो गया है। कमरे की जानकारी जल्द भेजी जाएगी।",
+	},
+	{
+		Event:    EventRequestRejected,
+		Language: "en",
+		Subject:  "Your room request could not be accommodated",
+		Body:     "Hello {{.name}}, we are sorry, but we could not accommodate your room request for {{.check_in}} to {{.check_out}}. Any payment made will be refunded.",
+	},
+	{
+		Event:    EventRequestRejected,
+		Language: "hi",
+		Subject:  "आपका कमरा अनुरोध स्वीकार नहीं हो सका",
+		Body:     "नमस्ते {{.name}}, क्षमा करें, {{.check_in}} से {{.check_out}} तक के लिए आपका कमरा अनुरोध स्वीकार नहीं हो सका। किया गया कोई भी भुगतान वापस कर दिया जाएगा।",
+	},
+	{
+		Event:    EventRoomAssigned,
+		Language: "en",
+		Subject:  "Room {{.room_number}} is ready for your stay",
+		Body:     "Hello {{.name}}, your request is approved and room {{.room_number}}, {{.building}} is reserved for you from {{.check_in}} to {{.check_out}}.{{if .dining_hall}} Your meals are at {{.dining_hall}}.{{end}}",
+	},
+	{
+		Event:    EventRoomAssigned,
+		Language: "hi",
+		Subject:  "आपके लिए कमरा {{.room_number}} आरक्षित है",
+		Body:     "नमस्ते {{.name}}, आपका अनुरोध स्वीकृत हो गया है और {{.check_in}} से {{.check_out}} तक कमरा {{.room_number}}, {{.building}} आपके लिए आरक्षित है।{{if .dining_hall}} आपका भोजन {{.dining_hall}} में है।{{end}}",
+	},
+	{
+		Event:    EventCheckInReminder,
+		Language: "en",
+		Subject:  "See you tomorrow",
+		Body:     "Hello {{.name}}, a reminder that your stay begins on {{.check_in}} in room {{.room_number}}, {{.building}}.{{if .dining_hall}} Your meals are at {{.dining_hall}}.{{end}} Please collect your keys at the front desk.",
+	},
+	{
+		Event:    EventCheckInReminder,
+		Language: "hi",
+		Subject:  "कल मिलते हैं",
+		Body:     "नमस्ते {{.name}}, याद दिला दें कि आपका प्रवास {{.check_in}} को कमरा {{.room_number}}, {{.building}} में शुरू हो रहा है।{{if .dining_hall}} आपका भोजन {{.dining_hall}} में है।{{end}} कृपया चाबी फ्रंट डेस्क से लें।",
+	},
+	{
+		Event:    EventCheckOutReminder,
+		Language: "en",
+		Subject:  "Checkout today",
+		Body:     "Hello {{.name}}, your checkout from room {{.room_number}}, {{.building}} is today, {{.check_out}}. Please return your keys at the front desk before leaving.",
+	},
+	{
+		Event:    EventCheckOutReminder,
+		Language: "hi",
+		Subject:  "आज चेकआउट है",
+		Body:     "नमस्ते {{.name}}, कमरा {{.room_number}}, {{.building}} से आपका चेकआउट आज, {{.check_out}} को है। कृपया जाने से पहले चाबी फ्रंट डेस्क पर लौटा दें।",
+	},
+	{
+		Event:    EventPaymentSuccess,
+		Language: "en",
+		Subject:  "Payment received{{if .invoice_number}}, invoice {{.invoice_number}}{{end}}",
+		Body:     "Hello {{.name}}, we have received your payment of Rs. {{.amount}} for {{.description}}.{{if .invoice_number}} Invoice {{.invoice_number}}.{{end}} Thank you.",
+	},
+	{
+		Event:    EventPaymentSuccess,
+		Language: "hi",
+		Subject:  "भुगतान प्राप्त हुआ",
+		Body:     "नमस्ते {{.name}}, {{.description}} के लिए आपका Rs. {{.amount}} का भुगतान प्राप्त हुआ।{{if .invoice_number}} बिल संख्या {{.invoice_number}}।{{end}} धन्यवाद।",
+	},
+	{
+		Event:    EventRefundProcessed,
+		Language: "en",
+		Subject:  "Your refund has been processed",
+		Body:     "Hello {{.name}}, a refund of Rs. {{.amount}} has been processed{{if .reason}} ({{.reason}}){{end}}. It may take a few days to reach your account.",
+	},
+	{
+		Event:    EventRefundProcessed,
+		Language: "hi",
+		Subject:  "आपका रिफंड हो गया है",
+		Body:     "नमस्ते {{.name}}, Rs. {{.amount}} का रिफंड कर दिया गया है{{if .reason}} ({{.reason}}){{end}}। आपके खाते में पहुँचने में कुछ दिन लग सकते हैं।",
+	},
+	{
+		Event:    EventCheckOutOverdue,
+		Language: "en",
+		Subject:  "{{.count}} overdue checkouts",
+		Body:     "Hello {{.name}}, {{.count}} rooms are past their checkout date and not checked out:\n{{.rooms}}",
+	},
+	{
+		Event:    EventCheckOutOverdue,
+		Language: "hi",
+		Subject:  "{{.count}} चेकआउट बाकी",
+		Body:     "नमस्ते {{.name}}, {{.count}} कमरों की चेकआउट तारीख निकल चुकी है पर चेकआउट नहीं हुआ:\n{{.rooms}}",
+	},
+}
@@ -71,12 +71,16 @@ func SetupRoutes(r *gin.Engine) {
 			rooms.GET("/buildings", handlers.GetBuildings)
 			rooms.GET("/floors", handlers.GetFloors)
 			rooms.PUT("/:id/toggle-cleaning", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.ToggleRoomCleaning)
+			rooms.POST("/:id/images", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.UploadRoomImages)
+			rooms.DELETE("/:id/images/:image_id", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.DeleteRoomImage)
 
 			//Room Category
 			rooms.POST("/create-room-category", middleware.RequireRole(models.RoleSuperAdmin), handlers.CreateRoomCategory)
 			rooms.GET("/get-room-categories", middleware.RequireRole(models.RoleSuperAdmin), handlers.GetRoomCategories)
 			rooms.PUT("/update-room-category/:id", middleware.RequireRole(models.RoleSuperAdmin), handlers.UpdateRoomCategory)
 			rooms.DELETE("/delete-room-category/:id", middleware.RequireRole(models.RoleSuperAdmin), handlers.DeleteRoomCategory)
+			rooms.POST("/room-category/:id/images", middleware.RequireRole(models.RoleSuperAdmin), handlers.UploadRoomCategoryImages)
+			rooms.DELETE("/room-category/:id/images/:image_id", middleware.RequireRole(models.RoleSuperAdmin), handlers.DeleteRoomCategoryImage)
 		}
 
 		// Room request routes
@@ -88,6 +92,8 @@ func SetupRoutes(r *gin.Engine) {
 			requests.PUT("/:id/process", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.ProcessRoomRequest)
 			requests.PUT("/:id/admin-update", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.AdminUpdateRoomRequest)
 			requests.PUT("/:id", handlers.UpdateRoomRequest)
+			requests.GET("/:id/chitthi", handlers.GetRoomRequestChitthi)
+			requests.PUT("/:id/chitthi", handlers.ReplaceRoomRequestChitthi)
 			requests.DELETE("/:id", handlers.DeleteRoomRequest)
 		}
 
@@ -104,7 +110,14 @@ func SetupRoutes(r *gin.Engine) {
 		{
 			foodPasses.POST("/generate", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.GenerateFoodPasses)
 			foodPasses.GET("/user/:user_id", handlers.GetUserFoodPasses)
+			foodPasses.GET("/wallet", handlers.GetFoodPassWallet)
+			foodPasses.GET("/print", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.PrintFoodPasses)
+			foodPasses.GET("/:id/qr.png", handlers.GetFoodPassQR)
+			foodPasses.GET("/:id/qr.svg", handlers.GetFoodPassQR)
 			foodPasses.POST("/scan", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.ScanFoodPass)
+			foodPasses.POST("/transfer", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.TransferFoodPasses)
+			foodPasses.POST("/guest", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.CreateGuestFoodPasses)
+			foodPasses.GET("/guest-quota", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.GetGuestPassQuota)
 			foodPasses.PUT("/:id", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.UpdateFoodPass)
 
 			//Food pass category
@@ -114,19 +127,105 @@ func SetupRoutes(r *gin.Engine) {
 			foodPasses.DELETE("/delete-pass-category/:id", middleware.RequireRole(models.RoleSuperAdmin), handlers.DeleteFoodPassCategory)
 		}
 
+		// Housekeeping routes
+		housekeeping := protected.Group("/housekeeping")
+		housekeeping.Use(middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff, models.RoleHousekeeping))
+		{
+			housekeeping.GET("/board", handlers.GetHousekeepingBoard)
+			housekeeping.GET("/tasks", handlers.GetCleaningTasks)
+			housekeeping.POST("/tasks", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.CreateCleaningTask)
+			housekeeping.PUT("/tasks/:id/assign", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.AssignCleaningTask)
+			housekeeping.PUT("/tasks/:id/start", handlers.StartCleaningTask)
+			housekeeping.PUT("/tasks/:id/complete", handlers.CompleteCleaningTask)
+			housekeeping.PUT("/tasks/:id/inspect", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.InspectCleaningTask)
+		}
+
+		// Maintenance routes
+		maintenance := protected.Group("/maintenance")
+		maintenance.Use(middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff, models.RoleHousekeeping))
+		{
+			maintenance.POST("/tickets", handlers.CreateMaintenanceTicket)
+			maintenance.GET("/tickets", handlers.GetMaintenanceTickets)
+			maintenance.GET("/tickets/:id", handlers.GetMaintenanceTicket)
+			maintenance.PUT("/tickets/:id", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.UpdateMaintenanceTicket)
+			maintenance.GET("/reports/recurring", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.GetRecurringMaintenanceIssues)
+		}
+
 		// Payment routes
 		payments := protected.Group("/payments")
 		{
 			payments.POST("/create", handlers.CreatePayment)
 			payments.POST("/verify", handlers.VerifyPayment)
 			payments.POST("/refund", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.ProcessRefund)
+			payments.POST("/offline", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.RecordOfflinePayment)
 			payments.GET("/my-payments", handlers.GetUserPayments)
 			payments.GET("/:id", handlers.GetPaymentByID)
+			payments.GET("/:id/receipt.pdf", handlers.GetPaymentReceipt)
 			payments.GET("/request/:request_id", handlers.GetPaymentByRequestID)
 			payments.GET("/", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.GetAllPayments)
 			payments.PUT("/:id/status", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.UpdatePaymentStatus)
 		}
 
+		// Cash desk routes
+		cashDesk := protected.Group("/cash-desk")
+		cashDesk.Use(middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff))
+		{
+			cashDesk.GET("/report", handlers.GetCashDeskReport)
+			cashDesk.POST("/close", handlers.CloseCashDesk)
+			cashDesk.GET("/closings", handlers.GetCashDeskClosings)
+		}
+
+		// Notification routes
+		protected.PUT("/profile/notifications", handlers.UpdateNotificationPreferences)
+
+		// Live event stream, filtered by role inside the handler
+		protected.GET("/events/stream", handlers.StreamEvents)
+		notifications := protected.Group("/notifications")
+		notifications.Use(middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff))
+		{
+			notifications.GET("/", handlers.GetNotifications)
+			notifications.GET("/events", handlers.GetNotificationEvents)
+			notifications.GET("/stats", handlers.GetNotificationStats)
+			notifications.POST("/send", handlers.SendNotification)
+			notifications.GET("/templates", handlers.GetNotificationTemplates)
+			notifications.POST("/templates", middleware.RequireRole(models.RoleSuperAdmin), handlers.CreateNotificationTemplate)
+			notifications.PUT("/templates/:id", middleware.RequireRole(models.RoleSuperAdmin), handlers.UpdateNotificationTemplate)
+			notifications.DELETE("/templates/:id", middleware.RequireRole(models.RoleSuperAdmin), handlers.DeleteNotificationTemplate)
+			notifications.GET("/:id", handlers.GetNotification)
+			notifications.POST("/:id/resend", handlers.ResendNotification)
+		}
+
+		// Room type routes
+		roomTypes := protected.Group("/room-types")
+		{
+			roomTypes.GET("/", handlers.GetRoomTypes)
+			roomTypes.GET("/:code", handlers.GetRoomType)
+			roomTypes.POST("/", middleware.RequireRole(models.RoleSuperAdmin), handlers.CreateRoomType)
+			roomTypes.POST("/migrate", middleware.RequireRole(models.RoleSuperAdmin), handlers.MigrateRoomTypesHandler)
+			roomTypes.PUT("/:code", middleware.RequireRole(models.RoleSuperAdmin), handlers.UpdateRoomType)
+			roomTypes.DELETE("/:code", middleware.RequireRole(models.RoleSuperAdmin), handlers.DeleteRoomType)
+		}
+
+		// Tariff routes
+		tariffs := protected.Group("/tariffs")
+		{
+			tariffs.GET("/", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.GetTariffs)
+			tariffs.POST("/", middleware.RequireRole(models.RoleSuperAdmin), handlers.CreateTariff)
+			tariffs.PUT("/:id", middleware.RequireRole(models.RoleSuperAdmin), handlers.UpdateTariff)
+			tariffs.DELETE("/:id", middleware.RequireRole(models.RoleSuperAdmin), handlers.DeleteTariff)
+			tariffs.GET("/overrides", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.GetTariffOverrides)
+			tariffs.POST("/overrides", middleware.RequireRole(models.RoleSuperAdmin), handlers.CreateTariffOverride)
+			tariffs.PUT("/overrides/:id", middleware.RequireRole(models.RoleSuperAdmin), handlers.UpdateTariffOverride)
+			tariffs.DELETE("/overrides/:id", middleware.RequireRole(models.RoleSuperAdmin), handlers.DeleteTariffOverride)
+		}
+
+		// Quote routes
+		quotes := protected.Group("/quotes")
+		{
+			quotes.POST("/", handlers.CreateQuote)
+			quotes.GET("/:id", handlers.GetQuote)
+		}
+
 		// Room type cost routes (admin only)
 		roomTypeCosts := protected.Group("/room-type-costs")
 		{
@@ -146,10 +245,61 @@ func SetupRoutes(r *gin.Engine) {
 			userTypeConfigs.DELETE("/:id", middleware.RequireRole(models.RoleSuperAdmin), handlers.DeleteUserTypeConfig)
 		}
 
+		// Occupancy and operations analytics (?from=&to=&building=)
+		protected.GET("/analytics", middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff), handlers.GetAnalytics)
+
+		// Financial reports (?from=&to=, ?format=csv|xlsx|ndjson to export)
+		finance := protected.Group("/reports/finance")
+		finance.Use(middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff))
+		{
+			finance.GET("/collections", handlers.GetCollectionsReport)
+			finance.GET("/refunds", handlers.GetRefundsReport)
+			finance.GET("/deposits", handlers.GetDepositsReport)
+			finance.GET("/foc", handlers.GetFOCReport)
+			finance.GET("/pending-refunds", handlers.GetPendingRefundsReport)
+		}
+
+		// Night audit reports
+		nightAudits := protected.Group("/night-audits")
+		nightAudits.Use(middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff))
+		{
+			nightAudits.GET("/", handlers.GetNightAudits)
+			nightAudits.GET("/:date", handlers.GetNightAudit)
+			nightAudits.POST("/run", middleware.RequireRole(models.RoleSuperAdmin), handlers.RunNightAudit)
+		}
+
+		// Data export routes (?format=csv|xlsx|ndjson)
+		exports := protected.Group("/exports")
+		exports.Use(middleware.RequireRole(models.RoleSuperAdmin, models.RoleStaff))
+		{
+			exports.GET("/rooms", handlers.ExportRooms)
+			exports.GET("/room-requests", handlers.ExportRoomRequests)
+			exports.GET("/users", handlers.ExportUsers)
+			exports.GET("/payments", handlers.ExportPayments)
+			exports.GET("/food-passes", handlers.ExportFoodPasses)
+		}
+
+		// Background job routes (admin only)
+		jobs := protected.Group("/admin/jobs")
+		jobs.Use(middleware.RequireRole(models.RoleSuperAdmin))
+		{
+			jobs.GET("/", handlers.GetJobs)
+			jobs.GET("/:name/runs", handlers.GetJobRuns)
+			jobs.POST("/:name/run", handlers.TriggerJob)
+			jobs.PUT("/:name/pause", handlers.PauseJob)
+			jobs.PUT("/:name/resume", handlers.ResumeJob)
+		}
+
+		// Consistency check route (admin only)
+		protected.POST("/admin/consistency-check", middleware.RequireRole(models.RoleSuperAdmin), handlers.CheckConsistency)
+
 		// Deposit check route (public - for checking if deposit required)
 		protected.GET("/check-deposit", handlers.CheckUserRequiresDeposit)
 	}
 
 	// Public webhook route (no auth required - called by Razorpay)
 	r.POST("/webhooks/razorpay", handlers.HandleWebhook)
+
+	// Delivery status callbacks from messaging providers, authenticated by token
+	r.POST("/webhooks/notifications/:channel", handlers.HandleNotificationCallback)
 }
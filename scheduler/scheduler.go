@@ -0,0 +1,512 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // Alpine images ship without zoneinfo
+
+	"github.com/go-co-op/gocron"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"utara_backend/config"
+	"utara_backend/models"
+)
+
+const (
+	defaultTimezone = "Asia/Kolkata"
+	defaultTimeout  = 30 * time.Minute
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobLocked   = errors.New("job is already running on another instance")
+)
+
+// JobFunc runs a job and returns a short human readable result for the run
+// history. ctx is cancelled once the job's Timeout passes, so the job must use
+// it for its database calls and stop when it is done.
+type JobFunc func(ctx context.Context) (string, error)
+
+// Job is a background job definition registered in code. Cron, Timezone and
+// Enabled are defaults which can be overridden from the DB or env.
+type Job struct {
+	Name        string
+	Description string
+	Cron        string
+	Timezone    string
+	Enabled     bool
+	Timeout     time.Duration
+	Run         JobFunc
+}
+
+// JobConfig is the effective configuration of a job after applying overrides
+type JobConfig struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Cron        string     `json:"cron"`
+	Timezone    string     `json:"timezone"`
+	Enabled     bool       `json:"enabled"`
+	Paused      bool       `json:"paused"`
+	PausedBy    string     `json:"paused_by,omitempty"`
+	PausedAt    *time.Time `json:"paused_at,omitempty"`
+}
+
+// JobStatus is returned by the admin job listing
+type JobStatus struct {
+	JobConfig
+	Scheduled bool           `json:"scheduled"`
+	NextRun   *time.Time     `json:"next_run,omitempty"`
+	Running   bool           `json:"running"`
+	LastRun   *models.JobRun `json:"last_run,omitempty"`
+}
+
+var (
+	mu        sync.RWMutex
+	registry  = map[string]Job{}
+	scheduled = map[string]*gocron.Job{}
+	cron      *gocron.Scheduler
+	instance  = instanceID()
+)
+
+// Register adds a job to the registry. It must be called before Start.
+func Register(job Job) {
+	if job.Timeout == 0 {
+		job.Timeout = defaultTimeout
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	registry[job.Name] = job
+}
+
+// Get returns a registered job by name
+func Get(name string) (Job, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	job, ok := registry[name]
+	return job, ok
+}
+
+// Jobs returns all registered jobs sorted by name
+func Jobs() []Job {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	jobs := make([]Job, 0, len(registry))
+	for _, job := range registry {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs
+}
+
+// Start schedules every enabled job. It needs env and the DB to be loaded first.
+func Start() error {
+	s := gocron.NewScheduler(time.UTC)
+
+	for _, job := range Jobs() {
+		cfg, err := Resolve(context.Background(), job)
+		if err != nil {
+			return err
+		}
+		if !cfg.Enabled {
+			log.Printf("Scheduler: job %s is disabled", job.Name)
+			continue
+		}
+
+		name := job.Name
+		loc, _ := time.LoadLocation(cfg.Timezone)
+		j, err := s.Cron(fmt.Sprintf("CRON_TZ=%s %s", cfg.Timezone, cfg.Cron)).Name(name).Tag(name).Do(func() {
+			slot := time.Now().In(loc).Truncate(time.Minute).Format(time.RFC3339)
+			runScheduled(name, slot)
+		})
+		if err != nil {
+			return fmt.Errorf("could not schedule job %s (%q): %w", name, cfg.Cron, err)
+		}
+
+		mu.Lock()
+		scheduled[name] = j
+		mu.Unlock()
+		log.Printf("Scheduler: job %s scheduled with %q (%s)", name, cfg.Cron, cfg.Timezone)
+	}
+
+	s.StartAsync()
+
+	mu.Lock()
+	cron = s
+	mu.Unlock()
+
+	for name, j := range scheduledJobs() {
+		log.Printf("Scheduler: next run of %s at %s", name, j.NextRun().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Stop stops the scheduler; jobs that are already running finish on their own
+func Stop() {
+	mu.Lock()
+	defer mu.Unlock()
+	if cron != nil {
+		cron.Stop()
+		cron = nil
+	}
+	scheduled = map[string]*gocron.Job{}
+}
+
+func scheduledJobs() map[string]*gocron.Job {
+	mu.RLock()
+	defer mu.RUnlock()
+	jobs := make(map[string]*gocron.Job, len(scheduled))
+	for name, j := range scheduled {
+		jobs[name] = j
+	}
+	return jobs
+}
+
+// Resolve returns the effective config of a job: code defaults, then the
+// scheduled_jobs document, then env vars.
+func Resolve(ctx context.Context, job Job) (JobConfig, error) {
+	cfg := JobConfig{
+		Name:        job.Name,
+		Description: job.Description,
+		Cron:        job.Cron,
+		Timezone:    job.Timezone,
+		Enabled:     job.Enabled,
+	}
+	if cfg.Timezone == "" {
+		cfg.Timezone = os.Getenv("SCHEDULER_TIMEZONE")
+	}
+	if cfg.Timezone == "" {
+		cfg.Timezone = defaultTimezone
+	}
+
+	var doc models.ScheduledJob
+	err := config.DB.Collection("scheduled_jobs").FindOne(ctx, bson.M{"_id": job.Name}).Decode(&doc)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return cfg, fmt.Errorf("error loading job %s: %w", job.Name, err)
+	}
+	if err == nil {
+		if doc.Cron != "" {
+			cfg.Cron = doc.Cron
+		}
+		if doc.Timezone != "" {
+			cfg.Timezone = doc.Timezone
+		}
+		if doc.Enabled != nil {
+			cfg.Enabled = *doc.Enabled
+		}
+		cfg.Paused = doc.Paused
+		cfg.PausedBy = doc.PausedBy
+		cfg.PausedAt = doc.PausedAt
+	}
+
+	prefix := "JOB_" + strings.ToUpper(strings.ReplaceAll(job.Name, "-", "_")) + "_"
+	if v := os.Getenv(prefix + "CRON"); v != "" {
+		cfg.Cron = v
+	}
+	if v := os.Getenv(prefix + "TIMEZONE"); v != "" {
+		cfg.Timezone = v
+	}
+	if v := os.Getenv(prefix + "ENABLED"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid %sENABLED value %q", prefix, v)
+		}
+		cfg.Enabled = enabled
+	}
+
+	if _, err := time.LoadLocation(cfg.Timezone); err != nil {
+		return cfg, fmt.Errorf("invalid timezone %q for job %s: %w", cfg.Timezone, job.Name, err)
+	}
+	return cfg, nil
+}
+
+// Status returns the effective config and runtime state of every registered job
+func Status(ctx context.Context) ([]JobStatus, error) {
+	active := scheduledJobs()
+	var statuses []JobStatus
+
+	for _, job := range Jobs() {
+		cfg, err := Resolve(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+		status := JobStatus{JobConfig: cfg}
+
+		if j, ok := active[job.Name]; ok {
+			status.Scheduled = true
+			next := j.NextRun()
+			status.NextRun = &next
+		}
+
+		var lock models.JobLock
+		err = config.DB.Collection("job_locks").FindOne(ctx, bson.M{"_id": job.Name}).Decode(&lock)
+		if err == nil && lock.LockedUntil.After(time.Now()) {
+			status.Running = true
+		}
+
+		var lastRun models.JobRun
+		err = config.DB.Collection("job_runs").FindOne(
+			ctx,
+			bson.M{"job_name": job.Name},
+			options.FindOne().SetSort(bson.D{{Key: "started_at", Value: -1}}),
+		).Decode(&lastRun)
+		if err == nil {
+			status.LastRun = &lastRun
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Runs returns the most recent runs of a job
+func Runs(ctx context.Context, name string, limit int64) ([]models.JobRun, error) {
+	if _, ok := Get(name); !ok {
+		return nil, ErrJobNotFound
+	}
+
+	cursor, err := config.DB.Collection("job_runs").Find(
+		ctx,
+		bson.M{"job_name": name},
+		options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}}).SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	runs := []models.JobRun{}
+	if err := cursor.All(ctx, &runs); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// SetPaused pauses or resumes a job on every replica. A paused job stays
+// scheduled but its runs are skipped until it is resumed.
+func SetPaused(ctx context.Context, name string, paused bool, by string) error {
+	if _, ok := Get(name); !ok {
+		return ErrJobNotFound
+	}
+
+	now := time.Now()
+	update := bson.M{"$set": bson.M{"paused": paused, "updated_at": now}}
+	if paused {
+		update["$set"].(bson.M)["paused_by"] = by
+		update["$set"].(bson.M)["paused_at"] = now
+	} else {
+		update["$unset"] = bson.M{"paused_by": "", "paused_at": ""}
+	}
+
+	_, err := config.DB.Collection("scheduled_jobs").UpdateOne(
+		ctx,
+		bson.M{"_id": name},
+		update,
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// Trigger starts a manual run of a job in the background and returns its run
+// record. Manual runs ignore the enabled and paused flags.
+func Trigger(name, triggeredBy string) (*models.JobRun, error) {
+	job, ok := Get(name)
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	run, err := begin(job, models.JobTriggerManual, triggeredBy, "manual-"+primitive.NewObjectID().Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	go finish(job, run)
+	return run, nil
+}
+
+// RunNow runs a job synchronously and returns the finished run record
+func RunNow(name, triggeredBy string) (*models.JobRun, error) {
+	job, ok := Get(name)
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	run, err := begin(job, models.JobTriggerManual, triggeredBy, "manual-"+primitive.NewObjectID().Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	finish(job, run)
+	return run, nil
+}
+
+func runScheduled(name, slot string) {
+	job, ok := Get(name)
+	if !ok {
+		return
+	}
+
+	cfg, err := Resolve(context.Background(), job)
+	if err != nil {
+		log.Printf("Scheduler: skipping %s: %v", name, err)
+		return
+	}
+	if cfg.Paused {
+		log.Printf("Scheduler: job %s is paused, skipping run", name)
+		return
+	}
+
+	run, err := begin(job, models.JobTriggerSchedule, "", slot)
+	if err != nil {
+		if !errors.Is(err, ErrJobLocked) {
+			log.Printf("Scheduler: could not start %s: %v", name, err)
+		}
+		return
+	}
+
+	finish(job, run)
+}
+
+// begin takes the job lock and records the start of a run
+func begin(job Job, trigger models.JobTrigger, triggeredBy, slot string) (*models.JobRun, error) {
+	ctx := context.Background()
+
+	acquired, err := acquireLock(ctx, job, slot)
+	if err != nil {
+		return nil, fmt.Errorf("error acquiring lock: %w", err)
+	}
+	if !acquired {
+		return nil, ErrJobLocked
+	}
+
+	run := &models.JobRun{
+		JobName:     job.Name,
+		Trigger:     trigger,
+		TriggeredBy: triggeredBy,
+		Instance:    instance,
+		Status:      models.JobRunRunning,
+		StartedAt:   time.Now(),
+	}
+
+	result, err := config.DB.Collection("job_runs").InsertOne(ctx, run)
+	if err != nil {
+		releaseLock(ctx, job.Name)
+		return nil, fmt.Errorf("error recording job run: %w", err)
+	}
+	run.ID = result.InsertedID.(primitive.ObjectID)
+
+	return run, nil
+}
+
+// finish executes the job, stores the outcome of the run and releases the lock
+func finish(job Job, run *models.JobRun) {
+	ctx, cancel := context.WithTimeout(context.Background(), job.Timeout)
+	defer cancel()
+
+	log.Printf("Scheduler: starting %s (%s)", job.Name, run.Trigger)
+
+	result, err := safeRun(ctx, job)
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.DurationMs = finishedAt.Sub(run.StartedAt).Milliseconds()
+	run.Result = result
+	run.Status = models.JobRunSuccess
+	if err != nil {
+		run.Status = models.JobRunFailed
+		run.Error = err.Error()
+		log.Printf("ERROR: job %s failed after %dms: %v", job.Name, run.DurationMs, err)
+	} else {
+		log.Printf("SUCCESS: job %s finished in %dms: %s", job.Name, run.DurationMs, result)
+	}
+
+	_, dbErr := config.DB.Collection("job_runs").UpdateOne(
+		context.Background(),
+		bson.M{"_id": run.ID},
+		bson.M{"$set": bson.M{
+			"status":      run.Status,
+			"result":      run.Result,
+			"error":       run.Error,
+			"finished_at": run.FinishedAt,
+			"duration_ms": run.DurationMs,
+		}},
+	)
+	if dbErr != nil {
+		log.Printf("Scheduler: error recording result of %s: %v", job.Name, dbErr)
+	}
+
+	releaseLock(context.Background(), job.Name)
+}
+
+func safeRun(ctx context.Context, job Job) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// acquireLock claims the job lease for this instance. The upsert fails with a
+// duplicate key error when another replica holds the lease or already claimed
+// this schedule slot.
+func acquireLock(ctx context.Context, job Job, slot string) (bool, error) {
+	now := time.Now()
+	_, err := config.DB.Collection("job_locks").UpdateOne(
+		ctx,
+		bson.M{
+			"_id":          job.Name,
+			"locked_until": bson.M{"$lte": now},
+			"last_slot":    bson.M{"$ne": slot},
+		},
+		bson.M{"$set": bson.M{
+			"locked_by":    instance,
+			"locked_at":    now,
+			"locked_until": now.Add(job.Timeout + time.Minute),
+			"last_slot":    slot,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func releaseLock(ctx context.Context, name string) {
+	_, err := config.DB.Collection("job_locks").UpdateOne(
+		ctx,
+		bson.M{"_id": name, "locked_by": instance},
+		bson.M{"$set": bson.M{"locked_until": time.Now()}},
+	)
+	if err != nil {
+		log.Printf("Scheduler: error releasing lock for %s: %v", name, err)
+	}
+}
+
+func instanceID() string {
+	if id := os.Getenv("INSTANCE_ID"); id != "" {
+		return id
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var ErrNotFound = errors.New("file not found")
+
+// Storage is a blob store for uploaded files. Keys are slash separated
+// paths such as "rooms/<id>/<file>.jpg".
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL of a key
+	URL(key string) string
+}
+
+// Files is the storage backend chosen by Init, local disk until then
+var Files Storage = NewLocal("./uploads", "/uploads")
+
+// Private holds files which must only be served through authenticated
+// handlers, such as chitthi letters. Its URLs are never handed out.
+var Private Storage = NewLocal("./private", "")
+
+// Init configures Files and Private from the environment. STORAGE_DRIVER
+// selects "local" (default) or "s3". Local private files live in
+// STORAGE_PRIVATE_DIR, which must not be served statically; on S3 they go to
+// S3_PRIVATE_BUCKET, which is required and must not be the public bucket.
+func Init() error {
+	driver := strings.ToLower(os.Getenv("STORAGE_DRIVER"))
+	switch driver {
+	case "", "local":
+		Files = NewLocal(getenv("STORAGE_LOCAL_DIR", "./uploads"), getenv("STORAGE_PUBLIC_URL", "/uploads"))
+		Private = NewLocal(getenv("STORAGE_PRIVATE_DIR", "./private"), "")
+	case "s3":
+		cfg := S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    getenv("S3_REGION", "us-east-1"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") != "false",
+		}
+		public, err := NewS3(cfg)
+		if err != nil {
+			return err
+		}
+		privateBucket := os.Getenv("S3_PRIVATE_BUCKET")
+		if privateBucket == "" {
+			return errors.New("S3_PRIVATE_BUCKET is required with the s3 driver")
+		}
+		if privateBucket == cfg.Bucket {
+			return errors.New("S3_PRIVATE_BUCKET must not be the public S3_BUCKET")
+		}
+		cfg.Bucket = privateBucket
+		cfg.PublicURL = ""
+		private, err := NewS3(cfg)
+		if err != nil {
+			return err
+		}
+		Files, Private = public, private
+	default:
+		return fmt.Errorf("unknown STORAGE_DRIVER %q", driver)
+	}
+	return nil
+}
+
+// cleanKey rejects keys that could escape the storage root
+func cleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(key, "/")
+	if key == "" || strings.Contains(key, "..") || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return key, nil
+}
+
+func getenv(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -10,7 +10,13 @@ import (
 // GeneratePublicRoomRequestID generates a unique, shareable ID for room requests
 // Format: REQ-YYYYMMDD-XXXX (where XXXX is 4 random alphanumeric characters)
 func GeneratePublicRoomRequestID() string {
-	timestamp := time.Now().Format("20060102")
+	return PublicRoomRequestIDFor(time.Now())
+}
+
+// PublicRoomRequestIDFor generates a public ID dated for a request created
+// at t, for requests made before they had one
+func PublicRoomRequestIDFor(t time.Time) string {
+	timestamp := t.Format("20060102")
 	const allowedChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	b := make([]byte, 4)
 	for i := range b {
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// MaxImagePixels guards against decompression bombs: 40 megapixels
+	MaxImagePixels = 40_000_000
+	ThumbnailSize  = 320
+)
+
+var ErrUnsupportedImage = errors.New("only JPEG, PNG and WebP images are allowed")
+
+// ImageExtensions maps the image types accepted for upload to a file extension
+var ImageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// ImageInfo describes a validated image
+type ImageInfo struct {
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+}
+
+// InspectImage sniffs the content type from the data rather than trusting
+// the file name or upload headers, and checks the image decodes
+func InspectImage(data []byte) (ImageInfo, error) {
+	contentType := http.DetectContentType(data)
+	ext, ok := ImageExtensions[contentType]
+	if !ok {
+		return ImageInfo{}, ErrUnsupportedImage
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ImageInfo{}, fmt.Errorf("invalid image: %w", err)
+	}
+	if cfg.Width*cfg.Height > MaxImagePixels {
+		return ImageInfo{}, fmt.Errorf("image is too large: %dx%d", cfg.Width, cfg.Height)
+	}
+
+	return ImageInfo{ContentType: contentType, Extension: ext, Width: cfg.Width, Height: cfg.Height}, nil
+}
+
+// GenerateThumbnail returns a JPEG that fits in a size x size box, keeping
+// the aspect ratio. Smaller images aren't scaled up.
+func GenerateThumbnail(data []byte, size int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > size || h > size {
+		if w >= h {
+			h = max(1, h*size/w)
+			w = size
+		} else {
+			w = max(1, w*size/h)
+			h = size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	// JPEG has no alpha, so flatten transparent PNGs onto white
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"bytes"
 	"encoding/base64"
+	"fmt"
 
 	"github.com/skip2/go-qrcode"
 )
@@ -12,13 +13,7 @@ func GenerateQRCode(data string) (string, error) {
 	var buf bytes.Buffer
 
 	// Generate QR code with medium recovery level and size 256
-	qr, err := qrcode.New(data, qrcode.Medium)
-	if err != nil {
-		return "", err
-	}
-
-	// Get PNG bytes
-	png, err := qr.PNG(256)
+	png, err := GenerateQRCodePNG(data, 256)
 	if err != nil {
 		return "", err
 	}
@@ -33,3 +28,38 @@ func GenerateQRCode(data string) (string, error) {
 
 	return "data:image/png;base64," + buf.String(), nil
 }
+
+// GenerateQRCodePNG generates a PNG QR code of the given size in pixels
+func GenerateQRCodePNG(data string, size int) ([]byte, error) {
+	qr, err := qrcode.New(data, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	return qr.PNG(size)
+}
+
+// GenerateQRCodeSVG generates an SVG QR code scaled to the given size in pixels
+func GenerateQRCodeSVG(data string, size int) ([]byte, error) {
+	qr, err := qrcode.New(data, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bitmap includes the quiet zone, one unit per module
+	bitmap := qr.Bitmap()
+	modules := len(bitmap)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, modules, modules)
+	buf.WriteString(`<rect width="100%" height="100%" fill="#ffffff"/><path fill="#000000" d="`)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,12 @@
+package utils
+
+import "time"
+
+// IST is the timezone all stay and meal dates are based on
+var IST = time.FixedZone("IST", 5*60*60+30*60)
+
+// StartOfDayIST returns IST midnight of the day t falls on in IST
+func StartOfDayIST(t time.Time) time.Time {
+	t = t.In(IST)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, IST)
+}